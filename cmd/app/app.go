@@ -1,23 +1,429 @@
 package app
 
 import (
-	"log"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
 
+	"aadhaar-user-service/internals/auth"
 	"aadhaar-user-service/internals/config"
+	"aadhaar-user-service/internals/cursor"
 	"aadhaar-user-service/internals/database"
+	"aadhaar-user-service/internals/dedup"
+	"aadhaar-user-service/internals/encryption"
+	"aadhaar-user-service/internals/logging"
+	"aadhaar-user-service/internals/masking"
+	"aadhaar-user-service/internals/metrics"
+	"aadhaar-user-service/internals/migrator"
+	"aadhaar-user-service/internals/rbac"
 	"aadhaar-user-service/internals/server"
+	"aadhaar-user-service/internals/tracing"
+	"aadhaar-user-service/internals/validator"
+	"aadhaar-user-service/migrations"
+	auditModel "aadhaar-user-service/models/audit"
+	userModel "aadhaar-user-service/models/users"
+	"aadhaar-user-service/services/audit"
+	"aadhaar-user-service/services/users"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"gorm.io/gorm"
 )
 
-func Setup() {
-	database.Connect()
+// App is one instance of the service: its database handle, repositories, services, HTTP server,
+// encryption keys, auth, RBAC and masking policies and metrics registry. Several can run in one
+// process; only logging and tracing are process-wide.
+type App struct {
+	cfg   config.Config
+	db    *gorm.DB
+	fiber *fiber.App
+	hooks []shutdownHook
+}
+
+// New builds the service from cfg. With the postgres driver it connects to the database and
+// applies pending migrations; with the memory driver it keeps all data in memory.
+func New(cfg config.Config) (*App, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	keys, err := loadKeys(cfg.Encryption)
+	if err != nil {
+		return nil, fmt.Errorf("load encryption keys: %w", err)
+	}
+	authenticator, err := auth.New(cfg.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("load authentication keys: %w", err)
+	}
+	roles, err := rbac.New(cfg.RBAC)
+	if err != nil {
+		return nil, fmt.Errorf("load RBAC policy: %w", err)
+	}
+	masks, err := masking.New(cfg.Masking)
+	if err != nil {
+		return nil, fmt.Errorf("load masking policy: %w", err)
+	}
+
+	cursors, err := cursor.New(cfg.Pagination.CursorSecret)
+	if err != nil {
+		return nil, fmt.Errorf("create cursor key: %w", err)
+	}
+	if cfg.Pagination.CursorSecret == "" {
+		slog.Warn("No pagination cursor secret configured, cursors only work on this instance until it restarts")
+	}
+
+	a := &App{cfg: cfg}
+	deps := server.Dependencies{
+		Validator: validator.New(),
+		Cursors:   cursors,
+		Dedup:     dedup.Policy{Mode: cfg.Dedup.Mode, Threshold: cfg.Dedup.Threshold},
+		Keys:      keys,
+		Auth:      authenticator,
+		RBAC:      roles,
+		Masking:   masks,
+		Metrics:   metrics.New(),
+	}
+
+	switch cfg.Database.Driver {
+	case config.DriverMemory:
+		deps.Users = userModel.NewMemoryRepository(keys)
+		deps.Audit = auditModel.NewMemoryRepository()
+
+	default:
+		db, err := a.connect(deps.Metrics)
+		if err != nil {
+			a.close(context.Background())
+			return nil, err
+		}
+		deps.DB = db
+		deps.Users = userModel.NewGormRepository(db, keys)
+		deps.Audit = auditModel.NewGormRepository(db)
+	}
+
+	a.fiber = server.New(cfg, deps)
+	return a, nil
+}
+
+// connect opens the database, exposes its query and pool metrics in m and brings the schema up
+// to date
+func (a *App) connect(m *metrics.Metrics) (*gorm.DB, error) {
+	db, err := database.Connect(a.cfg, m)
+	if err != nil {
+		return nil, err
+	}
+	a.db = db
+	a.onShutdown("database", func(context.Context) error {
+		return database.Close(db)
+	})
+
+	sql, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	unregister, err := m.RegisterDBStats(sql, a.cfg.Database.Name)
+	if err != nil {
+		return nil, fmt.Errorf("register pool metrics: %w", err)
+	}
+	a.onShutdown("pool metrics", func(context.Context) error {
+		unregister()
+		return nil
+	})
+
+	mig, err := migrator.New(db, migrations.FS)
+	if err != nil {
+		return nil, fmt.Errorf("load migrations: %w", err)
+	}
+	applied, err := mig.Up(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("apply migrations: %w", err)
+	}
+	slog.Info("Migrations applied", slog.Int("applied", applied), slog.Int("version", mig.Latest()))
+
+	return db, nil
+}
+
+// Fiber returns the Fiber app serving the API
+func (a *App) Fiber() *fiber.App {
+	return a.fiber
+}
+
+// Handler returns the API as a net/http handler, for mounting in another server
+func (a *App) Handler() http.Handler {
+	return adaptor.FiberApp(a.fiber)
+}
+
+// DB returns the database handle, nil with the memory driver
+func (a *App) DB() *gorm.DB {
+	return a.db
+}
+
+// Start listens on the configured address and serves until Shutdown is called or the listener fails
+func (a *App) Start() error {
+	slog.Info("Starting Aadhaar User Service", slog.String("addr", a.cfg.Server.Addr()))
+	return a.fiber.Listen(a.cfg.Server.Addr())
+}
+
+// Run starts the service and blocks until it has shut down, returning the process exit code
+func Run() int {
+	cfg, err := loadConfig()
+	if err != nil {
+		slog.Error("Error loading configuration", logging.Err(err))
+		return ExitStartupError
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		slog.Error("Error setting up tracing", logging.Err(err))
+		return ExitStartupError
+	}
+
+	a, err := New(cfg)
+	if err != nil {
+		slog.Error("Error starting service", logging.Err(err))
+		shutdownTracing(context.Background())
+		return ExitStartupError
+	}
+
+	code := serve(a)
 
-	config.Automigration()
+	// Flush the spans of the drained requests last
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("Error shutting down", slog.String("hook", "tracing"), logging.Err(err))
+		if code == ExitOK {
+			code = ExitShutdownError
+		}
+	}
+
+	slog.Info("Aadhaar User Service stopped", slog.Int("exit_code", code))
+	return code
+}
+
+// RotateKeys re-encrypts stored PII under the active master key and returns the exit code
+func RotateKeys() int {
+	return runCommand("rotate-keys", func(cfg config.Config, db *gorm.DB) error {
+		svc, err := maintenanceService(cfg, db)
+		if err != nil {
+			return err
+		}
+		if err := svc.RotateKeys(context.Background()); err != nil {
+			return fmt.Errorf("rotate encryption keys after %d rows: %w", svc.Reencrypted, err)
+		}
+
+		slog.Info("Re-encrypted rows", slog.Int("reencrypted", svc.Reencrypted))
+		return nil
+	})
+}
+
+// Reindex computes the search columns of users written before migration 008 and returns the
+// exit code
+func Reindex() int {
+	return runCommand("reindex", func(cfg config.Config, db *gorm.DB) error {
+		// Encrypted names are decrypted to compute their keys
+		svc, err := maintenanceService(cfg, db)
+		if err != nil {
+			return err
+		}
+		if err := svc.Reindex(context.Background()); err != nil {
+			return fmt.Errorf("reindex users after %d rows: %w", svc.Reindexed, err)
+		}
+
+		slog.Info("Reindexed users", slog.Int("reindexed", svc.Reindexed))
+		return nil
+	})
+}
+
+// DedupReport scans the live users for likely duplicates and writes the pairs found as CSV to
+// the file named by args[0], or to stdout without one, then returns the exit code
+func DedupReport(args []string) int {
+	return runCommand("dedup-report", func(cfg config.Config, db *gorm.DB) error {
+		svc, err := maintenanceService(cfg, db)
+		if err != nil {
+			return err
+		}
+
+		out := os.Stdout
+		if len(args) > 0 && args[0] != "-" {
+			f, err := os.Create(args[0])
+			if err != nil {
+				return fmt.Errorf("create report file: %w", err)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		if err := svc.DuplicateReport(context.Background(), cfg.Dedup.Threshold); err != nil {
+			return fmt.Errorf("scan for duplicates after %d users: %w", svc.Scanned, err)
+		}
+
+		w := csv.NewWriter(out)
+		w.Write([]string{"user_id", "duplicate_id", "score", "name", "date_of_birth", "gender", "phone", "address"})
+		for _, p := range svc.Report {
+			w.Write([]string{p.UserID.String(), p.Duplicate.UserID.String(), score(p.Score),
+				score(p.Fields.Name), score(p.Fields.DateOfBirth), score(p.Fields.Gender), score(p.Fields.Phone), score(p.Fields.Address)})
+		}
+		if w.Flush(); w.Error() != nil {
+			return fmt.Errorf("write report: %w", w.Error())
+		}
+
+		slog.Info("Duplicate report written", slog.Int("scanned", svc.Scanned), slog.Int("pairs", len(svc.Report)),
+			slog.Float64("threshold", cfg.Dedup.Threshold))
+		return nil
+	})
+}
+
+// score formats a duplicate score for the report
+func score(s float64) string {
+	return strconv.FormatFloat(s, 'f', 3, 64)
+}
+
+// Migrate runs the migrate subcommand: up, down [steps], status or baseline <version>, and
+// returns the exit code
+func Migrate(args []string) int {
+	if len(args) == 0 {
+		slog.Error("Usage: migrate up|down [steps]|status|baseline <version>")
+		return ExitCommandError
+	}
+
+	return runCommand("migrate", func(cfg config.Config, db *gorm.DB) error {
+		m, err := migrator.New(db, migrations.FS)
+		if err != nil {
+			return fmt.Errorf("load migrations: %w", err)
+		}
+
+		ctx := context.Background()
+
+		switch args[0] {
+		case "up":
+			applied, err := m.Up(ctx)
+			if err != nil {
+				return fmt.Errorf("apply migrations: %w", err)
+			}
+			slog.Info("Migrations applied", slog.Int("applied", applied))
 
-	server.Setup()
-	app := server.New()
+		case "down":
+			steps := 1
+			if len(args) > 1 {
+				if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+					return fmt.Errorf("invalid number of steps %q", args[1])
+				}
+			}
+			reverted, err := m.Down(ctx, steps)
+			if err != nil {
+				return fmt.Errorf("revert migrations after %d: %w", reverted, err)
+			}
+			slog.Info("Migrations reverted", slog.Int("reverted", reverted))
 
-	log.Println("Starting Aadhaar User Service on port :3015")
-	if err := app.Listen(":3015"); err != nil {
-		log.Fatalf("Error starting server %v\n", err)
+		case "status":
+			statuses, err := m.Status(ctx)
+			if err != nil {
+				return fmt.Errorf("read migration status: %w", err)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+			for _, s := range statuses {
+				status, appliedAt := "pending", ""
+				if s.Applied {
+					status, appliedAt = "applied", s.AppliedAt.Format(time.RFC3339)
+				}
+				if s.Modified {
+					status = "modified"
+				}
+				if s.Unknown {
+					status = "unknown"
+				}
+				fmt.Fprintf(w, "%03d\t%s\t%s\t%s\n", s.Version, s.Name, status, appliedAt)
+			}
+			w.Flush()
+
+		case "baseline":
+			if len(args) < 2 {
+				return errors.New("usage: migrate baseline <version>")
+			}
+			version, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid version %q", args[1])
+			}
+			if err := m.Baseline(ctx, version); err != nil {
+				return fmt.Errorf("record baseline: %w", err)
+			}
+			slog.Info("Recorded migrations as applied", slog.Int("up_to_version", version))
+
+		default:
+			return fmt.Errorf("unknown migrate command %q", args[0])
+		}
+		return nil
+	})
+}
+
+// runCommand runs a maintenance command against Postgres and returns the exit code. The database
+// is closed however fn returns.
+func runCommand(name string, fn func(cfg config.Config, db *gorm.DB) error) int {
+	cfg, err := loadConfig()
+	if err != nil {
+		slog.Error("Error loading configuration", logging.Err(err))
+		return ExitStartupError
+	}
+
+	if cfg.Database.Driver != config.DriverPostgres {
+		slog.Error("Command requires the postgres database driver", slog.String("command", name), slog.String("driver", cfg.Database.Driver))
+		return ExitStartupError
+	}
+	db, err := database.Connect(cfg, nil)
+	if err != nil {
+		slog.Error("Error connecting to database", logging.Err(err))
+		return ExitStartupError
+	}
+	defer database.Close(db)
+
+	if err := fn(cfg, db); err != nil {
+		slog.Error("Command failed", slog.String("command", name), logging.Err(err))
+		return ExitCommandError
+	}
+	return ExitOK
+}
+
+// maintenanceService builds the user service of the maintenance commands, with the keyring
+// loaded to decrypt and re-encrypt stored PII
+func maintenanceService(cfg config.Config, db *gorm.DB) (*users.UserService, error) {
+	keys, err := loadKeys(cfg.Encryption)
+	if err != nil {
+		return nil, fmt.Errorf("load encryption keys: %w", err)
+	}
+	return users.New(userModel.NewGormRepository(db, keys), audit.NewRecorder(auditModel.NewGormRepository(db), keys, nil), nil), nil
+}
+
+// loadKeys loads the PII keyring described by cfg, nil when no keys are configured
+func loadKeys(cfg config.Encryption) (*encryption.Keyring, error) {
+	k, err := encryption.Load(cfg)
+	if errors.Is(err, encryption.ErrNotConfigured) {
+		slog.Warn("No PII encryption keys configured, storing PII in plaintext")
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
+
+	slog.Info("PII encryption enabled", slog.String("active_key_id", k.ActiveKeyID()))
+	return k, nil
+}
+
+// loadConfig loads and validates the configuration and sets up logging
+func loadConfig() (config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return cfg, err
+	}
+
+	logging.Setup(cfg.Log)
+	return cfg, nil
 }
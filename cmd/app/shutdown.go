@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"aadhaar-user-service/internals/logging"
+)
+
+// Exit codes returned by Run and the maintenance commands
+const (
+	ExitOK              = 0 // drained and shut down cleanly, or the command succeeded
+	ExitStartupError    = 1 // failed before serving traffic or before the command could run
+	ExitServerError     = 2 // listener failed while serving
+	ExitShutdownTimeout = 3 // in-flight requests were cut off at the shutdown deadline
+	ExitShutdownError   = 4 // a shutdown hook failed
+	ExitCommandError    = 5 // a maintenance command failed
+)
+
+// ErrDrainTimeout is returned by Shutdown when in-flight requests were cut off at the deadline
+var ErrDrainTimeout = errors.New("in-flight requests were not drained before the deadline")
+
+// shutdownHook releases a resource or flushes a background worker on shutdown
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// onShutdown registers fn to run after the HTTP server has drained, in reverse registration order
+func (a *App) onShutdown(name string, fn func(ctx context.Context) error) {
+	a.hooks = append(a.hooks, shutdownHook{name: name, fn: fn})
+}
+
+// Shutdown stops accepting connections, waits for in-flight requests until ctx is done, then
+// releases the database pool and the other resources of the instance
+func (a *App) Shutdown(ctx context.Context) error {
+	var errs []error
+	if err := a.fiber.ShutdownWithContext(ctx); err != nil {
+		slog.Error("Error draining requests", logging.Err(err))
+		errs = append(errs, fmt.Errorf("%w: %w", ErrDrainTimeout, err))
+	}
+	return errors.Join(append(errs, a.close(ctx))...)
+}
+
+// close runs the shutdown hooks, newest first
+func (a *App) close(ctx context.Context) error {
+	var errs []error
+	for i := len(a.hooks) - 1; i >= 0; i-- {
+		hook := a.hooks[i]
+		if err := hook.fn(ctx); err != nil {
+			slog.Error("Error shutting down", slog.String("hook", hook.name), logging.Err(err))
+			errs = append(errs, fmt.Errorf("%s: %w", hook.name, err))
+		}
+	}
+	a.hooks = nil
+	return errors.Join(errs...)
+}
+
+// serve runs the app until SIGINT or SIGTERM, then shuts it down within the configured timeout,
+// returning the process exit code
+func serve(a *App) int {
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- a.Start()
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	timeout := a.cfg.Server.ShutdownTimeout
+
+	code := ExitOK
+	select {
+	case err := <-listenErr:
+		slog.Error("Error serving", logging.Err(err))
+		code = ExitServerError
+	case sig := <-signals:
+		slog.Info("Draining in-flight requests", slog.String("signal", sig.String()), slog.Duration("timeout", timeout))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := a.Shutdown(ctx); err != nil && code == ExitOK {
+		code = ExitShutdownError
+		if errors.Is(err, ErrDrainTimeout) {
+			code = ExitShutdownTimeout
+		}
+	}
+	return code
+}
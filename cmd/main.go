@@ -1,7 +1,29 @@
 package main
 
-import "aadhaar-user-service/cmd/app"
+import (
+	"os"
+
+	"aadhaar-user-service/cmd/app"
+)
 
 func main() {
-	app.Setup()
+	os.Exit(run(os.Args[1:]))
+}
+
+// run dispatches to the subcommand named by args[0], or serves the API, and returns the exit code
+func run(args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "rotate-keys":
+			return app.RotateKeys()
+		case "reindex":
+			return app.Reindex()
+		case "dedup-report":
+			return app.DedupReport(args[1:])
+		case "migrate":
+			return app.Migrate(args[1:])
+		}
+	}
+
+	return app.Run()
 }
@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"aadhaar-user-service/internals/dto"
+	"aadhaar-user-service/internals/validator"
+	auditModel "aadhaar-user-service/models/audit"
+	"aadhaar-user-service/services/audit"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrorResponse represents an error response
+type ErrorResponse struct {
+	Error   string                      `json:"error"`
+	Details []validator.ValidationError `json:"details,omitempty"`
+}
+
+// Handler serves the audit trail endpoints
+type Handler struct {
+	repo     auditModel.EventRepository
+	validate *validator.Validator
+}
+
+// NewHandler creates a Handler reading the audit trail from repo
+func NewHandler(repo auditModel.EventRepository, validate *validator.Validator) *Handler {
+	return &Handler{repo: repo, validate: validate}
+}
+
+// GetAll retrieves audit events with filtering and pagination
+func (h *Handler) GetAll(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	// Get default params
+	filter := dto.DefaultAuditFilter()
+
+	// Parse query parameters
+	if err := c.QueryParser(&filter); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Invalid query parameters",
+		})
+	}
+
+	// Validate and normalize pagination
+	filter.Page, filter.Limit = validator.ValidatePagination(filter.Page, filter.Limit)
+
+	// Validate filters
+	if validationErrors := h.validate.Payload(filter); len(validationErrors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Validation failed",
+			Details: validationErrors,
+		})
+	}
+
+	svc := audit.New(h.repo)
+	if err := svc.GetAllPaginated(ctx, filter); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error: "Failed to retrieve audit events",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(svc.Events)
+}
+
+// Verify checks the integrity of the audit hash chain
+func (h *Handler) Verify(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	svc := audit.New(h.repo)
+	if err := svc.Verify(ctx); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error: "Failed to verify audit trail",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(svc.Verification)
+}
@@ -1,8 +1,20 @@
 package users
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"aadhaar-user-service/internals/cursor"
+	"aadhaar-user-service/internals/dedup"
 	"aadhaar-user-service/internals/dto"
+	"aadhaar-user-service/internals/geo"
+	"aadhaar-user-service/internals/metrics"
+	"aadhaar-user-service/internals/rbac"
 	"aadhaar-user-service/internals/validator"
+	userModel "aadhaar-user-service/models/users"
+	"aadhaar-user-service/services/audit"
 	"aadhaar-user-service/services/users"
 
 	"github.com/gofiber/fiber/v2"
@@ -12,10 +24,29 @@ import (
 type ErrorResponse struct {
 	Error   string                      `json:"error"`
 	Details []validator.ValidationError `json:"details,omitempty"`
+
+	// Duplicates are the users an applicant rejected as a likely duplicate matches
+	Duplicates []dto.Duplicate `json:"duplicates,omitempty"`
+}
+
+// Handler serves the user endpoints
+type Handler struct {
+	repo     userModel.UserRepository
+	recorder *audit.Recorder
+	validate *validator.Validator
+	cursors  *cursor.Codec
+	dedup    dedup.Policy
+	metrics  *metrics.Metrics
+}
+
+// NewHandler creates a Handler storing users in repo, recording to the audit trail, sealing
+// list cursors with cursors, checking enrolments for duplicates by policy and counting into m
+func NewHandler(repo userModel.UserRepository, recorder *audit.Recorder, validate *validator.Validator, cursors *cursor.Codec, policy dedup.Policy, m *metrics.Metrics) *Handler {
+	return &Handler{repo: repo, recorder: recorder, validate: validate, cursors: cursors, dedup: policy, metrics: m}
 }
 
 // Add creates a new user
-func Add(c *fiber.Ctx) error {
+func (h *Handler) Add(c *fiber.Ctx) error {
 	ctx := c.UserContext()
 
 	var input dto.UserCreate
@@ -28,7 +59,7 @@ func Add(c *fiber.Ctx) error {
 	}
 
 	// Validate input
-	if validationErrors := validator.Payload(input); len(validationErrors) > 0 {
+	if validationErrors := h.validate.Payload(input); len(validationErrors) > 0 {
 		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
 			Error:   "Validation failed",
 			Details: validationErrors,
@@ -36,9 +67,14 @@ func Add(c *fiber.Ctx) error {
 	}
 
 	// Create user via service
-	svc := users.New()
-	if err := svc.Create(ctx, input); err != nil {
+	svc := users.New(h.repo, h.recorder, h.metrics)
+	if err := svc.Create(ctx, input, h.dedup); err != nil {
 		switch err {
+		case users.ErrLikelyDuplicate:
+			return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+				Error:      "Applicant is likely enrolled already",
+				Duplicates: svc.Duplicates.Duplicates,
+			})
 		case users.ErrEmailExists:
 			return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
 				Error: "Email already exists",
@@ -47,6 +83,10 @@ func Add(c *fiber.Ctx) error {
 			return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
 				Error: "Aadhaar application ID already exists",
 			})
+		case users.ErrInvalidDOB:
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error: "Invalid date of birth",
+			})
 		default:
 			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
 				Error: "Failed to create user",
@@ -58,7 +98,7 @@ func Add(c *fiber.Ctx) error {
 }
 
 // Get retrieves a user by ID
-func Get(c *fiber.Ctx) error {
+func (h *Handler) Get(c *fiber.Ctx) error {
 	ctx := c.UserContext()
 
 	id := c.Params("id")
@@ -68,8 +108,15 @@ func Get(c *fiber.Ctx) error {
 		})
 	}
 
-	svc := users.New()
-	if err := svc.GetByID(ctx, id); err != nil {
+	includeDeleted := c.QueryBool("include_deleted")
+	if includeDeleted && !rbac.Allowed(ctx, rbac.UsersReadDeleted) {
+		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+			Error: "Not allowed to view deleted users",
+		})
+	}
+
+	svc := users.New(h.repo, h.recorder, h.metrics)
+	if err := svc.GetByID(ctx, id, includeDeleted); err != nil {
 		switch err {
 		case users.ErrInvalidUUID:
 			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
@@ -89,8 +136,103 @@ func Get(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(svc.User)
 }
 
-// GetAll retrieves all users with pagination and sorting
-func GetAll(c *fiber.Ctx) error {
+// GetAll retrieves all users with pagination, sorting and filters
+func (h *Handler) GetAll(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	params, status, failure := h.listParams(c)
+	if failure != nil {
+		return c.Status(status).JSON(failure)
+	}
+
+	// A cursor continues a listing with the same sorting and filters, in place of page
+	if token := c.Query("cursor"); token != "" {
+		position, err := h.cursors.Decode(token)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error: "Invalid cursor",
+			})
+		}
+		if position.Query != params.Fingerprint() {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error: "Cursor does not match the sorting and filters of the query",
+			})
+		}
+		params.Cursor = &position
+	}
+
+	// Counting is skipped by default when paging with cursors
+	params.WithTotal = c.QueryBool("include_total", params.Cursor == nil)
+
+	svc := users.New(h.repo, h.recorder, h.metrics)
+	if err := svc.GetAllPaginated(ctx, params); err != nil {
+		if unsupported := unsupportedQuery(err); unsupported != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(unsupported)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error: "Failed to retrieve users",
+		})
+	}
+
+	var err error
+	if svc.Users.NextCursor, err = h.encodeCursor(svc.NextPage); err == nil {
+		svc.Users.PrevCursor, err = h.encodeCursor(svc.PrevPage)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error: "Failed to retrieve users",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(svc.Users)
+}
+
+// Export downloads the users matching the sorting, search and filters of a listing as CSV
+func (h *Handler) Export(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	params, status, failure := h.listParams(c)
+	if failure != nil {
+		return c.Status(status).JSON(failure)
+	}
+
+	// The export is only sent once complete, so a failure midway leaves no partial file
+	var body bytes.Buffer
+	svc := users.New(h.repo, h.recorder, h.metrics)
+	if err := svc.Export(ctx, params, &body); err != nil {
+		if unsupported := unsupportedQuery(err); unsupported != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(unsupported)
+		}
+		if err == users.ErrExportTooLarge {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error: fmt.Sprintf("Export exceeds %d users, narrow it down with filters", users.MaxExportRows),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error: "Failed to export users",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="users.csv"`)
+	return c.Status(fiber.StatusOK).Send(body.Bytes())
+}
+
+// unsupportedQuery returns the validation failure for a query the store cannot answer, or nil
+func unsupportedQuery(err error) *ErrorResponse {
+	var unsupported *userModel.UnsupportedQueryError
+	if !errors.As(err, &unsupported) {
+		return nil
+	}
+	return &ErrorResponse{
+		Error:   "Validation failed",
+		Details: []validator.ValidationError{{Field: unsupported.Field, Message: unsupported.Message}},
+	}
+}
+
+// listParams parses and validates the sorting, search and filters of a listing, or returns the
+// status and response to fail the request with
+func (h *Handler) listParams(c *fiber.Ctx) (dto.PaginationParams, int, *ErrorResponse) {
 	ctx := c.UserContext()
 
 	// Get default params
@@ -112,13 +254,46 @@ func GetAll(c *fiber.Ctx) error {
 	if search := c.Query("search"); search != "" {
 		params.Search = search
 	}
+	if mode := c.Query("search_mode"); mode != "" {
+		params.SearchMode = mode
+
+		// Searching in a mode ranks by relevance unless asked otherwise
+		if c.Query("sort_by") == "" && params.Search != "" {
+			params.SortBy = "relevance"
+		}
+	}
+	params.Similarity = c.QueryFloat("similarity", params.Similarity)
+	if state := c.Query("state"); state != "" {
+		params.State = state
+		if s, ok := geo.LookupState(state); ok {
+			params.State = s.Name
+		}
+	}
+	if err := c.QueryParser(&params.Filters); err != nil {
+		return params, fiber.StatusBadRequest, &ErrorResponse{
+			Error: "Invalid query parameters",
+		}
+	}
+
+	// Application IDs may be given comma-separated as well as repeated
+	var ids []string
+	for _, id := range params.Filters.AadhaarApplicationIDs {
+		ids = append(ids, strings.Split(id, ",")...)
+	}
+	params.Filters.AadhaarApplicationIDs = ids
+	params.IncludeDeleted = c.QueryBool("include_deleted")
+	if params.IncludeDeleted && !rbac.Allowed(ctx, rbac.UsersReadDeleted) {
+		return params, fiber.StatusForbidden, &ErrorResponse{
+			Error: "Not allowed to view deleted users",
+		}
+	}
 
 	// Validate and normalize pagination
 	params.Page, params.Limit = validator.ValidatePagination(params.Page, params.Limit)
 
 	// Validate sort parameters
 	validSortFields := map[string]bool{
-		"name": true, "email": true, "created_at": true, "aadhaar_application_id": true,
+		"name": true, "email": true, "created_at": true, "aadhaar_application_id": true, "relevance": true,
 	}
 	if !validSortFields[params.SortBy] {
 		params.SortBy = "created_at"
@@ -129,18 +304,48 @@ func GetAll(c *fiber.Ctx) error {
 		params.Order = "desc"
 	}
 
-	svc := users.New()
-	if err := svc.GetAllPaginated(ctx, params); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
-			Error: "Failed to retrieve users",
+	// Validate the search mode, fuzzy threshold and filters
+	if validationErrors := h.validate.Payload(params); len(validationErrors) > 0 {
+		return params, fiber.StatusBadRequest, &ErrorResponse{
+			Error:   "Validation failed",
+			Details: validationErrors,
+		}
+	}
+
+	return params, 0, nil
+}
+
+// encodeCursor seals a list position into a cursor, or returns "" without one
+func (h *Handler) encodeCursor(position *dto.Cursor) (string, error) {
+	if position == nil {
+		return "", nil
+	}
+	return h.cursors.Encode(*position)
+}
+
+// Update replaces the editable fields of a user by ID
+func (h *Handler) Update(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "User ID is required",
 		})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(svc.Users)
+	var input dto.UserUpdate
+
+	// Parse request body
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Invalid request body",
+		})
+	}
+
+	return h.update(c, id, input)
 }
 
-// Delete removes a user by ID
-func Delete(c *fiber.Ctx) error {
+// Patch partially updates a user by ID using a JSON Merge Patch body
+func (h *Handler) Patch(c *fiber.Ctx) error {
 	ctx := c.UserContext()
 
 	id := c.Params("id")
@@ -150,7 +355,83 @@ func Delete(c *fiber.Ctx) error {
 		})
 	}
 
-	svc := users.New()
+	// Load current state to merge the patch onto
+	input, err := users.New(h.repo, h.recorder, h.metrics).GetUpdate(ctx, id)
+	if err != nil {
+		return updateError(c, err)
+	}
+
+	if err := input.ApplyMergePatch(c.Body()); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Invalid request body",
+		})
+	}
+
+	return h.update(c, id, input)
+}
+
+// update validates the payload and applies it via the service
+func (h *Handler) update(c *fiber.Ctx, id string, input dto.UserUpdate) error {
+	ctx := c.UserContext()
+
+	// Validate input
+	if validationErrors := h.validate.Payload(input); len(validationErrors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Validation failed",
+			Details: validationErrors,
+		})
+	}
+
+	svc := users.New(h.repo, h.recorder, h.metrics)
+	if err := svc.Update(ctx, id, input); err != nil {
+		return updateError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(svc.User)
+}
+
+// updateError maps service errors from an update to HTTP responses
+func updateError(c *fiber.Ctx, err error) error {
+	switch err {
+	case users.ErrInvalidUUID:
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Invalid user ID format",
+		})
+	case users.ErrUserNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error: "User not found",
+		})
+	case users.ErrEmailExists:
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Error: "Email already exists",
+		})
+	case users.ErrAadhaarIDExists:
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Error: "Aadhaar application ID already exists",
+		})
+	case users.ErrInvalidDOB:
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Invalid date of birth",
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error: "Failed to update user",
+		})
+	}
+}
+
+// Delete soft-deletes a user by ID
+func (h *Handler) Delete(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "User ID is required",
+		})
+	}
+
+	svc := users.New(h.repo, h.recorder, h.metrics)
 	if err := svc.Delete(ctx, id); err != nil {
 		switch err {
 		case users.ErrInvalidUUID:
@@ -170,3 +451,107 @@ func Delete(c *fiber.Ctx) error {
 
 	return c.SendStatus(fiber.StatusNoContent)
 }
+
+// Restore brings back a soft-deleted user by ID
+func (h *Handler) Restore(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "User ID is required",
+		})
+	}
+
+	svc := users.New(h.repo, h.recorder, h.metrics)
+	if err := svc.Restore(ctx, id); err != nil {
+		switch err {
+		case users.ErrInvalidUUID:
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error: "Invalid user ID format",
+			})
+		case users.ErrUserNotFound:
+			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+				Error: "User not found",
+			})
+		case users.ErrUserNotDeleted:
+			return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+				Error: "User is not deleted",
+			})
+		case users.ErrEmailExists:
+			return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+				Error: "Email already exists",
+			})
+		case users.ErrAadhaarIDExists:
+			return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+				Error: "Aadhaar application ID already exists",
+			})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+				Error: "Failed to restore user",
+			})
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(svc.User)
+}
+
+// Purge permanently removes users soft-deleted longer ago than older_than_days
+func (h *Handler) Purge(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	params := dto.DefaultPurgeParams()
+	params.OlderThanDays = c.QueryInt("older_than_days", params.OlderThanDays)
+
+	// Validate input
+	if validationErrors := h.validate.Payload(params); len(validationErrors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Validation failed",
+			Details: validationErrors,
+		})
+	}
+
+	svc := users.New(h.repo, h.recorder, h.metrics)
+	if err := svc.Purge(ctx, params); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error: "Failed to purge users",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(svc.Purged)
+}
+
+// Duplicates lists the live users that are likely the same applicant as a user
+func (h *Handler) Duplicates(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	params := dto.DuplicatesParams{MinScore: c.QueryFloat("min_score", h.dedup.Threshold)}
+
+	// Validate input
+	if validationErrors := h.validate.Payload(params); len(validationErrors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error:   "Validation failed",
+			Details: validationErrors,
+		})
+	}
+
+	svc := users.New(h.repo, h.recorder, h.metrics)
+	if err := svc.FindDuplicates(ctx, c.Params("id"), params); err != nil {
+		switch err {
+		case users.ErrInvalidUUID:
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error: "Invalid user ID format",
+			})
+		case users.ErrUserNotFound:
+			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+				Error: "User not found",
+			})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+				Error: "Failed to find duplicates",
+			})
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(svc.Duplicates)
+}
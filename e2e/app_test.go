@@ -0,0 +1,53 @@
+package e2e
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"aadhaar-user-service/internals/config"
+)
+
+// scrape returns the metrics the instance exposes
+func (c *client) scrape() string {
+	c.t.Helper()
+
+	resp, err := c.app.Fiber().Test(httptest.NewRequest(http.MethodGet, "/metrics", nil), -1)
+	if err != nil {
+		c.t.Fatalf("scrape metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatalf("read metrics: %v", err)
+	}
+	return string(data)
+}
+
+func TestInstancesAreIndependent(t *testing.T) {
+	eachStoreWith(t, func(cfg *config.Config) {}, func(t *testing.T, c *client) {
+		// A second instance on the same database, with authentication disabled
+		cfg := c.cfg
+		cfg.Auth = config.Auth{Disabled: true}
+		open := newClient(t, cfg)
+
+		if status := c.do(http.MethodGet, "/aadhaar/users", "", nil, nil); status != http.StatusUnauthorized {
+			t.Errorf("without credentials: status %d, want %d", status, http.StatusUnauthorized)
+		}
+		if status := open.do(http.MethodGet, "/aadhaar/users", "", nil, nil); status != http.StatusOK {
+			t.Errorf("without credentials, authentication disabled: status %d, want %d", status, http.StatusOK)
+		}
+
+		// Each instance counts only its own requests
+		served := `aadhaar_http_requests_total{method="GET",route="/aadhaar/users/",status="200"} 1`
+		if !strings.Contains(open.scrape(), served) {
+			t.Errorf("instance serving the request does not count it")
+		}
+		if strings.Contains(c.scrape(), `route="/aadhaar/users/",status="200"`) {
+			t.Errorf("other instance counts the request")
+		}
+	})
+}
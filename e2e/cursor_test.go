@@ -0,0 +1,240 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+
+	"aadhaar-user-service/internals/config"
+	"aadhaar-user-service/internals/dto"
+
+	"github.com/google/uuid"
+)
+
+// ids returns the IDs of a list in order
+func ids(users []dto.User) []uuid.UUID {
+	ids := make([]uuid.UUID, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	return ids
+}
+
+// walk follows cursors from the first page until there are none, returning the pages in the
+// order they were visited; backward follows prev_cursor from the last page instead
+func (c *client) walk(query url.Values, backward bool) [][]uuid.UUID {
+	c.t.Helper()
+
+	page := c.list(query)
+	if backward {
+		for page.NextCursor != "" {
+			page = c.list(with(query, "cursor", page.NextCursor))
+		}
+	}
+
+	var pages [][]uuid.UUID
+	for {
+		pages = append(pages, ids(page.Users))
+		if total(page) != -1 && page.Page == 0 {
+			c.t.Errorf("cursor page counted a total of %d without include_total", total(page))
+		}
+
+		next := page.NextCursor
+		if backward {
+			next = page.PrevCursor
+		}
+		if next == "" {
+			return pages
+		}
+		if len(pages) > 100 {
+			c.t.Fatal("cursors do not terminate")
+		}
+		page = c.list(with(query, "cursor", next))
+	}
+}
+
+// with returns a copy of query with key set to value
+func with(query url.Values, key, value string) url.Values {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = slices.Clone(v)
+	}
+	q.Set(key, value)
+	return q
+}
+
+func TestCursorPagination(t *testing.T) {
+	eachStore(t, func(t *testing.T, c *client) {
+		// Repeated names make the id tie breaker matter
+		for i := 1; i <= 23; i++ {
+			c.create(newUser(i, fmt.Sprintf("Applicant %c", 'A'+i%4)))
+		}
+
+		for _, sortBy := range []string{"name", "email", "created_at", "aadhaar_application_id"} {
+			for _, order := range []string{"asc", "desc"} {
+				t.Run(sortBy+" "+order, func(t *testing.T) {
+					query := url.Values{"sort_by": {sortBy}, "order": {order}, "limit": {"5"}}
+					want := ids(c.list(url.Values{"sort_by": {sortBy}, "order": {order}, "limit": {"100"}}).Users)
+
+					forward := c.walk(query, false)
+					if len(forward) != 5 {
+						t.Errorf("%d pages forward, want 5", len(forward))
+					}
+					if got := slices.Concat(forward...); !slices.Equal(got, want) {
+						t.Errorf("forward pages = %v, want %v", got, want)
+					}
+
+					backward := c.walk(query, true)
+					slices.Reverse(backward)
+					if !slices.EqualFunc(backward, forward, slices.Equal) {
+						t.Errorf("backward pages = %v, want %v", backward, forward)
+					}
+				})
+			}
+		}
+	})
+}
+
+func TestCursorStableUnderInserts(t *testing.T) {
+	eachKeying(t, func(t *testing.T, c *client) {
+		for i := 1; i <= 10; i++ {
+			c.create(newUser(i, fmt.Sprintf("Applicant %02d", i)))
+		}
+
+		// Newest first: users enrolled while paging land before the cursor and shift offsets
+		query := url.Values{"limit": {"4"}}
+		first := c.list(query)
+		before := ids(c.list(with(query, "page", "2")).Users)
+
+		c.create(newUser(11, "Applicant 11"))
+		c.create(newUser(12, "Applicant 12"))
+
+		second := c.list(with(query, "cursor", first.NextCursor))
+		if got := ids(second.Users); !slices.Equal(got, before) {
+			t.Errorf("second page after inserts = %v, want %v", got, before)
+		}
+		if shifted := ids(c.list(with(query, "page", "2")).Users); slices.Equal(shifted, before) {
+			t.Error("offset page 2 did not shift, the inserts were not seen")
+		}
+
+		// Going back from the second page shows the new users too
+		prev := c.list(with(query, "cursor", second.PrevCursor))
+		if got := names(prev); !slices.Equal(got, []string{"Applicant 10", "Applicant 09", "Applicant 08", "Applicant 07"}) {
+			t.Errorf("previous page = %v", got)
+		}
+		if prev.PrevCursor == "" {
+			t.Error("no cursor to the page holding the new users")
+		}
+	})
+}
+
+func TestCursorTotals(t *testing.T) {
+	eachKeying(t, func(t *testing.T, c *client) {
+		for i := 1; i <= 7; i++ {
+			c.create(newUser(i, fmt.Sprintf("Applicant %02d", i)))
+		}
+
+		offset := c.list(url.Values{"limit": {"3"}})
+		if total(offset) != 7 || totalPages(offset) != 3 || offset.Page != 1 || offset.PrevCursor != "" || offset.NextCursor == "" {
+			t.Errorf("offset page: total=%d total_pages=%d page=%d prev=%q next=%q",
+				total(offset), totalPages(offset), offset.Page, offset.PrevCursor, offset.NextCursor)
+		}
+
+		uncounted := c.list(url.Values{"limit": {"3"}, "include_total": {"false"}})
+		if total(uncounted) != -1 || totalPages(uncounted) != -1 || !slices.Equal(ids(uncounted.Users), ids(offset.Users)) {
+			t.Errorf("include_total=false: total=%d total_pages=%d", total(uncounted), totalPages(uncounted))
+		}
+
+		next := c.list(url.Values{"limit": {"3"}, "cursor": {offset.NextCursor}})
+		if total(next) != -1 || next.Page != 0 || next.PrevCursor == "" || next.NextCursor == "" {
+			t.Errorf("cursor page: total=%d page=%d prev=%q next=%q", total(next), next.Page, next.PrevCursor, next.NextCursor)
+		}
+
+		counted := c.list(url.Values{"limit": {"3"}, "cursor": {offset.NextCursor}, "include_total": {"true"}})
+		if total(counted) != 7 || totalPages(counted) != 3 {
+			t.Errorf("cursor page with include_total: total=%d total_pages=%d", total(counted), totalPages(counted))
+		}
+
+		last := c.list(url.Values{"limit": {"3"}, "cursor": {next.NextCursor}})
+		if len(last.Users) != 1 || last.NextCursor != "" || last.PrevCursor == "" {
+			t.Errorf("last page: len=%d prev=%q next=%q", len(last.Users), last.PrevCursor, last.NextCursor)
+		}
+
+		// Page 3 of the offset mode links back like the cursor mode does
+		third := c.list(url.Values{"limit": {"3"}, "page": {"3"}})
+		if !slices.Equal(ids(third.Users), ids(last.Users)) || third.NextCursor != "" || third.PrevCursor == "" {
+			t.Errorf("offset page 3: %v prev=%q next=%q, want %v", ids(third.Users), third.PrevCursor, third.NextCursor, ids(last.Users))
+		}
+	})
+}
+
+func TestInvalidCursors(t *testing.T) {
+	eachStore(t, func(t *testing.T, c *client) {
+		for i := 1; i <= 3; i++ {
+			c.create(newUser(i, fmt.Sprintf("Applicant %02d", i)))
+		}
+		cursor := c.list(url.Values{"limit": {"1"}, "sort_by": {"name"}}).NextCursor
+
+		tampered := []byte(cursor)
+		tampered[len(tampered)/2] ^= 'A' ^ 'B'
+		if tampered[len(tampered)/2] == cursor[len(cursor)/2] {
+			t.Fatal("cursor unchanged")
+		}
+
+		tests := []struct {
+			name  string
+			query url.Values
+			error string
+		}{
+			{"not base64", url.Values{"cursor": {"not a cursor!"}, "sort_by": {"name"}}, "Invalid cursor"},
+			{"random bytes", url.Values{"cursor": {"c29tZSByYW5kb20gYnl0ZXMgdGhhdCBhcmUgbG9uZyBlbm91Z2g"}, "sort_by": {"name"}}, "Invalid cursor"},
+			{"tampered", url.Values{"cursor": {string(tampered)}, "sort_by": {"name"}}, "Invalid cursor"},
+			{"other sort column", url.Values{"cursor": {cursor}, "sort_by": {"email"}}, "Cursor does not match the sorting and filters of the query"},
+			{"other order", url.Values{"cursor": {cursor}, "sort_by": {"name"}, "order": {"asc"}}, "Cursor does not match the sorting and filters of the query"},
+			{"other search", url.Values{"cursor": {cursor}, "sort_by": {"name"}, "search": {"Applicant"}}, "Cursor does not match the sorting and filters of the query"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var resp errorResponse
+				if status := c.do(http.MethodGet, "/aadhaar/users?"+tt.query.Encode(), adminKey, nil, &resp); status != http.StatusBadRequest {
+					t.Fatalf("status %d, want %d", status, http.StatusBadRequest)
+				}
+				if resp.Error != tt.error {
+					t.Errorf("error %q, want %q", resp.Error, tt.error)
+				}
+			})
+		}
+	})
+}
+
+func TestCursorSecret(t *testing.T) {
+	instance := func(secret string) *client {
+		cfg := defaultConfig()
+		cfg.Database.Driver = config.DriverMemory
+		cfg.Pagination.CursorSecret = secret
+		return newClient(t, cfg)
+	}
+	status := func(c *client, cursor string) int {
+		return c.do(http.MethodGet, "/aadhaar/users?cursor="+url.QueryEscape(cursor), adminKey, nil, nil)
+	}
+
+	secret := "0123456789abcdef0123456789abcdef"
+	a := instance(secret)
+	for i := 1; i <= 2; i++ {
+		a.create(newUser(i, fmt.Sprintf("Applicant %02d", i)))
+	}
+	cursor := a.list(url.Values{"limit": {"1"}}).NextCursor
+
+	if got := status(instance(secret), cursor); got != http.StatusOK {
+		t.Errorf("instance with the same secret: status %d, want %d", got, http.StatusOK)
+	}
+	if got := status(instance(""), cursor); got != http.StatusBadRequest {
+		t.Errorf("instance with a random key: status %d, want %d", got, http.StatusBadRequest)
+	}
+	if got := status(instance(secret+"x"), cursor); got != http.StatusBadRequest {
+		t.Errorf("instance with another secret: status %d, want %d", got, http.StatusBadRequest)
+	}
+}
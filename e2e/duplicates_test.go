@@ -0,0 +1,194 @@
+package e2e
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"aadhaar-user-service/internals/config"
+	"aadhaar-user-service/internals/dedup"
+	"aadhaar-user-service/internals/dto"
+)
+
+// applicant returns a create payload for n with the personal details duplicate detection
+// compares, shared by every applicant unless changed
+func applicant(n int, name string) dto.UserCreate {
+	u := newUser(n, name)
+	u.Phone = "9812345678"
+	u.Address = "12 MG Road, Bengaluru 560001"
+	u.DateOfBirth = "1985-03-04"
+	u.Gender = "male"
+	return u
+}
+
+// dedupMode configures instances to detect duplicates in mode
+func dedupMode(mode string) func(cfg *config.Config) {
+	return func(cfg *config.Config) {
+		cfg.Dedup.Mode = mode
+	}
+}
+
+func TestDuplicateRejected(t *testing.T) {
+	eachStoreWith(t, dedupMode(dedup.Strict), func(t *testing.T, c *client) {
+		original := c.create(applicant(1, "Mohammed Iqbal"))
+
+		for _, name := range []string{"Mohamed Iqbal", "Muhammad Iqbal", "मोहम्मद इक़बाल"} {
+			t.Run(name, func(t *testing.T) {
+				var resp errorResponse
+				if status := c.do(http.MethodPost, "/aadhaar/users", adminKey, applicant(2, name), &resp); status != http.StatusConflict {
+					t.Fatalf("status %d, want %d", status, http.StatusConflict)
+				}
+				if resp.Error != "Applicant is likely enrolled already" {
+					t.Errorf("error %q", resp.Error)
+				}
+				if len(resp.Duplicates) != 1 || resp.Duplicates[0].UserID != original.ID {
+					t.Fatalf("duplicates = %+v, want %s", resp.Duplicates, original.ID)
+				}
+				d := resp.Duplicates[0]
+				if d.Score < config.Default().Dedup.Threshold || d.Score >= 1 || d.Fields.DateOfBirth != 1 || d.Fields.Phone != 1 {
+					t.Errorf("scores = %+v", d)
+				}
+			})
+		}
+
+		// A day and month swapped on entry still matches
+		swapped := applicant(3, "Mohammed Iqbal")
+		swapped.DateOfBirth = "1985-04-03"
+		if status := c.do(http.MethodPost, "/aadhaar/users", adminKey, swapped, nil); status != http.StatusConflict {
+			t.Errorf("swapped date of birth: status %d, want %d", status, http.StatusConflict)
+		}
+
+		// A brother sharing the date of birth, phone and address is a different applicant
+		brother := c.create(applicant(4, "Rafiq Iqbal"))
+		if brother.PossibleDuplicate || len(brother.Duplicates) != 0 {
+			t.Errorf("brother flagged as a duplicate: %+v", brother.Duplicates)
+		}
+
+		// So is someone else of the same name
+		namesake := applicant(5, "Mohammed Iqbal")
+		namesake.DateOfBirth, namesake.Phone, namesake.Address = "1972-11-20", "9000012345", "4 Park Street, Kolkata"
+		c.create(namesake)
+
+		// Deleted users are not matched
+		if status := c.do(http.MethodDelete, "/aadhaar/users/"+original.ID.String(), adminKey, nil, nil); status != http.StatusNoContent {
+			t.Fatalf("delete: status %d, want %d", status, http.StatusNoContent)
+		}
+		c.create(applicant(6, "Mohamed Iqbal"))
+	})
+}
+
+func TestDuplicateSamePhoneAndBirth(t *testing.T) {
+	strict := map[string]func(cfg *config.Config){
+		"plaintext": dedupMode(dedup.Strict),
+		"encrypted": func(cfg *config.Config) {
+			cfg.Dedup.Mode = dedup.Strict
+			encrypted(cfg)
+		},
+	}
+	for keying, configure := range strict {
+		t.Run(keying, func(t *testing.T) {
+			eachStoreWith(t, configure, func(t *testing.T, c *client) {
+				original := c.create(applicant(1, "Xavier Dsouza"))
+
+				// The names share no phonetic key, the phone number and date of birth find the match
+				var resp errorResponse
+				if status := c.do(http.MethodPost, "/aadhaar/users", adminKey, applicant(2, "Zavier D'Souza"), &resp); status != http.StatusConflict {
+					t.Fatalf("status %d, want %d", status, http.StatusConflict)
+				}
+				if len(resp.Duplicates) != 1 || resp.Duplicates[0].UserID != original.ID {
+					t.Errorf("duplicates = %+v, want %s", resp.Duplicates, original.ID)
+				}
+
+				// With another phone number the spelling alone is not enough
+				other := applicant(3, "Zavier D'Souza")
+				other.Phone = "9000012345"
+				c.create(other)
+			})
+		})
+	}
+}
+
+func TestDuplicateSoftMode(t *testing.T) {
+	eachStoreWith(t, dedupMode(dedup.Soft), func(t *testing.T, c *client) {
+		original := c.create(applicant(1, "Lakshmi Devi"))
+		if original.PossibleDuplicate {
+			t.Error("first applicant flagged as a duplicate")
+		}
+
+		again := c.create(applicant(2, "Laxmi Devi"))
+		if !again.PossibleDuplicate || len(again.Duplicates) != 1 || again.Duplicates[0].UserID != original.ID {
+			t.Errorf("possible_duplicate=%v duplicates=%+v, want %s", again.PossibleDuplicate, again.Duplicates, original.ID)
+		}
+
+		// The flag is only part of the create response
+		var read dto.User
+		c.do(http.MethodGet, "/aadhaar/users/"+again.ID.String(), adminKey, nil, &read)
+		if read.PossibleDuplicate || read.Duplicates != nil {
+			t.Errorf("read carries the flag: %+v", read)
+		}
+	})
+}
+
+func TestDuplicatesOff(t *testing.T) {
+	eachStoreWith(t, dedupMode(dedup.Off), func(t *testing.T, c *client) {
+		c.create(applicant(1, "Lakshmi Devi"))
+		if again := c.create(applicant(2, "Lakshmi Devi")); again.PossibleDuplicate {
+			t.Error("flagged with duplicate detection off")
+		}
+	})
+}
+
+func TestDuplicatesEndpoint(t *testing.T) {
+	eachStoreWith(t, dedupMode(dedup.Soft), func(t *testing.T, c *client) {
+		first := c.create(applicant(1, "Vijay Sharma"))
+		second := c.create(applicant(2, "Vijai Sharma"))
+		unrelated := c.create(newUser(3, "Asha Rao"))
+
+		duplicates := func(id string, query url.Values) (int, dto.Duplicates) {
+			var resp dto.Duplicates
+			status := c.do(http.MethodGet, "/aadhaar/users/"+id+"/duplicates?"+query.Encode(), operatorKey, nil, &resp)
+			return status, resp
+		}
+
+		status, resp := duplicates(first.ID.String(), nil)
+		if status != http.StatusOK {
+			t.Fatalf("status %d, want %d", status, http.StatusOK)
+		}
+		if resp.UserID != first.ID || resp.MinScore != config.Default().Dedup.Threshold {
+			t.Errorf("user_id=%s min_score=%v", resp.UserID, resp.MinScore)
+		}
+		if len(resp.Duplicates) != 1 || resp.Duplicates[0].UserID != second.ID || resp.Duplicates[0].Fields.Name != 0.95 {
+			t.Errorf("duplicates = %+v, want %s", resp.Duplicates, second.ID)
+		}
+
+		if _, resp := duplicates(unrelated.ID.String(), nil); resp.Duplicates == nil || len(resp.Duplicates) != 0 {
+			t.Errorf("duplicates of an unrelated user = %+v, want []", resp.Duplicates)
+		}
+		if _, resp := duplicates(first.ID.String(), url.Values{"min_score": {"1"}}); len(resp.Duplicates) != 0 {
+			t.Errorf("duplicates scoring 1 = %+v, want none", resp.Duplicates)
+		}
+
+		tests := []struct {
+			name   string
+			id     string
+			query  url.Values
+			status int
+		}{
+			{"zero min_score", first.ID.String(), url.Values{"min_score": {"0"}}, http.StatusBadRequest},
+			{"min_score above one", first.ID.String(), url.Values{"min_score": {"1.5"}}, http.StatusBadRequest},
+			{"invalid id", "not-a-uuid", nil, http.StatusBadRequest},
+			{"unknown id", "00000000-0000-0000-0000-000000000000", nil, http.StatusNotFound},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if status, _ := duplicates(tt.id, tt.query); status != tt.status {
+					t.Errorf("status %d, want %d", status, tt.status)
+				}
+			})
+		}
+
+		if status := c.do(http.MethodGet, "/aadhaar/users/"+first.ID.String()+"/duplicates", "", nil, nil); status != http.StatusUnauthorized {
+			t.Errorf("unauthenticated: status %d, want %d", status, http.StatusUnauthorized)
+		}
+	})
+}
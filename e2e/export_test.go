@@ -0,0 +1,152 @@
+package e2e
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"aadhaar-user-service/internals/dto"
+)
+
+// export downloads /aadhaar/users/export with the given query and key, returning the status and
+// the CSV records, or the error response when the export failed
+func (c *client) export(query url.Values, key string) (int, [][]string, errorResponse) {
+	c.t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/aadhaar/users/export?"+query.Encode(), nil)
+	req.Header.Set("X-API-Key", key)
+	resp, err := c.app.Fiber().Test(req, -1)
+	if err != nil {
+		c.t.Fatalf("export %s: %v", query.Encode(), err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatalf("read export: %v", err)
+	}
+
+	var failure errorResponse
+	if resp.StatusCode != http.StatusOK {
+		if err := json.Unmarshal(data, &failure); err != nil {
+			c.t.Fatalf("export %s: decode %s: %v", query.Encode(), data, err)
+		}
+		return resp.StatusCode, nil, failure
+	}
+
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		c.t.Errorf("content type %q, want text/csv", ct)
+	}
+	records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	if err != nil {
+		c.t.Fatalf("export %s: parse CSV: %v", query.Encode(), err)
+	}
+	if len(records) == 0 || records[0][0] != "id" {
+		c.t.Fatalf("export %s: no header in %q", query.Encode(), data)
+	}
+	return resp.StatusCode, records, failure
+}
+
+// column returns a column of the exported users, without the header
+func column(records [][]string, name string) []string {
+	i := slices.Index(records[0], name)
+	values := make([]string, 0, len(records)-1)
+	for _, r := range records[1:] {
+		values = append(values, r[i])
+	}
+	return values
+}
+
+func TestExport(t *testing.T) {
+	eachKeying(t, func(t *testing.T, c *client) {
+		// More users than fit on one page of the export
+		var created []string
+		for n := 1; n <= 102; n++ {
+			input := newUser(n, "Applicant "+strings.Repeat("x", n%7+1))
+			if n%10 == 0 {
+				input.Gender = "male"
+			}
+			created = append(created, c.create(input).ID.String())
+		}
+
+		status, records, _ := c.export(nil, adminKey)
+		if status != http.StatusOK {
+			t.Fatalf("status %d, want %d", status, http.StatusOK)
+		}
+		got := column(records, "id")
+		slices.Sort(got)
+		slices.Sort(created)
+		if !slices.Equal(got, created) {
+			t.Errorf("exported %d users, want all %d once", len(got), len(created))
+		}
+		if emails := column(records, "email"); !slices.Contains(emails, "applicant1@example.com") {
+			t.Errorf("emails %v, want them unmasked for admin", emails[:3])
+		}
+
+		var events dto.AuditEvents
+		if status := c.do(http.MethodGet, "/aadhaar/audit?action=export", adminKey, nil, &events); status != http.StatusOK {
+			t.Fatalf("audit: status %d, want %d", status, http.StatusOK)
+		}
+		disclosed := 0
+		for _, e := range events.Events {
+			ids, _ := e.Details["user_ids"].([]any)
+			disclosed += len(ids)
+		}
+		if len(events.Events) != 2 || disclosed != len(created) {
+			t.Errorf("%d export events for %d users, want one per page for all %d", len(events.Events), disclosed, len(created))
+		}
+
+		status, records, _ = c.export(url.Values{"gender": {"male"}, "sort_by": {"aadhaar_application_id"}, "order": {"asc"}}, adminKey)
+		if status != http.StatusOK {
+			t.Fatalf("filtered: status %d, want %d", status, http.StatusOK)
+		}
+		ids := column(records, "aadhaar_application_id")
+		if len(ids) != 10 || !slices.IsSorted(ids) {
+			t.Errorf("filtered export = %v, want the 10 male applicants in order", ids)
+		}
+	})
+}
+
+func TestExportRefused(t *testing.T) {
+	eachStore(t, func(t *testing.T, c *client) {
+		c.create(newUser(1, "Asha Rao"))
+
+		if status, _, _ := c.export(nil, operatorKey); status != http.StatusForbidden {
+			t.Errorf("operator: status %d, want %d", status, http.StatusForbidden)
+		}
+		if status, _, _ := c.export(nil, ""); status != http.StatusUnauthorized {
+			t.Errorf("unauthenticated: status %d, want %d", status, http.StatusUnauthorized)
+		}
+
+		status, _, resp := c.export(url.Values{"search": {"asha"}, "search_mode": {"sideways"}}, adminKey)
+		if status != http.StatusBadRequest || len(resp.Details) == 0 {
+			t.Errorf("invalid search mode: status %d, details %+v, want %d with details", status, resp.Details, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestEncryptedExport(t *testing.T) {
+	eachStoreWith(t, encrypted, func(t *testing.T, c *client) {
+		c.create(newUser(1, "Asha Rao"))
+		c.create(newUser(2, "Ravi Kumar"))
+
+		status, records, _ := c.export(url.Values{"search": {"ravi"}, "search_mode": {"fulltext"}}, adminKey)
+		if status != http.StatusOK {
+			t.Fatalf("status %d, want %d", status, http.StatusOK)
+		}
+		if got := column(records, "name"); !slices.Equal(got, []string{"Ravi Kumar"}) {
+			t.Errorf("names %v, want the decrypted [Ravi Kumar]", got)
+		}
+
+		status, _, resp := c.export(url.Values{"sort_by": {"name"}}, adminKey)
+		if status != http.StatusBadRequest || len(resp.Details) != 1 || resp.Details[0].Field != "SortBy" {
+			t.Errorf("sort by name: status %d, details %+v, want %d on SortBy", status, resp.Details, http.StatusBadRequest)
+		}
+	})
+}
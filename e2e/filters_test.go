@@ -0,0 +1,225 @@
+package e2e
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"aadhaar-user-service/internals/dto"
+)
+
+// enrolFiltered creates users with fields the list filters tell apart
+func (c *client) enrolFiltered() []dto.User {
+	c.t.Helper()
+
+	asha := newUser(1, "Asha Rao")
+	asha.Email, asha.Phone, asha.DateOfBirth = "asha@gov.in", "9811111111", "1985-03-04"
+	asha.AddressDetails = &dto.Address{House: "12", VillageTown: "Bengaluru", District: "Bengaluru Urban", State: "Karnataka", PinCode: "560001"}
+
+	ravi := newUser(2, "Ravi Kumar")
+	ravi.Gender, ravi.Email, ravi.Phone, ravi.DateOfBirth = "male", "ravi@example.com", "9822222222", "1990-06-15"
+	ravi.AddressDetails = &dto.Address{House: "4", VillageTown: "Mumbai", District: "Mumbai City", State: "Maharashtra", PinCode: "400001"}
+
+	meena := newUser(3, "Meena Iyer")
+	meena.Email, meena.Phone, meena.DateOfBirth = "meena@GOV.in", "9811122222", "1992-12-31"
+	meena.AddressDetails = &dto.Address{House: "7", VillageTown: "Bengaluru", District: "Bengaluru Urban", State: "Karnataka", PinCode: "560095"}
+
+	arjun := newUser(4, "Arjun Das")
+	arjun.Gender, arjun.Email, arjun.Phone, arjun.DateOfBirth = "other", "arjun@mail.gov.in", "7000000000", "2001-01-01"
+
+	var users []dto.User
+	for _, u := range []dto.UserCreate{asha, ravi, meena, arjun} {
+		users = append(users, c.create(u))
+	}
+	return users
+}
+
+// read fetches a user as stored, with timestamps at the precision of the store
+func (c *client) read(id string) dto.User {
+	c.t.Helper()
+
+	var user dto.User
+	if status := c.do(http.MethodGet, "/aadhaar/users/"+id, adminKey, nil, &user); status != http.StatusOK {
+		c.t.Fatalf("get %s: status %d, want %d", id, status, http.StatusOK)
+	}
+	return user
+}
+
+func TestListFilters(t *testing.T) {
+	eachStore(t, func(t *testing.T, c *client) {
+		users := c.enrolFiltered()
+		meena := c.read(users[2].ID.String())
+
+		// Arjun is the only user updated since
+		if status := c.do(http.MethodPatch, "/aadhaar/users/"+users[3].ID.String(), adminKey, map[string]string{"phone": "7000000001"}, nil); status != http.StatusOK {
+			t.Fatalf("patch: status %d, want %d", status, http.StatusOK)
+		}
+		arjun := c.read(users[3].ID.String())
+
+		stamp := func(t *time.Time) string { return t.Format(time.RFC3339Nano) }
+
+		tests := []struct {
+			name  string
+			query url.Values
+			want  []string
+		}{
+			{"gender", url.Values{"gender": {"female"}}, []string{"Asha Rao", "Meena Iyer"}},
+			{"date of birth range includes both days", url.Values{"date_of_birth_from": {"1985-03-04"}, "date_of_birth_to": {"1990-06-15"}}, []string{"Asha Rao", "Ravi Kumar"}},
+			{"date of birth as DD-MM-YYYY", url.Values{"date_of_birth_from": {"31-12-1992"}}, []string{"Meena Iyer", "Arjun Das"}},
+			{"email domain ignores case", url.Values{"email_domain": {"Gov.In"}}, []string{"Asha Rao", "Meena Iyer"}},
+			{"email domain is not a suffix", url.Values{"email_domain": {"in"}}, []string{}},
+			{"phone prefix", url.Values{"phone_prefix": {"98111"}}, []string{"Asha Rao", "Meena Iyer"}},
+			{"pin code prefix", url.Values{"pin_code_prefix": {"560"}}, []string{"Asha Rao", "Meena Iyer"}},
+			{"application ids comma-separated", url.Values{"aadhaar_application_id": {"20000000000001,20000000000004"}}, []string{"Asha Rao", "Arjun Das"}},
+			{"application ids repeated", url.Values{"aadhaar_application_id": {"20000000000002", "20000000000003"}}, []string{"Ravi Kumar", "Meena Iyer"}},
+			{"created from includes the bound", url.Values{"created_from": {stamp(meena.CreatedAt)}}, []string{"Meena Iyer", "Arjun Das"}},
+			{"created to excludes the bound", url.Values{"created_to": {stamp(meena.CreatedAt)}}, []string{"Asha Rao", "Ravi Kumar"}},
+			{"updated from", url.Values{"updated_from": {stamp(arjun.UpdatedAt)}}, []string{"Arjun Das"}},
+			{"every filter must match", url.Values{"gender": {"female"}, "pin_code_prefix": {"56000"}, "email_domain": {"gov.in"}}, []string{"Asha Rao"}},
+			{"filters and search", url.Values{"gender": {"female"}, "search": {"a"}, "search_mode": {"prefix"}}, []string{"Asha Rao"}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				users := c.list(tt.query)
+
+				got := names(users)
+				sort.Strings(got)
+				want := slices.Clone(tt.want)
+				sort.Strings(want)
+				if !slices.Equal(got, want) {
+					t.Errorf("list %s = %v, want %v", tt.query.Encode(), got, want)
+				}
+				if total(users) != int64(len(tt.want)) {
+					t.Errorf("total %d, want %d", total(users), len(tt.want))
+				}
+			})
+		}
+	})
+}
+
+func TestListFilterCursors(t *testing.T) {
+	eachStore(t, func(t *testing.T, c *client) {
+		c.enrolFiltered()
+
+		query := url.Values{"gender": {"female"}, "limit": {"1"}, "sort_by": {"name"}, "order": {"asc"}}
+		first := c.list(query)
+		if first.NextCursor == "" {
+			t.Fatal("no next cursor")
+		}
+
+		// The same filters in another order continue the listing
+		next := c.list(with(with(url.Values{"sort_by": {"name"}, "order": {"asc"}, "limit": {"1"}}, "gender", "female"), "cursor", first.NextCursor))
+		if got := names(next); !slices.Equal(got, []string{"Meena Iyer"}) || next.NextCursor != "" {
+			t.Errorf("next page %v, next cursor %q", got, next.NextCursor)
+		}
+
+		var resp errorResponse
+		if status := c.do(http.MethodGet, "/aadhaar/users?"+with(with(query, "gender", "male"), "cursor", first.NextCursor).Encode(), adminKey, nil, &resp); status != http.StatusBadRequest {
+			t.Fatalf("other filter: status %d, want %d", status, http.StatusBadRequest)
+		}
+		if resp.Error != "Cursor does not match the sorting and filters of the query" {
+			t.Errorf("error %q", resp.Error)
+		}
+	})
+}
+
+func TestListFilterValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   url.Values
+		field   string
+		message string
+	}{
+		{"unknown gender", url.Values{"gender": {"unknown"}}, "Gender", "Gender must be one of: male female other"},
+		{"invalid date of birth", url.Values{"date_of_birth_from": {"1990-13-01"}}, "DateOfBirthFrom", "DateOfBirthFrom must be a date in YYYY-MM-DD or DD-MM-YYYY format"},
+		{"date of birth range reversed", url.Values{"date_of_birth_from": {"1990-01-02"}, "date_of_birth_to": {"01-01-1990"}}, "DateOfBirthTo", "DateOfBirthTo must not be before DateOfBirthFrom"},
+		{"created range reversed", url.Values{"created_from": {"2024-02-01T00:00:00Z"}, "created_to": {"2024-01-01T00:00:00Z"}}, "CreatedTo", "CreatedTo must not be before CreatedFrom"},
+		{"updated range reversed", url.Values{"updated_from": {"2024-02-01T00:00:00Z"}, "updated_to": {"2024-01-01T00:00:00Z"}}, "UpdatedTo", "UpdatedTo must not be before UpdatedFrom"},
+		{"email domain", url.Values{"email_domain": {"%.in"}}, "EmailDomain", "EmailDomain must be a domain name"},
+		{"phone prefix", url.Values{"phone_prefix": {"98_"}}, "PhonePrefix", "PhonePrefix must contain only numbers"},
+		{"pin code prefix", url.Values{"pin_code_prefix": {"5600011"}}, "PinCodePrefix", "PinCodePrefix must be at most 6 characters"},
+		{"application id", url.Values{"aadhaar_application_id": {"20000000000001,123"}}, "AadhaarApplicationIDs[1]", "AadhaarApplicationIDs[1] must be 14 digits and must not start with 0 or 1"},
+	}
+
+	eachKeying(t, func(t *testing.T, c *client) {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var resp errorResponse
+				if status := c.do(http.MethodGet, "/aadhaar/users?"+tt.query.Encode(), adminKey, nil, &resp); status != http.StatusBadRequest {
+					t.Fatalf("status %d, want %d", status, http.StatusBadRequest)
+				}
+				if len(resp.Details) != 1 || resp.Details[0].Field != tt.field || resp.Details[0].Message != tt.message {
+					t.Errorf("details = %+v, want %s: %s", resp.Details, tt.field, tt.message)
+				}
+			})
+		}
+
+		var resp errorResponse
+		if status := c.do(http.MethodGet, "/aadhaar/users?created_from=yesterday", adminKey, nil, &resp); status != http.StatusBadRequest || resp.Error != "Invalid query parameters" {
+			t.Errorf("unparsable timestamp: status %d, error %q", status, resp.Error)
+		}
+	})
+}
+
+func TestEncryptedListFilters(t *testing.T) {
+	tests := []struct {
+		name  string
+		query url.Values
+		want  []string
+	}{
+		{"date of birth whole years", url.Values{"date_of_birth_from": {"1985-01-01"}, "date_of_birth_to": {"31-12-1990"}}, []string{"Asha Rao", "Ravi Kumar"}},
+		{"date of birth from a year", url.Values{"date_of_birth_from": {"1992-01-01"}}, []string{"Meena Iyer", "Arjun Das"}},
+		{"email domain ignores case", url.Values{"email_domain": {"Gov.In"}}, []string{"Asha Rao", "Meena Iyer"}},
+		{"email domain is not a suffix", url.Values{"email_domain": {"in"}}, []string{}},
+		{"phone prefix", url.Values{"phone_prefix": {"98111"}}, []string{"Asha Rao", "Meena Iyer"}},
+		{"whole phone number", url.Values{"phone_prefix": {"9822222222"}}, []string{"Ravi Kumar"}},
+		{"every filter must match", url.Values{"gender": {"female"}, "phone_prefix": {"98"}, "email_domain": {"gov.in"}, "date_of_birth_to": {"1990-12-31"}}, []string{"Asha Rao"}},
+	}
+
+	refused := []struct {
+		name    string
+		query   url.Values
+		field   string
+		message string
+	}{
+		{"date of birth from within a year", url.Values{"date_of_birth_from": {"1985-03-04"}}, "DateOfBirthFrom", "DateOfBirthFrom must be the first day of a year while PII is encrypted"},
+		{"date of birth to within a year", url.Values{"date_of_birth_to": {"1990-06-15"}}, "DateOfBirthTo", "DateOfBirthTo must be the last day of a year while PII is encrypted"},
+	}
+
+	eachStoreWith(t, encrypted, func(t *testing.T, c *client) {
+		c.enrolFiltered()
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				users := c.list(tt.query)
+
+				got := names(users)
+				sort.Strings(got)
+				want := slices.Clone(tt.want)
+				sort.Strings(want)
+				if !slices.Equal(got, want) {
+					t.Errorf("list %s = %v, want %v", tt.query.Encode(), got, want)
+				}
+				if total(users) != int64(len(tt.want)) {
+					t.Errorf("total %d, want %d", total(users), len(tt.want))
+				}
+			})
+		}
+
+		for _, tt := range refused {
+			t.Run(tt.name, func(t *testing.T) {
+				var resp errorResponse
+				if status := c.do(http.MethodGet, "/aadhaar/users?"+tt.query.Encode(), adminKey, nil, &resp); status != http.StatusBadRequest {
+					t.Fatalf("status %d, want %d", status, http.StatusBadRequest)
+				}
+				if len(resp.Details) != 1 || resp.Details[0].Field != tt.field || resp.Details[0].Message != tt.message {
+					t.Errorf("details = %+v, want %s: %s", resp.Details, tt.field, tt.message)
+				}
+			})
+		}
+	})
+}
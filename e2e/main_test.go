@@ -0,0 +1,323 @@
+// Package e2e drives the real HTTP routes of the service through fiber.App.Test. Every test runs
+// against the in-memory store and, when one is available, against a throwaway Postgres:
+//
+//   - E2E_DB_HOST (with E2E_DB_PORT, E2E_DB_USER and E2E_DB_PASSWORD) points at an existing
+//     server, where each test creates and drops its own database;
+//   - otherwise, when initdb and pg_ctl are on PATH (or in E2E_PG_BIN), a temporary cluster
+//     is started for the run and removed afterwards.
+//
+// Without either, the Postgres variants are skipped.
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"aadhaar-user-service/cmd/app"
+	"aadhaar-user-service/internals/auth"
+	"aadhaar-user-service/internals/config"
+	"aadhaar-user-service/internals/database"
+	"aadhaar-user-service/internals/dedup"
+)
+
+// API keys the suite authenticates with, by role
+const (
+	adminKey    = "e2e-admin-key"
+	operatorKey = "e2e-operator-key"
+)
+
+// postgres is the server the Postgres variants create their databases on, nil when unavailable
+var postgres *config.Database
+
+// apiKeysFile holds the hashed API keys of the suite
+var apiKeysFile string
+
+// databases numbers the throwaway databases
+var databases atomic.Int64
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	// Access logs would drown the test output
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	dir, err := os.MkdirTemp("", "aadhaar-e2e-")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "create temp dir:", err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+
+	if err := writeAPIKeys(dir); err != nil {
+		fmt.Fprintln(os.Stderr, "write API keys:", err)
+		return 1
+	}
+
+	stop, err := setupPostgres(dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "start postgres:", err)
+		return 1
+	}
+	defer stop()
+
+	return m.Run()
+}
+
+// writeAPIKeys writes the hashed API keys of the suite to a file in dir
+func writeAPIKeys(dir string) error {
+	keys, err := json.Marshal([]auth.APIKey{
+		{ID: "admin", Hash: auth.HashAPIKey(adminKey), Roles: []string{"admin"}},
+		{ID: "operator", Hash: auth.HashAPIKey(operatorKey), Roles: []string{"operator"}},
+	})
+	if err != nil {
+		return err
+	}
+
+	apiKeysFile = filepath.Join(dir, "api-keys.json")
+	return os.WriteFile(apiKeysFile, keys, 0o600)
+}
+
+// setupPostgres finds or starts the Postgres server for the run and returns how to stop it
+func setupPostgres(dir string) (func(), error) {
+	if host := os.Getenv("E2E_DB_HOST"); host != "" {
+		db := config.Default().Database
+		db.Host = host
+		if port := os.Getenv("E2E_DB_PORT"); port != "" {
+			p, err := strconv.Atoi(port)
+			if err != nil {
+				return nil, fmt.Errorf("E2E_DB_PORT: %w", err)
+			}
+			db.Port = p
+		}
+		if user := os.Getenv("E2E_DB_USER"); user != "" {
+			db.User = user
+		}
+		db.Password = os.Getenv("E2E_DB_PASSWORD")
+		postgres = &db
+		return func() {}, nil
+	}
+
+	initdb, pgctl := pgBinary("initdb"), pgBinary("pg_ctl")
+	if initdb == "" || pgctl == "" {
+		return func() {}, nil
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+
+	data := filepath.Join(dir, "pgdata")
+	out, err := exec.Command(initdb, "-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-sync").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("initdb: %w: %s", err, out)
+	}
+
+	options := fmt.Sprintf("-p %d -k %s -c listen_addresses=127.0.0.1 -c fsync=off", port, dir)
+	out, err = exec.Command(pgctl, "-D", data, "-o", options, "-l", filepath.Join(dir, "postgres.log"), "-w", "start").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("pg_ctl start: %w: %s", err, out)
+	}
+
+	db := config.Default().Database
+	db.Host = "127.0.0.1"
+	db.Port = port
+	db.User = "postgres"
+	postgres = &db
+
+	return func() {
+		exec.Command(pgctl, "-D", data, "-m", "immediate", "stop").Run()
+	}, nil
+}
+
+// pgBinary looks up a Postgres program in E2E_PG_BIN or on PATH
+func pgBinary(name string) string {
+	if dir := os.Getenv("E2E_PG_BIN"); dir != "" {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// eachStore runs test as a subtest against a fresh instance on every available store. Duplicate
+// detection is off, as fixtures share names and dates of birth.
+func eachStore(t *testing.T, test func(t *testing.T, c *client)) {
+	eachStoreWith(t, func(cfg *config.Config) { cfg.Dedup.Mode = dedup.Off }, test)
+}
+
+// eachStoreWith runs test like eachStore, on instances configured by configure
+func eachStoreWith(t *testing.T, configure func(cfg *config.Config), test func(t *testing.T, c *client)) {
+	t.Run("memory", func(t *testing.T) {
+		cfg := defaultConfig()
+		cfg.Database.Driver = config.DriverMemory
+		configure(&cfg)
+		test(t, newClient(t, cfg))
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		if postgres == nil {
+			t.Skip("no Postgres available, set E2E_DB_HOST or put initdb and pg_ctl on PATH")
+		}
+		cfg := defaultConfig()
+		cfg.Database = *postgres
+		cfg.Database.Name = createDatabase(t)
+		configure(&cfg)
+		test(t, newClient(t, cfg))
+	})
+}
+
+// eachKeying runs test like eachStore, once with PII stored in plaintext and once encrypted
+func eachKeying(t *testing.T, test func(t *testing.T, c *client)) {
+	t.Run("plaintext", func(t *testing.T) { eachStore(t, test) })
+	t.Run("encrypted", func(t *testing.T) {
+		eachStoreWith(t, func(cfg *config.Config) {
+			cfg.Dedup.Mode = dedup.Off
+			encrypted(cfg)
+		}, test)
+	})
+}
+
+// encrypted configures PII encryption with fixed test keys
+func encrypted(cfg *config.Config) {
+	cfg.Encryption.MasterKeys = "k1:" + key('m')
+	cfg.Encryption.BlindIndexKey = key('b')
+}
+
+// key returns a base64-encoded 32-byte test key filled with b
+func key(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+// defaultConfig returns the default configuration, authenticating with the suite's API keys
+func defaultConfig() config.Config {
+	cfg := config.Default()
+	cfg.Auth.APIKeysFile = apiKeysFile
+	return cfg
+}
+
+// createDatabase creates an empty database for one test and drops it when the test ends
+func createDatabase(t *testing.T) string {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.Database = *postgres
+	cfg.Database.Name = "postgres"
+
+	admin, err := database.Connect(cfg, nil)
+	if err != nil {
+		t.Fatalf("connect to postgres: %v", err)
+	}
+	t.Cleanup(func() { database.Close(admin) })
+
+	name := fmt.Sprintf("aadhaar_e2e_%d_%d", os.Getpid(), databases.Add(1))
+	if err := admin.Exec("CREATE DATABASE " + name).Error; err != nil {
+		t.Fatalf("create database: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := admin.Exec("DROP DATABASE IF EXISTS " + name + " WITH (FORCE)").Error; err != nil {
+			t.Errorf("drop database: %v", err)
+		}
+	})
+
+	return name
+}
+
+// client sends requests to one instance of the service
+type client struct {
+	t   *testing.T
+	cfg config.Config
+	app *app.App
+}
+
+func newClient(t *testing.T, cfg config.Config) *client {
+	t.Helper()
+
+	a, err := app.New(cfg)
+	if err != nil {
+		t.Fatalf("build app: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := a.Shutdown(ctx); err != nil {
+			t.Errorf("shut down app: %v", err)
+		}
+	})
+
+	return &client{t: t, cfg: cfg, app: a}
+}
+
+// do sends a request authenticated with key, encoding body as JSON unless it is a string,
+// and decodes the JSON response into out when given
+func (c *client) do(method, path, key string, body, out any) int {
+	c.t.Helper()
+
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = bytes.NewBufferString(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			c.t.Fatalf("encode body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if reader != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+
+	resp, err := c.app.Fiber().Test(req, -1)
+	if err != nil {
+		c.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatalf("read response: %v", err)
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			c.t.Fatalf("%s %s: decode %s: %v", method, path, data, err)
+		}
+	}
+	return resp.StatusCode
+}
@@ -0,0 +1,82 @@
+package e2e
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"aadhaar-user-service/internals/dto"
+	"aadhaar-user-service/internals/encryption"
+	auditModel "aadhaar-user-service/models/audit"
+	userModel "aadhaar-user-service/models/users"
+	"aadhaar-user-service/services/audit"
+	"aadhaar-user-service/services/users"
+
+	"gorm.io/gorm"
+)
+
+// TestMaintenanceKeepsUpdatedAt runs reindex and rotate-keys over stored users and checks that
+// neither rewrite moves updated_at, which tells when an applicant's data last changed
+func TestMaintenanceKeepsUpdatedAt(t *testing.T) {
+	eachStoreWith(t, encrypted, func(t *testing.T, c *client) {
+		db := c.app.DB()
+		if db == nil {
+			t.Skip("maintenance commands need Postgres")
+		}
+
+		for n, name := range []string{"Asha Rao", "Ravi Kumar"} {
+			input := newUser(n+1, name)
+			input.AddressDetails = &dto.Address{House: "12", VillageTown: "Bengaluru", District: "Bengaluru Urban", State: "Karnataka", PinCode: "560001"}
+			c.create(input)
+		}
+
+		// Make the rows look written before migrations 008 and 009, long ago
+		since := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("SELECT set_config('aadhaar.maintenance', 'on', true)").Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("UPDATE users SET name_phonetic = NULL, birth_year = NULL, updated_at = ?", since).Error; err != nil {
+				return err
+			}
+			return tx.Exec("UPDATE user_addresses SET updated_at = ?", since).Error
+		})
+		if err != nil {
+			t.Fatalf("age rows: %v", err)
+		}
+
+		// Rotate to a second master key, keeping the first to decrypt
+		cfg := c.cfg.Encryption
+		cfg.MasterKeys += ",k2:" + key('n')
+		keys, err := encryption.Load(cfg)
+		if err != nil {
+			t.Fatalf("load keys: %v", err)
+		}
+		svc := users.New(userModel.NewGormRepository(db, keys), audit.NewRecorder(auditModel.NewGormRepository(db), keys, nil), nil)
+
+		if err := svc.Reindex(context.Background()); err != nil || svc.Reindexed != 2 {
+			t.Fatalf("reindex: %d rows, %v, want 2", svc.Reindexed, err)
+		}
+		if err := svc.RotateKeys(context.Background()); err != nil || svc.Reencrypted == 0 {
+			t.Fatalf("rotate keys: %d rows, %v, want some", svc.Reencrypted, err)
+		}
+
+		for _, table := range []string{"users", "user_addresses"} {
+			var rows []struct {
+				UpdatedAt time.Time
+				PIIKeyID  string `gorm:"column:pii_key_id"`
+			}
+			if err := db.Table(table).Select("updated_at", "pii_key_id").Find(&rows).Error; err != nil {
+				t.Fatalf("read %s: %v", table, err)
+			}
+			for _, r := range rows {
+				if r.PIIKeyID != "k2" {
+					t.Errorf("%s: key %q, want rows re-encrypted under k2", table, r.PIIKeyID)
+				}
+				if !r.UpdatedAt.Equal(since) {
+					t.Errorf("%s: updated_at %v, want it kept at %v", table, r.UpdatedAt, since)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,248 @@
+package e2e
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"testing"
+)
+
+// enrolApplicants creates users with names the search modes tell apart
+func (c *client) enrolApplicants() {
+	c.t.Helper()
+
+	for i, name := range []string{"Mohammed Iqbal", "Lakshmi Narayanan", "Asha Rao", "Ashok Kumar", "Rao Venkatesh"} {
+		u := newUser(i+1, name)
+		if name == "Lakshmi Narayanan" {
+			u.Email = "l.narayanan@example.org"
+		}
+		c.create(u)
+	}
+}
+
+func TestSearchModes(t *testing.T) {
+	tests := []struct {
+		name  string
+		query url.Values
+		want  []string
+	}{
+		{"exact name ignores case", url.Values{"search": {"asha rao"}, "search_mode": {"exact"}}, []string{"Asha Rao"}},
+		{"exact needs the whole name", url.Values{"search": {"Asha"}, "search_mode": {"exact"}}, []string{}},
+		{"exact email", url.Values{"search": {"L.Narayanan@example.org"}, "search_mode": {"exact"}}, []string{"Lakshmi Narayanan"}},
+		{"exact application id", url.Values{"search": {"20000000000005"}, "search_mode": {"exact"}}, []string{"Rao Venkatesh"}},
+		{"exact wildcards are literal", url.Values{"search": {"Asha%"}, "search_mode": {"exact"}}, []string{}},
+		{"prefix of a first name", url.Values{"search": {"ash"}, "search_mode": {"prefix"}}, []string{"Asha Rao", "Ashok Kumar"}},
+		{"prefix of a surname", url.Values{"search": {"narayan"}, "search_mode": {"prefix"}}, []string{"Lakshmi Narayanan"}},
+		{"prefix of every word", url.Values{"search": {"ra ven"}, "search_mode": {"prefix"}}, []string{"Rao Venkatesh"}},
+		{"prefix of the application id", url.Values{"search": {"2000000000000"}, "search_mode": {"prefix"}}, []string{"Ashok Kumar", "Asha Rao", "Lakshmi Narayanan", "Mohammed Iqbal", "Rao Venkatesh"}},
+		{"prefix is not a substring", url.Values{"search": {"shok"}, "search_mode": {"prefix"}}, []string{}},
+		{"fuzzy tolerates a typo", url.Values{"search": {"Mohamed Iqbal"}, "search_mode": {"fuzzy"}}, []string{"Mohammed Iqbal"}},
+		{"fuzzy tolerates a missing letter", url.Values{"search": {"Laksmi Narayanan"}, "search_mode": {"fuzzy"}}, []string{"Lakshmi Narayanan"}},
+		{"fuzzy with a strict threshold", url.Values{"search": {"Mohamed Iqbal"}, "search_mode": {"fuzzy"}, "similarity": {"0.95"}}, []string{}},
+		{"fuzzy without a match", url.Values{"search": {"Xavier"}, "search_mode": {"fuzzy"}}, []string{}},
+		{"full-text word", url.Values{"search": {"rao"}, "search_mode": {"fulltext"}}, []string{"Asha Rao", "Rao Venkatesh"}},
+		{"full-text needs every word", url.Values{"search": {"Asha Rao"}, "search_mode": {"fulltext"}}, []string{"Asha Rao"}},
+		{"full-text over email words", url.Values{"search": {"example.org"}, "search_mode": {"fulltext"}}, []string{"Lakshmi Narayanan"}},
+		{"full-text of punctuation only", url.Values{"search": {"@@"}, "search_mode": {"fulltext"}}, []string{}},
+		{"contains stays the default", url.Values{"search": {"sha"}}, []string{"Asha Rao"}},
+	}
+
+	eachStore(t, func(t *testing.T, c *client) {
+		c.enrolApplicants()
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				users := c.list(tt.query)
+
+				got := names(users)
+				sort.Strings(got)
+				want := slices.Clone(tt.want)
+				sort.Strings(want)
+				if !slices.Equal(got, want) {
+					t.Errorf("search %s = %v, want %v", tt.query.Encode(), got, want)
+				}
+
+				for _, u := range users.Users {
+					if u.Score == nil {
+						t.Errorf("%s has no score", u.Name)
+					}
+				}
+			})
+		}
+	})
+}
+
+func TestSearchRanking(t *testing.T) {
+	eachStore(t, func(t *testing.T, c *client) {
+		c.enrolApplicants()
+
+		// Ranked by relevance when a mode is given, best match first
+		users := c.list(url.Values{"search": {"Asha Rao"}, "search_mode": {"fuzzy"}, "similarity": {"0.1"}})
+		if len(users.Users) < 2 || users.Users[0].Name != "Asha Rao" {
+			t.Fatalf("ranking = %v, want Asha Rao first", names(users))
+		}
+		if *users.Users[0].Score != 1 {
+			t.Errorf("score of the exact match = %v, want 1", *users.Users[0].Score)
+		}
+		for i := 1; i < len(users.Users); i++ {
+			if *users.Users[i].Score > *users.Users[i-1].Score {
+				t.Errorf("scores not descending: %v", names(users))
+			}
+		}
+
+		// An explicit sort wins over relevance
+		sorted := c.list(url.Values{"search": {"rao"}, "search_mode": {"fulltext"}, "sort_by": {"name"}, "order": {"desc"}})
+		if got := names(sorted); !slices.Equal(got, []string{"Rao Venkatesh", "Asha Rao"}) {
+			t.Errorf("sorted by name = %v", got)
+		}
+
+		// Search without a mode keeps the newest-first order, still with scores
+		plain := c.list(url.Values{"search": {"a"}})
+		if got := names(plain); !slices.Equal(got, []string{"Rao Venkatesh", "Ashok Kumar", "Asha Rao", "Lakshmi Narayanan", "Mohammed Iqbal"}) {
+			t.Errorf("contains order = %v", got)
+		}
+
+		// Cursors walk the ranking like one long page
+		query := url.Values{"search": {"Asha Rao"}, "search_mode": {"fuzzy"}, "similarity": {"0.05"}, "limit": {"2"}}
+		want := ids(c.list(with(query, "limit", "100")).Users)
+		if got := slices.Concat(c.walk(query, false)...); !slices.Equal(got, want) {
+			t.Errorf("cursor pages = %v, want %v", got, want)
+		}
+		backward := c.walk(query, true)
+		slices.Reverse(backward)
+		if got := slices.Concat(backward...); !slices.Equal(got, want) {
+			t.Errorf("backward cursor pages = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestPhoneticSearch(t *testing.T) {
+	tests := []struct {
+		name  string
+		query url.Values
+		want  []string
+	}{
+		{"vowel spellings", url.Values{"search": {"Mohammed"}, "search_mode": {"phonetic"}}, []string{"Muhammad Rafi"}},
+		{"ksh and x", url.Values{"search": {"Lakshmi"}, "search_mode": {"phonetic"}}, []string{"लक्ष्मी देवी", "Laxmi Narayan"}},
+		{"leading vowel and q", url.Values{"search": {"Iqbal"}, "search_mode": {"phonetic"}}, []string{"Eqbal Ahmed"}},
+		{"devanagari query for a latin name", url.Values{"search": {"विजय शर्मा"}, "search_mode": {"phonetic"}}, []string{"Vijay Sharma"}},
+		{"tamil query for a latin name", url.Values{"search": {"லக்ஷ்மி"}, "search_mode": {"phonetic"}}, []string{"लक्ष्मी देवी", "Laxmi Narayan"}},
+		{"needs every word", url.Values{"search": {"Laxmi Devi"}, "search_mode": {"phonetic"}}, []string{"लक्ष्मी देवी"}},
+		{"ignores emails", url.Values{"search": {"applicant"}, "search_mode": {"phonetic"}}, []string{}},
+		{"digits only", url.Values{"search": {"2024"}, "search_mode": {"phonetic"}}, []string{}},
+		{"full-text over transliterated words", url.Values{"search": {"devi"}, "search_mode": {"fulltext"}}, []string{"लक्ष्मी देवी"}},
+		{"prefix over transliterated words", url.Values{"search": {"laks"}, "search_mode": {"prefix"}}, []string{"लक्ष्मी देवी"}},
+	}
+
+	eachStore(t, func(t *testing.T, c *client) {
+		for i, name := range []string{"Muhammad Rafi", "लक्ष्मी देवी", "Laxmi Narayan", "Eqbal Ahmed", "Vijay Sharma"} {
+			c.create(newUser(i+1, name))
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				users := c.list(tt.query)
+
+				got := names(users)
+				sort.Strings(got)
+				want := slices.Clone(tt.want)
+				sort.Strings(want)
+				if !slices.Equal(got, want) {
+					t.Errorf("search %s = %v, want %v", tt.query.Encode(), got, want)
+				}
+
+				for _, u := range users.Users {
+					if u.Score == nil {
+						t.Errorf("%s has no score", u.Name)
+					}
+				}
+			})
+		}
+	})
+}
+
+func TestSearchValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   url.Values
+		field   string
+		message string
+	}{
+		{"unknown mode", url.Values{"search": {"a"}, "search_mode": {"soundex"}}, "SearchMode", "SearchMode must be one of: contains exact prefix fuzzy fulltext phonetic"},
+		{"zero similarity", url.Values{"search": {"a"}, "search_mode": {"fuzzy"}, "similarity": {"0"}}, "Similarity", "Similarity must be greater than 0"},
+		{"similarity above one", url.Values{"search": {"a"}, "search_mode": {"fuzzy"}, "similarity": {"1.5"}}, "Similarity", "Similarity must be at most 1"},
+	}
+
+	eachStore(t, func(t *testing.T, c *client) {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var resp errorResponse
+				if status := c.do(http.MethodGet, "/aadhaar/users?"+tt.query.Encode(), adminKey, nil, &resp); status != http.StatusBadRequest {
+					t.Fatalf("status %d, want %d", status, http.StatusBadRequest)
+				}
+				if len(resp.Details) != 1 || resp.Details[0].Field != tt.field || resp.Details[0].Message != tt.message {
+					t.Errorf("details = %+v, want %s: %s", resp.Details, tt.field, tt.message)
+				}
+			})
+		}
+	})
+}
+
+func TestEncryptedSearch(t *testing.T) {
+	tests := []struct {
+		name  string
+		query url.Values
+		want  []string
+	}{
+		{"exact name", url.Values{"search": {"asha rao"}, "search_mode": {"exact"}}, []string{"Asha Rao"}},
+		{"exact email", url.Values{"search": {"L.Narayanan@example.org"}, "search_mode": {"exact"}}, []string{"Lakshmi Narayanan"}},
+		{"exact application id", url.Values{"search": {"20000000000005"}, "search_mode": {"exact"}}, []string{"Rao Venkatesh"}},
+		{"full-text word", url.Values{"search": {"rao"}, "search_mode": {"fulltext"}}, []string{"Asha Rao", "Rao Venkatesh"}},
+		{"phonetic", url.Values{"search": {"Mohamad Ikbal"}, "search_mode": {"phonetic"}}, []string{"Mohammed Iqbal"}},
+		{"sorted by application id", url.Values{"sort_by": {"aadhaar_application_id"}, "limit": {"2"}}, []string{"Rao Venkatesh", "Ashok Kumar"}},
+	}
+
+	refused := []struct {
+		name    string
+		query   url.Values
+		field   string
+		message string
+	}{
+		{"sort by name", url.Values{"sort_by": {"name"}}, "SortBy", "SortBy name is not available while PII is encrypted"},
+		{"sort by email", url.Values{"sort_by": {"email"}, "order": {"asc"}}, "SortBy", "SortBy email is not available while PII is encrypted"},
+		{"contains", url.Values{"search": {"sha"}}, "SearchMode", "SearchMode contains is not available while PII is encrypted, use exact, fulltext or phonetic"},
+		{"prefix", url.Values{"search": {"ash"}, "search_mode": {"prefix"}}, "SearchMode", "SearchMode prefix is not available while PII is encrypted, use exact, fulltext or phonetic"},
+		{"fuzzy", url.Values{"search": {"Mohamed Iqbal"}, "search_mode": {"fuzzy"}}, "SearchMode", "SearchMode fuzzy is not available while PII is encrypted, use exact, fulltext or phonetic"},
+	}
+
+	eachStoreWith(t, encrypted, func(t *testing.T, c *client) {
+		c.enrolApplicants()
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// Without a sort the matches are compared in any order
+				got, want := names(c.list(tt.query)), slices.Clone(tt.want)
+				if !tt.query.Has("sort_by") {
+					sort.Strings(got)
+					sort.Strings(want)
+				}
+				if !slices.Equal(got, want) {
+					t.Errorf("list %s = %v, want %v", tt.query.Encode(), got, want)
+				}
+			})
+		}
+
+		for _, tt := range refused {
+			t.Run(tt.name, func(t *testing.T) {
+				var resp errorResponse
+				if status := c.do(http.MethodGet, "/aadhaar/users?"+tt.query.Encode(), adminKey, nil, &resp); status != http.StatusBadRequest {
+					t.Fatalf("status %d, want %d", status, http.StatusBadRequest)
+				}
+				if len(resp.Details) != 1 || resp.Details[0].Field != tt.field || resp.Details[0].Message != tt.message {
+					t.Errorf("details = %+v, want %s: %s", resp.Details, tt.field, tt.message)
+				}
+			})
+		}
+	})
+}
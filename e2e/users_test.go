@@ -0,0 +1,527 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"aadhaar-user-service/internals/dto"
+	"aadhaar-user-service/internals/validator"
+
+	"github.com/google/uuid"
+)
+
+// errorResponse mirrors the controllers' ErrorResponse
+type errorResponse struct {
+	Error      string                      `json:"error"`
+	Details    []validator.ValidationError `json:"details"`
+	Duplicates []dto.Duplicate             `json:"duplicates"`
+}
+
+// newUser returns a valid create payload, unique per n
+func newUser(n int, name string) dto.UserCreate {
+	return dto.UserCreate{
+		AadhaarApplicationID: fmt.Sprintf("2%013d", n),
+		Name:                 name,
+		Email:                fmt.Sprintf("applicant%d@example.com", n),
+		Phone:                fmt.Sprintf("98%08d", n),
+		Address:              "12 MG Road, Bengaluru",
+		DateOfBirth:          "1990-01-02",
+		Gender:               "female",
+	}
+}
+
+// create adds a user as admin and fails the test unless it is created
+func (c *client) create(input dto.UserCreate) dto.User {
+	c.t.Helper()
+
+	var user dto.User
+	if status := c.do(http.MethodPost, "/aadhaar/users", adminKey, input, &user); status != http.StatusCreated {
+		c.t.Fatalf("create %s: status %d, want %d", input.Name, status, http.StatusCreated)
+	}
+	return user
+}
+
+// list fetches /aadhaar/users with the given query as admin
+func (c *client) list(query url.Values) dto.Users {
+	c.t.Helper()
+
+	var users dto.Users
+	if status := c.do(http.MethodGet, "/aadhaar/users?"+query.Encode(), adminKey, nil, &users); status != http.StatusOK {
+		c.t.Fatalf("list %s: status %d, want %d", query.Encode(), status, http.StatusOK)
+	}
+	return users
+}
+
+// total returns the counted total of a list, or -1 when it was not counted
+func total(users dto.Users) int64 {
+	if users.Total == nil {
+		return -1
+	}
+	return *users.Total
+}
+
+// totalPages returns the page count of a list, or -1 when the total was not counted
+func totalPages(users dto.Users) int {
+	if users.TotalPages == nil {
+		return -1
+	}
+	return *users.TotalPages
+}
+
+func names(users dto.Users) []string {
+	names := make([]string, len(users.Users))
+	for i, u := range users.Users {
+		names[i] = u.Name
+	}
+	return names
+}
+
+func TestUserLifecycle(t *testing.T) {
+	eachKeying(t, func(t *testing.T, c *client) {
+		input := newUser(1, "Asha Rao")
+		created := c.create(input)
+
+		if created.ID == uuid.Nil {
+			t.Fatal("created user has no id")
+		}
+		if created.Email != input.Email || created.Phone != input.Phone || created.DateOfBirth != input.DateOfBirth {
+			t.Errorf("created user = %+v, want the submitted values unmasked for admin", created)
+		}
+		if created.Age == nil || *created.Age < 30 {
+			t.Errorf("age = %v, want the age computed from %s", created.Age, input.DateOfBirth)
+		}
+
+		path := "/aadhaar/users/" + created.ID.String()
+
+		var fetched dto.User
+		if status := c.do(http.MethodGet, path, adminKey, nil, &fetched); status != http.StatusOK {
+			t.Fatalf("get: status %d, want %d", status, http.StatusOK)
+		}
+		if fetched.ID != created.ID || fetched.Name != input.Name || fetched.AadhaarApplicationID != input.AadhaarApplicationID {
+			t.Errorf("get = %+v, want %+v", fetched, created)
+		}
+
+		// Operators see PII masked
+		var masked dto.User
+		c.do(http.MethodGet, path, operatorKey, nil, &masked)
+		if masked.Phone != "XXXXXX0001" || masked.DateOfBirth != "1990" {
+			t.Errorf("operator view phone=%q dob=%q, want masked", masked.Phone, masked.DateOfBirth)
+		}
+
+		if users := c.list(nil); total(users) != 1 || len(users.Users) != 1 || users.Users[0].ID != created.ID {
+			t.Errorf("list = %+v, want only the created user", users)
+		}
+
+		if status := c.do(http.MethodDelete, path, adminKey, nil, nil); status != http.StatusNoContent {
+			t.Fatalf("delete: status %d, want %d", status, http.StatusNoContent)
+		}
+
+		var notFound errorResponse
+		if status := c.do(http.MethodGet, path, adminKey, nil, &notFound); status != http.StatusNotFound {
+			t.Errorf("get after delete: status %d, want %d", status, http.StatusNotFound)
+		}
+		if notFound.Error != "User not found" {
+			t.Errorf("get after delete: error %q", notFound.Error)
+		}
+
+		if users := c.list(nil); total(users) != 0 {
+			t.Errorf("list after delete: total %d, want 0", total(users))
+		}
+
+		// Soft-deleted users stay visible to those allowed to see them
+		var deleted dto.User
+		if status := c.do(http.MethodGet, path+"?include_deleted=true", adminKey, nil, &deleted); status != http.StatusOK {
+			t.Fatalf("get deleted: status %d, want %d", status, http.StatusOK)
+		}
+		if deleted.DeletedAt == nil {
+			t.Error("get deleted: deleted_at not set")
+		}
+		if deleted.UpdatedAt == nil || fetched.UpdatedAt == nil || !deleted.UpdatedAt.After(*fetched.UpdatedAt) {
+			t.Errorf("get deleted: updated_at %v, want it moved past %v by the delete", deleted.UpdatedAt, fetched.UpdatedAt)
+		}
+		if status := c.do(http.MethodGet, path+"?include_deleted=true", operatorKey, nil, nil); status != http.StatusForbidden {
+			t.Errorf("get deleted as operator: status %d, want %d", status, http.StatusForbidden)
+		}
+
+		if status := c.do(http.MethodDelete, path, adminKey, nil, nil); status != http.StatusNotFound {
+			t.Errorf("second delete: status %d, want %d", status, http.StatusNotFound)
+		}
+	})
+}
+
+func TestUnauthenticated(t *testing.T) {
+	eachStore(t, func(t *testing.T, c *client) {
+		var resp errorResponse
+		if status := c.do(http.MethodGet, "/aadhaar/users", "", nil, &resp); status != http.StatusUnauthorized {
+			t.Errorf("status %d, want %d", status, http.StatusUnauthorized)
+		}
+		if status := c.do(http.MethodGet, "/aadhaar/users", "wrong-key", nil, nil); status != http.StatusUnauthorized {
+			t.Errorf("wrong key: status %d, want %d", status, http.StatusUnauthorized)
+		}
+	})
+}
+
+func TestCreateValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(u *dto.UserCreate)
+		field   string
+		message string
+	}{
+		{
+			name:    "missing name",
+			modify:  func(u *dto.UserCreate) { u.Name = "" },
+			field:   "Name",
+			message: "Name is required",
+		},
+		{
+			name:    "short name",
+			modify:  func(u *dto.UserCreate) { u.Name = "A" },
+			field:   "Name",
+			message: "Name must be at least 2 characters",
+		},
+		{
+			name:    "invalid email",
+			modify:  func(u *dto.UserCreate) { u.Email = "not-an-email" },
+			field:   "Email",
+			message: "Email must be a valid email address",
+		},
+		{
+			name:    "short phone",
+			modify:  func(u *dto.UserCreate) { u.Phone = "98765" },
+			field:   "Phone",
+			message: "Phone must be exactly 10 characters",
+		},
+		{
+			name:    "application id with leading 1",
+			modify:  func(u *dto.UserCreate) { u.AadhaarApplicationID = "12345678901234" },
+			field:   "AadhaarApplicationID",
+			message: "AadhaarApplicationID must be 14 digits and must not start with 0 or 1",
+		},
+		{
+			name:    "application id with letters",
+			modify:  func(u *dto.UserCreate) { u.AadhaarApplicationID = "2345678901234A" },
+			field:   "AadhaarApplicationID",
+			message: "AadhaarApplicationID must be 14 digits and must not start with 0 or 1",
+		},
+		{
+			name:    "date of birth in the future",
+			modify:  func(u *dto.UserCreate) { u.DateOfBirth = "2999-01-01" },
+			field:   "DateOfBirth",
+			message: "DateOfBirth must be a past date in YYYY-MM-DD or DD-MM-YYYY format, at most 125 years ago",
+		},
+		{
+			name:    "unknown gender",
+			modify:  func(u *dto.UserCreate) { u.Gender = "unknown" },
+			field:   "Gender",
+			message: "Gender must be one of: male female other",
+		},
+		{
+			name:    "no address",
+			modify:  func(u *dto.UserCreate) { u.Address = "" },
+			field:   "Address",
+			message: "Address is required when AddressDetails is not provided",
+		},
+		{
+			name: "district of another state",
+			modify: func(u *dto.UserCreate) {
+				u.AddressDetails = &dto.Address{House: "4", VillageTown: "Mumbai", District: "Mumbai City", State: "Karnataka", PinCode: "560001"}
+			},
+			field:   "District",
+			message: "District is not a district of Karnataka",
+		},
+	}
+
+	eachStore(t, func(t *testing.T, c *client) {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				input := newUser(1, "Asha Rao")
+				tt.modify(&input)
+
+				var resp errorResponse
+				if status := c.do(http.MethodPost, "/aadhaar/users", adminKey, input, &resp); status != http.StatusBadRequest {
+					t.Fatalf("status %d, want %d", status, http.StatusBadRequest)
+				}
+				if resp.Error != "Validation failed" {
+					t.Errorf("error %q, want %q", resp.Error, "Validation failed")
+				}
+				want := validator.ValidationError{Field: tt.field, Message: tt.message}
+				if !slices.Contains(resp.Details, want) {
+					t.Errorf("details %+v, want %+v", resp.Details, want)
+				}
+			})
+		}
+
+		t.Run("malformed body", func(t *testing.T) {
+			var resp errorResponse
+			if status := c.do(http.MethodPost, "/aadhaar/users", adminKey, `{"name":`, &resp); status != http.StatusBadRequest {
+				t.Fatalf("status %d, want %d", status, http.StatusBadRequest)
+			}
+			if resp.Error != "Invalid request body" {
+				t.Errorf("error %q, want %q", resp.Error, "Invalid request body")
+			}
+		})
+
+		if users := c.list(nil); total(users) != 0 {
+			t.Errorf("%d users stored after rejected creates", total(users))
+		}
+	})
+}
+
+func TestConflicts(t *testing.T) {
+	eachKeying(t, func(t *testing.T, c *client) {
+		first := c.create(newUser(1, "Asha Rao"))
+		second := c.create(newUser(2, "Bhavna Iyer"))
+
+		tests := []struct {
+			name   string
+			method string
+			path   string
+			input  dto.UserCreate
+			error  string
+		}{
+			{
+				name:   "create with taken email",
+				method: http.MethodPost,
+				path:   "/aadhaar/users",
+				input:  func() dto.UserCreate { u := newUser(3, "Chetan Das"); u.Email = first.Email; return u }(),
+				error:  "Email already exists",
+			},
+			{
+				name:   "create with taken email in other case",
+				method: http.MethodPost,
+				path:   "/aadhaar/users",
+				input: func() dto.UserCreate {
+					u := newUser(3, "Chetan Das")
+					u.Email = strings.ToUpper(first.Email)
+					return u
+				}(),
+				error: "Email already exists",
+			},
+			{
+				name:   "create with taken application id",
+				method: http.MethodPost,
+				path:   "/aadhaar/users",
+				input: func() dto.UserCreate {
+					u := newUser(3, "Chetan Das")
+					u.AadhaarApplicationID = first.AadhaarApplicationID
+					return u
+				}(),
+				error: "Aadhaar application ID already exists",
+			},
+			{
+				name:   "update to taken email",
+				method: http.MethodPut,
+				path:   "/aadhaar/users/" + second.ID.String(),
+				input:  func() dto.UserCreate { u := newUser(2, "Bhavna Iyer"); u.Email = first.Email; return u }(),
+				error:  "Email already exists",
+			},
+			{
+				name:   "update to taken email in other case",
+				method: http.MethodPut,
+				path:   "/aadhaar/users/" + second.ID.String(),
+				input: func() dto.UserCreate {
+					u := newUser(2, "Bhavna Iyer")
+					u.Email = "Applicant1@Example.com"
+					return u
+				}(),
+				error: "Email already exists",
+			},
+			{
+				name:   "update to taken application id",
+				method: http.MethodPut,
+				path:   "/aadhaar/users/" + second.ID.String(),
+				input: func() dto.UserCreate {
+					u := newUser(2, "Bhavna Iyer")
+					u.AadhaarApplicationID = first.AadhaarApplicationID
+					return u
+				}(),
+				error: "Aadhaar application ID already exists",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var resp errorResponse
+				if status := c.do(tt.method, tt.path, adminKey, tt.input, &resp); status != http.StatusConflict {
+					t.Fatalf("status %d, want %d", status, http.StatusConflict)
+				}
+				if resp.Error != tt.error {
+					t.Errorf("error %q, want %q", resp.Error, tt.error)
+				}
+			})
+		}
+
+		t.Run("values of deleted users are free", func(t *testing.T) {
+			c.do(http.MethodDelete, "/aadhaar/users/"+first.ID.String(), adminKey, nil, nil)
+
+			reused := newUser(3, "Chetan Das")
+			reused.Email = first.Email
+			reused.AadhaarApplicationID = first.AadhaarApplicationID
+			c.create(reused)
+
+			// Restoring the original would now collide
+			var resp errorResponse
+			if status := c.do(http.MethodPost, "/aadhaar/users/"+first.ID.String()+"/restore", adminKey, nil, &resp); status != http.StatusConflict {
+				t.Errorf("restore: status %d, want %d", status, http.StatusConflict)
+			}
+		})
+	})
+}
+
+func TestInvalidIDs(t *testing.T) {
+	missing := "/aadhaar/users/" + uuid.NewString()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+		status int
+		error  string
+	}{
+		{"get malformed id", http.MethodGet, "/aadhaar/users/not-a-uuid", nil, http.StatusBadRequest, "Invalid user ID format"},
+		{"get unknown id", http.MethodGet, missing, nil, http.StatusNotFound, "User not found"},
+		{"update malformed id", http.MethodPut, "/aadhaar/users/not-a-uuid", newUser(1, "Asha Rao"), http.StatusBadRequest, "Invalid user ID format"},
+		{"update unknown id", http.MethodPut, missing, newUser(1, "Asha Rao"), http.StatusNotFound, "User not found"},
+		{"patch malformed id", http.MethodPatch, "/aadhaar/users/not-a-uuid", `{"name":"Asha Rao"}`, http.StatusBadRequest, "Invalid user ID format"},
+		{"patch unknown id", http.MethodPatch, missing, `{"name":"Asha Rao"}`, http.StatusNotFound, "User not found"},
+		{"delete malformed id", http.MethodDelete, "/aadhaar/users/12345", nil, http.StatusBadRequest, "Invalid user ID format"},
+		{"delete unknown id", http.MethodDelete, missing, nil, http.StatusNotFound, "User not found"},
+		{"restore malformed id", http.MethodPost, "/aadhaar/users/not-a-uuid/restore", nil, http.StatusBadRequest, "Invalid user ID format"},
+		{"restore unknown id", http.MethodPost, missing + "/restore", nil, http.StatusNotFound, "User not found"},
+	}
+
+	eachKeying(t, func(t *testing.T, c *client) {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var resp errorResponse
+				if status := c.do(tt.method, tt.path, adminKey, tt.body, &resp); status != tt.status {
+					t.Fatalf("status %d, want %d", status, tt.status)
+				}
+				if resp.Error != tt.error {
+					t.Errorf("error %q, want %q", resp.Error, tt.error)
+				}
+			})
+		}
+	})
+}
+
+func TestPagination(t *testing.T) {
+	tests := []struct {
+		name      string
+		page      string
+		limit     string
+		wantPage  int
+		wantLimit int
+		wantLen   int
+		wantTotal int64
+		wantPages int
+	}{
+		{name: "defaults", wantPage: 1, wantLimit: 10, wantLen: 10, wantTotal: 25, wantPages: 3},
+		{name: "middle page", page: "2", limit: "10", wantPage: 2, wantLimit: 10, wantLen: 10, wantTotal: 25, wantPages: 3},
+		{name: "partial last page", page: "3", limit: "10", wantPage: 3, wantLimit: 10, wantLen: 5, wantTotal: 25, wantPages: 3},
+		{name: "past the last page", page: "4", limit: "10", wantPage: 4, wantLimit: 10, wantLen: 0, wantTotal: 25, wantPages: 3},
+		{name: "exact division", page: "1", limit: "5", wantPage: 1, wantLimit: 5, wantLen: 5, wantTotal: 25, wantPages: 5},
+		{name: "single page", page: "1", limit: "25", wantPage: 1, wantLimit: 25, wantLen: 25, wantTotal: 25, wantPages: 1},
+		{name: "limit capped at 100", page: "1", limit: "500", wantPage: 1, wantLimit: 100, wantLen: 25, wantTotal: 25, wantPages: 1},
+		{name: "invalid page and limit fall back", page: "0", limit: "-3", wantPage: 1, wantLimit: 10, wantLen: 10, wantTotal: 25, wantPages: 3},
+	}
+
+	eachKeying(t, func(t *testing.T, c *client) {
+		if users := c.list(nil); users.Page != 1 || total(users) != 0 || totalPages(users) != 0 || len(users.Users) != 0 {
+			t.Errorf("empty store: page=%d total=%d total_pages=%d len=%d, want page=1 and nothing else",
+				users.Page, total(users), totalPages(users), len(users.Users))
+		}
+
+		for i := 1; i <= 25; i++ {
+			c.create(newUser(i, fmt.Sprintf("Applicant %02d", i)))
+		}
+
+		seen := map[uuid.UUID]bool{}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				query := url.Values{}
+				if tt.page != "" {
+					query.Set("page", tt.page)
+				}
+				if tt.limit != "" {
+					query.Set("limit", tt.limit)
+				}
+
+				users := c.list(query)
+
+				if users.Page != tt.wantPage || users.Limit != tt.wantLimit || len(users.Users) != tt.wantLen ||
+					total(users) != tt.wantTotal || totalPages(users) != tt.wantPages {
+					t.Errorf("page=%d limit=%d len=%d total=%d total_pages=%d, want page=%d limit=%d len=%d total=%d total_pages=%d",
+						users.Page, users.Limit, len(users.Users), total(users), totalPages(users),
+						tt.wantPage, tt.wantLimit, tt.wantLen, tt.wantTotal, tt.wantPages)
+				}
+
+				if tt.limit == "10" {
+					for _, u := range users.Users {
+						if seen[u.ID] {
+							t.Errorf("user %s listed on more than one page", u.ID)
+						}
+						seen[u.ID] = true
+					}
+				}
+			})
+		}
+	})
+}
+
+func TestSearchAndSort(t *testing.T) {
+	eachStore(t, func(t *testing.T, c *client) {
+		c.create(newUser(1, "Chetan Das"))
+		c.create(newUser(2, "Asha Rao"))
+		iyer := newUser(3, "Bhavna Iyer")
+		iyer.Email = "bhavna.iyer@example.org"
+		c.create(iyer)
+
+		searches := []struct {
+			search string
+			want   []string
+		}{
+			{"Iyer", []string{"Bhavna Iyer"}},
+			{"iyer", []string{"Bhavna Iyer"}},
+			{"ASHA", []string{"Asha Rao"}},
+			{"example.org", []string{"Bhavna Iyer"}},
+			{"00000000001", []string{"Chetan Das"}},
+			{"nobody", []string{}},
+		}
+		for _, tt := range searches {
+			t.Run("search "+tt.search, func(t *testing.T) {
+				users := c.list(url.Values{"search": {tt.search}, "sort_by": {"name"}, "order": {"asc"}})
+				if got := names(users); !slices.Equal(got, tt.want) || total(users) != int64(len(tt.want)) {
+					t.Errorf("search %q = %v (total %d), want %v", tt.search, got, total(users), tt.want)
+				}
+			})
+		}
+
+		sorts := []struct {
+			name  string
+			query url.Values
+			want  []string
+		}{
+			{"name ascending", url.Values{"sort_by": {"name"}, "order": {"asc"}}, []string{"Asha Rao", "Bhavna Iyer", "Chetan Das"}},
+			{"name descending", url.Values{"sort_by": {"name"}, "order": {"desc"}}, []string{"Chetan Das", "Bhavna Iyer", "Asha Rao"}},
+			{"application id ascending", url.Values{"sort_by": {"aadhaar_application_id"}, "order": {"asc"}}, []string{"Chetan Das", "Asha Rao", "Bhavna Iyer"}},
+			{"created_at ascending", url.Values{"sort_by": {"created_at"}, "order": {"asc"}}, []string{"Chetan Das", "Asha Rao", "Bhavna Iyer"}},
+			{"default is newest first", url.Values{}, []string{"Bhavna Iyer", "Asha Rao", "Chetan Das"}},
+			{"unknown column falls back to created_at", url.Values{"sort_by": {"phone; DROP TABLE users"}}, []string{"Bhavna Iyer", "Asha Rao", "Chetan Das"}},
+			{"unknown order falls back to descending", url.Values{"sort_by": {"name"}, "order": {"sideways"}}, []string{"Chetan Das", "Bhavna Iyer", "Asha Rao"}},
+		}
+		for _, tt := range sorts {
+			t.Run("sort "+tt.name, func(t *testing.T) {
+				if got := names(c.list(tt.query)); !slices.Equal(got, tt.want) {
+					t.Errorf("order = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	})
+}
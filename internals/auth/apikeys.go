@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// APIKey is a static API key, stored only as the hex SHA-256 of the key
+type APIKey struct {
+	ID    string   `json:"id"`
+	Hash  string   `json:"hash"`
+	Roles []string `json:"roles"`
+
+	hash []byte
+}
+
+// APIKeyStore validates static API keys
+type APIKeyStore struct {
+	keys []APIKey
+}
+
+// LoadAPIKeys reads hashed API keys from the JSON file at path.
+// It returns nil when path is empty.
+func LoadAPIKeys(path string) (*APIKeyStore, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read API keys: %w", err)
+	}
+
+	var keys []APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("parse API keys: %w", err)
+	}
+
+	for i := range keys {
+		if keys[i].ID == "" {
+			return nil, errors.New("API key without id")
+		}
+		hash, err := hex.DecodeString(strings.TrimPrefix(keys[i].Hash, "sha256:"))
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("API key %q: hash must be a hex SHA-256 digest", keys[i].ID)
+		}
+		keys[i].hash = hash
+	}
+
+	return &APIKeyStore{keys: keys}, nil
+}
+
+// HashAPIKey returns the hex SHA-256 digest stored for an API key
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify looks up the caller owning an API key
+func (s *APIKeyStore) Verify(key string) (*Principal, error) {
+	sum := sha256.Sum256([]byte(key))
+
+	// Compare against every key so timing does not reveal which one matched
+	var match *APIKey
+	for i := range s.keys {
+		if subtle.ConstantTimeCompare(sum[:], s.keys[i].hash) == 1 {
+			match = &s.keys[i]
+		}
+	}
+
+	if match == nil {
+		return nil, errors.New("unknown API key")
+	}
+	return &Principal{Subject: "apikey:" + match.ID, Roles: match.Roles}, nil
+}
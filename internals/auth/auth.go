@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+
+	"aadhaar-user-service/internals/config"
+)
+
+var (
+	ErrMissingCredentials = errors.New("missing credentials")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Authenticator identifies callers from bearer tokens or API keys
+type Authenticator struct {
+	jwt      *JWTVerifier
+	apiKeys  *APIKeyStore
+	disabled bool
+}
+
+// New loads the authenticator described by cfg. With cfg.Disabled every request is let through
+// anonymously, for local development only.
+func New(cfg config.Auth) (*Authenticator, error) {
+	if cfg.Disabled {
+		slog.Warn("Authentication is disabled, all requests are anonymous")
+		return &Authenticator{disabled: true}, nil
+	}
+
+	verifier, err := LoadJWTVerifier(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys, err := LoadAPIKeys(cfg.APIKeysFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if verifier == nil && apiKeys == nil {
+		slog.Warn("No JWT keys or API keys configured, all authenticated routes will return 401")
+	}
+
+	return &Authenticator{jwt: verifier, apiKeys: apiKeys}, nil
+}
+
+// Disabled reports whether authentication is turned off
+func (a *Authenticator) Disabled() bool {
+	return a.disabled
+}
+
+// Authenticate identifies the caller from the Authorization and X-API-Key header values.
+// Accepted forms are "Bearer <jwt>", "ApiKey <key>" and a bare key in X-API-Key.
+func (a *Authenticator) Authenticate(authorization, apiKey string) (*Principal, error) {
+	scheme, credentials, _ := strings.Cut(strings.TrimSpace(authorization), " ")
+	credentials = strings.TrimSpace(credentials)
+
+	switch {
+	case strings.EqualFold(scheme, "Bearer") && credentials != "":
+		if a.jwt == nil {
+			return nil, ErrInvalidCredentials
+		}
+		p, err := a.jwt.Verify(credentials)
+		if err != nil {
+			return nil, ErrInvalidCredentials
+		}
+		return p, nil
+	case strings.EqualFold(scheme, "ApiKey") && credentials != "":
+		apiKey = credentials
+	case authorization != "":
+		return nil, ErrInvalidCredentials
+	}
+
+	if apiKey == "" {
+		return nil, ErrMissingCredentials
+	}
+	if a.apiKeys == nil {
+		return nil, ErrInvalidCredentials
+	}
+	p, err := a.apiKeys.Verify(apiKey)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return p, nil
+}
@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"aadhaar-user-service/internals/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const hs256Secret = "0123456789abcdef0123456789abcdef"
+
+// writeFile writes content to a file in a temporary directory and returns its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// inAnHour is the expiry of valid test tokens
+func inAnHour() int64 {
+	return time.Now().Add(time.Hour).Unix()
+}
+
+// sign returns a token for claims signed with key, with kid in its header when given
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+func newAuthenticator(t *testing.T, cfg config.Auth) *Authenticator {
+	t.Helper()
+
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("authenticator: %v", err)
+	}
+	return a
+}
+
+func TestAPIKeys(t *testing.T) {
+	keys, err := json.Marshal([]APIKey{
+		{ID: "operator", Hash: HashAPIKey("operator-key"), Roles: []string{"operator"}},
+		{ID: "admin", Hash: "sha256:" + HashAPIKey("admin-key"), Roles: []string{"admin"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := newAuthenticator(t, config.Auth{APIKeysFile: writeFile(t, "api-keys.json", string(keys))})
+
+	tests := []struct {
+		name          string
+		authorization string
+		apiKey        string
+		subject       string
+		want          error
+	}{
+		{"X-API-Key header", "", "operator-key", "apikey:operator", nil},
+		{"ApiKey scheme", "ApiKey admin-key", "", "apikey:admin", nil},
+		{"scheme is case-insensitive", "apikey admin-key", "", "apikey:admin", nil},
+		{"unknown key", "", "wrong-key", "", ErrInvalidCredentials},
+		{"no credentials", "", "", "", ErrMissingCredentials},
+		{"unknown scheme", "Basic b3BlcmF0b3I6a2V5", "", "", ErrInvalidCredentials},
+		{"bearer without JWT keys", "Bearer token", "", "", ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := a.Authenticate(tt.authorization, tt.apiKey)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("error %v, want %v", err, tt.want)
+			}
+			if err == nil && p.Subject != tt.subject {
+				t.Errorf("subject %q, want %q", p.Subject, tt.subject)
+			}
+		})
+	}
+}
+
+func TestLoadAPIKeysInvalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"not JSON":       "[",
+		"no id":          `[{"hash": "` + HashAPIKey("key") + `"}]`,
+		"hash not hex":   `[{"id": "a", "hash": "not hex"}]`,
+		"hash too short": `[{"id": "a", "hash": "abcd"}]`,
+	} {
+		if _, err := LoadAPIKeys(writeFile(t, "api-keys.json", content)); err == nil {
+			t.Errorf("%s: loaded, want an error", name)
+		}
+	}
+	if store, err := LoadAPIKeys(""); store != nil || err != nil {
+		t.Errorf("no file = %v, %v, want nil", store, err)
+	}
+}
+
+func TestHS256(t *testing.T) {
+	a := newAuthenticator(t, config.Auth{
+		JWTHS256SecretFile: writeFile(t, "secret", hs256Secret+"\n"),
+		JWTIssuer:          "https://idp.example.com",
+		JWTAudience:        "aadhaar-user-service",
+	})
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{"sub": "asha", "roles": []string{"operator", "auditor"}, "iss": "https://idp.example.com", "aud": "aadhaar-user-service", "exp": inAnHour()}
+	}
+
+	p, err := a.Authenticate("Bearer "+sign(t, jwt.SigningMethodHS256, []byte(hs256Secret), "", valid()), "")
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if p.Subject != "asha" || !slices.Equal(p.Roles, []string{"operator", "auditor"}) || !p.HasRole("auditor") {
+		t.Errorf("principal %+v, want asha with the roles of the token", p)
+	}
+
+	single := valid()
+	delete(single, "roles")
+	single["role"] = "admin"
+	if p, err := a.Authenticate("Bearer "+sign(t, jwt.SigningMethodHS256, []byte(hs256Secret), "", single), ""); err != nil || !slices.Equal(p.Roles, []string{"admin"}) {
+		t.Errorf("single role claim = %+v, %v, want role admin", p, err)
+	}
+
+	refused := map[string]func(c jwt.MapClaims){
+		"expired":        func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() },
+		"no expiry":      func(c jwt.MapClaims) { delete(c, "exp") },
+		"no subject":     func(c jwt.MapClaims) { delete(c, "sub") },
+		"other issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"other audience": func(c jwt.MapClaims) { c["aud"] = "another-service" },
+	}
+	for name, change := range refused {
+		claims := valid()
+		change(claims)
+		if _, err := a.Authenticate("Bearer "+sign(t, jwt.SigningMethodHS256, []byte(hs256Secret), "", claims), ""); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s: error %v, want %v", name, err, ErrInvalidCredentials)
+		}
+	}
+
+	forged := sign(t, jwt.SigningMethodHS256, []byte(strings.Repeat("x", 32)), "", valid())
+	if _, err := a.Authenticate("Bearer "+forged, ""); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("other secret: error %v, want %v", err, ErrInvalidCredentials)
+	}
+
+	unsigned := sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", valid())
+	if _, err := a.Authenticate("Bearer "+unsigned, ""); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("unsigned token: error %v, want %v", err, ErrInvalidCredentials)
+	}
+}
+
+func TestHS256SecretTooShort(t *testing.T) {
+	if _, err := New(config.Auth{JWTHS256SecretFile: writeFile(t, "secret", "short")}); err == nil {
+		t.Error("loaded a short HS256 secret, want an error")
+	}
+}
+
+func TestRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	jwks, err := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kid": "2024",
+		"kty": "RSA",
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := func() jwt.MapClaims { return jwt.MapClaims{"sub": "ravi", "role": "supervisor", "exp": inAnHour()} }
+
+	pemOnly := newAuthenticator(t, config.Auth{JWTRS256PublicKeyFile: writeFile(t, "public.pem", publicKey)})
+	if p, err := pemOnly.Authenticate("Bearer "+sign(t, jwt.SigningMethodRS256, key, "", claims()), ""); err != nil || p.Subject != "ravi" {
+		t.Errorf("public key = %+v, %v, want ravi", p, err)
+	}
+	if _, err := pemOnly.Authenticate("Bearer "+sign(t, jwt.SigningMethodRS256, other, "", claims()), ""); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("other key: error %v, want %v", err, ErrInvalidCredentials)
+	}
+
+	// An RS256-only verifier must not accept HS256 tokens keyed with its public key
+	confused := sign(t, jwt.SigningMethodHS256, []byte(publicKey), "", claims())
+	if _, err := pemOnly.Authenticate("Bearer "+confused, ""); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("HS256 with the public key: error %v, want %v", err, ErrInvalidCredentials)
+	}
+
+	withJWKS := newAuthenticator(t, config.Auth{JWTJWKSFile: writeFile(t, "jwks.json", string(jwks))})
+	if p, err := withJWKS.Authenticate("Bearer "+sign(t, jwt.SigningMethodRS256, key, "2024", claims()), ""); err != nil || p.Subject != "ravi" {
+		t.Errorf("JWKS key = %+v, %v, want ravi", p, err)
+	}
+	if _, err := withJWKS.Authenticate("Bearer "+sign(t, jwt.SigningMethodRS256, key, "2023", claims()), ""); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("unknown kid: error %v, want %v", err, ErrInvalidCredentials)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	a := newAuthenticator(t, config.Auth{Disabled: true})
+	if !a.Disabled() {
+		t.Error("authentication enabled, want it disabled")
+	}
+	if newAuthenticator(t, config.Auth{}).Disabled() {
+		t.Error("authentication disabled without being configured so")
+	}
+}
@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"aadhaar-user-service/internals/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTVerifier validates HS256 and RS256 bearer tokens
+type JWTVerifier struct {
+	hmacSecret []byte
+	rsaKey     *rsa.PublicKey
+	jwks       map[string]*rsa.PublicKey
+	issuer     string
+	audience   string
+}
+
+// claims are the JWT claims read into a Principal
+type claims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles"`
+	Role  string   `json:"role"`
+}
+
+// jwkSet is the JSON Web Key Set file format (RFC 7517), only RSA keys are used
+type jwkSet struct {
+	Keys []struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// LoadJWTVerifier reads the signing keys from the HS256 secret, RS256 public key and JWKS files
+// named in cfg. It returns nil when none is set.
+func LoadJWTVerifier(cfg config.Auth) (*JWTVerifier, error) {
+	v := &JWTVerifier{
+		issuer:   cfg.JWTIssuer,
+		audience: cfg.JWTAudience,
+	}
+	configured := false
+
+	if path := cfg.JWTHS256SecretFile; path != "" {
+		secret, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read HS256 secret: %w", err)
+		}
+		v.hmacSecret = []byte(strings.TrimSpace(string(secret)))
+		if len(v.hmacSecret) < 32 {
+			return nil, errors.New("HS256 secret must be at least 32 bytes")
+		}
+		configured = true
+	}
+
+	if path := cfg.JWTRS256PublicKeyFile; path != "" {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read RS256 public key: %w", err)
+		}
+		if v.rsaKey, err = jwt.ParseRSAPublicKeyFromPEM(pem); err != nil {
+			return nil, fmt.Errorf("parse RS256 public key: %w", err)
+		}
+		configured = true
+	}
+
+	if path := cfg.JWTJWKSFile; path != "" {
+		keys, err := loadJWKS(path)
+		if err != nil {
+			return nil, err
+		}
+		v.jwks = keys
+		configured = true
+	}
+
+	if !configured {
+		return nil, nil
+	}
+	return v, nil
+}
+
+// Verify parses and validates a token, returning the caller it identifies
+func (v *JWTVerifier) Verify(token string) (*Principal, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"HS256", "RS256"}),
+		jwt.WithExpirationRequired(),
+	}
+	if v.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(v.issuer))
+	}
+	if v.audience != "" {
+		opts = append(opts, jwt.WithAudience(v.audience))
+	}
+
+	c := &claims{}
+	if _, err := jwt.ParseWithClaims(token, c, v.key, opts...); err != nil {
+		return nil, err
+	}
+	if c.Subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+
+	roles := c.Roles
+	if len(roles) == 0 && c.Role != "" {
+		roles = []string{c.Role}
+	}
+	return &Principal{Subject: c.Subject, Roles: roles}, nil
+}
+
+// key selects the verification key for a token by its algorithm and kid
+func (v *JWTVerifier) key(t *jwt.Token) (interface{}, error) {
+	switch t.Method.Alg() {
+	case "HS256":
+		if v.hmacSecret == nil {
+			return nil, errors.New("HS256 tokens are not accepted")
+		}
+		return v.hmacSecret, nil
+	case "RS256":
+		if kid, ok := t.Header["kid"].(string); ok && v.jwks != nil {
+			if key, ok := v.jwks[kid]; ok {
+				return key, nil
+			}
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		if v.rsaKey == nil {
+			return nil, errors.New("RS256 tokens are not accepted")
+		}
+		return v.rsaKey, nil
+	default:
+		return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+	}
+}
+
+// loadJWKS reads RSA signing keys from a JWKS file
+func loadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read JWKS: %w", err)
+	}
+
+	var set jwkSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %q: invalid modulus", k.Kid)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %q: invalid exponent", k.Kid)
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS contains no RSA signing keys")
+	}
+	return keys, nil
+}
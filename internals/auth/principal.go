@@ -0,0 +1,40 @@
+package auth
+
+import "context"
+
+// Principal represents an authenticated caller
+type Principal struct {
+	Subject string   `json:"subject"`
+	Roles   []string `json:"roles"`
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying the caller
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the caller stored in the context, if any
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// RolesFromContext returns the roles of the caller, nil for anonymous callers
+func RolesFromContext(ctx context.Context) []string {
+	if p, ok := FromContext(ctx); ok {
+		return p.Roles
+	}
+	return nil
+}
+
+// HasRole reports whether the caller has the role
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,251 @@
+// Package config loads the service configuration. Values come from, in increasing order of
+// precedence: built-in defaults, the YAML file named by CONFIG_FILE, the .env file and the
+// process environment.
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every runtime setting of the service
+type Config struct {
+	Server     Server     `yaml:"server"`
+	Database   Database   `yaml:"database"`
+	CORS       CORS       `yaml:"cors"`
+	Log        Log        `yaml:"log"`
+	Health     Health     `yaml:"health"`
+	Metrics    Metrics    `yaml:"metrics"`
+	Tracing    Tracing    `yaml:"tracing"`
+	Pagination Pagination `yaml:"pagination"`
+	Dedup      Dedup      `yaml:"dedup"`
+	Encryption Encryption `yaml:"encryption"`
+	Auth       Auth       `yaml:"auth"`
+	RBAC       RBAC       `yaml:"rbac"`
+	Masking    Masking    `yaml:"masking"`
+}
+
+// Server configures the HTTP listener
+type Server struct {
+	Host            string        `yaml:"host"`
+	Port            int           `yaml:"port"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	BodyLimit       int           `yaml:"body_limit"`
+}
+
+// Storage drivers
+const (
+	DriverPostgres = "postgres"
+	DriverMemory   = "memory"
+)
+
+// Database configures the Postgres connection and pool. The memory driver keeps all data in
+// process memory instead, for tests and local runs.
+type Database struct {
+	Driver          string        `yaml:"driver"`
+	Host            string        `yaml:"host"`
+	Port            int           `yaml:"port"`
+	User            string        `yaml:"user"`
+	Password        string        `yaml:"password"`
+	Name            string        `yaml:"name"`
+	SSLMode         string        `yaml:"sslmode"`
+	ConnectTimeout  time.Duration `yaml:"connect_timeout"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+}
+
+// CORS configures cross-origin requests
+type CORS struct {
+	AllowOrigins     []string `yaml:"allow_origins"`
+	AllowMethods     []string `yaml:"allow_methods"`
+	AllowHeaders     []string `yaml:"allow_headers"`
+	AllowCredentials bool     `yaml:"allow_credentials"`
+	MaxAge           int      `yaml:"max_age"`
+}
+
+// Health configures the readiness probe
+type Health struct {
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Metrics configures the Prometheus endpoint
+type Metrics struct {
+	Enabled bool   `yaml:"enabled"`
+	Path    string `yaml:"path"`
+}
+
+// Tracing configures OpenTelemetry trace export
+type Tracing struct {
+	Exporter    string  `yaml:"exporter"`
+	Endpoint    string  `yaml:"endpoint"`
+	Insecure    bool    `yaml:"insecure"`
+	File        string  `yaml:"file"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// Pagination configures list cursors
+type Pagination struct {
+	// CursorSecret seals cursors so they survive restarts and work across replicas.
+	// Without it every instance seals with a random key of its own.
+	CursorSecret string `yaml:"cursor_secret"`
+}
+
+// Dedup configures duplicate-applicant detection on enrolment
+type Dedup struct {
+	// Mode is strict to reject likely duplicates with 409, soft to enrol them flagged, or off
+	Mode string `yaml:"mode"`
+
+	// Threshold is the score, from 0 to 1, from which an existing user is a likely duplicate
+	Threshold float64 `yaml:"threshold"`
+}
+
+// Encryption configures envelope encryption of PII. Without master keys PII is stored in plaintext.
+type Encryption struct {
+	// KeyringFile names a JSON keyring, used instead of the keys below when set
+	KeyringFile string `yaml:"keyring_file"`
+
+	// MasterKeys lists base64-encoded 32-byte keys as "id:base64,id:base64"
+	MasterKeys string `yaml:"master_keys"`
+
+	// ActiveKeyID wraps new data keys, the last listed master key when empty
+	ActiveKeyID string `yaml:"active_key_id"`
+
+	// BlindIndexKey keys the blind indexes, required with master keys
+	BlindIndexKey string `yaml:"blind_index_key"`
+}
+
+// Auth configures how callers are identified. Signing keys and API keys are read from files.
+type Auth struct {
+	// Disabled lets every request through anonymously, for local development only
+	Disabled bool `yaml:"disabled"`
+
+	JWTIssuer             string `yaml:"jwt_issuer"`
+	JWTAudience           string `yaml:"jwt_audience"`
+	JWTHS256SecretFile    string `yaml:"jwt_hs256_secret_file"`
+	JWTRS256PublicKeyFile string `yaml:"jwt_rs256_public_key_file"`
+	JWTJWKSFile           string `yaml:"jwt_jwks_file"`
+	APIKeysFile           string `yaml:"api_keys_file"`
+}
+
+// RBAC configures the role definitions
+type RBAC struct {
+	// PolicyFile names a YAML or JSON policy replacing the built-in roles
+	PolicyFile string `yaml:"policy_file"`
+}
+
+// Masking configures how much PII each role sees
+type Masking struct {
+	// PolicyFile names a JSON policy replacing the built-in rules
+	PolicyFile string `yaml:"policy_file"`
+}
+
+// Log configures logging
+type Log struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+// Default returns the configuration used when nothing is overridden
+func Default() Config {
+	return Config{
+		Server: Server{
+			Port:            3015,
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    15 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+			BodyLimit:       16 * 1024 * 1024, // 16MB
+		},
+		Database: Database{
+			Driver:          DriverPostgres,
+			Host:            "localhost",
+			Port:            5432,
+			User:            "postgres",
+			Name:            "aadhaar_db",
+			SSLMode:         "disable",
+			ConnectTimeout:  10 * time.Second,
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		CORS: CORS{
+			AllowOrigins: []string{"*"},
+			AllowMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+			AllowHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-Key", "X-Request-ID"},
+		},
+		Log: Log{
+			Level:  "info",
+			Format: "json",
+		},
+		Health: Health{
+			Timeout: 2 * time.Second,
+		},
+		Metrics: Metrics{
+			Enabled: true,
+			Path:    "/metrics",
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			SampleRatio: 1,
+		},
+		Dedup: Dedup{
+			Mode:      "strict",
+			Threshold: 0.85,
+		},
+	}
+}
+
+// Load builds the configuration from defaults, CONFIG_FILE, .env and the environment, and validates it
+func Load() (Config, error) {
+	if err := godotenv.Load(); err != nil {
+		slog.Info("No .env file found, using system environment variables")
+	}
+
+	cfg := Default()
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return cfg, fmt.Errorf("read config file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return cfg, fmt.Errorf("parse config file %s: %w", path, err)
+		}
+	}
+
+	errs := cfg.applyEnv()
+	if err := invalid(append(errs, cfg.validate()...)); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// dsnEscaper escapes a quoted libpq connection string value
+var dsnEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// Addr returns the address the HTTP server listens on
+func (s Server) Addr() string {
+	return fmt.Sprintf("%s:%d", s.Host, s.Port)
+}
+
+// DSN returns the Postgres connection string
+func (d Database) DSN() string {
+	dsn := fmt.Sprintf("host=%s port=%d user=%s dbname=%s sslmode=%s connect_timeout=%d",
+		d.Host, d.Port, d.User, d.Name, d.SSLMode, int(d.ConnectTimeout.Seconds()))
+	if d.Password != "" {
+		dsn += fmt.Sprintf(" password='%s'", dsnEscaper.Replace(d.Password))
+	}
+	return dsn
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// applyEnv overrides settings with the environment variables that are set and returns parse errors
+func (c *Config) applyEnv() []error {
+	e := &envReader{}
+
+	e.string(&c.Server.Host, "SERVER_HOST")
+	e.int(&c.Server.Port, "SERVER_PORT")
+	e.duration(&c.Server.ReadTimeout, "SERVER_READ_TIMEOUT")
+	e.duration(&c.Server.WriteTimeout, "SERVER_WRITE_TIMEOUT")
+	e.duration(&c.Server.IdleTimeout, "SERVER_IDLE_TIMEOUT")
+	e.duration(&c.Server.ShutdownTimeout, "SERVER_SHUTDOWN_TIMEOUT")
+	e.int(&c.Server.BodyLimit, "SERVER_BODY_LIMIT")
+
+	e.string(&c.Database.Driver, "DB_DRIVER")
+	e.string(&c.Database.Host, "DB_HOST")
+	e.int(&c.Database.Port, "DB_PORT")
+	e.string(&c.Database.User, "DB_USER")
+	e.string(&c.Database.Password, "DB_PASSWORD")
+	e.string(&c.Database.Name, "DB_NAME")
+	e.string(&c.Database.SSLMode, "DB_SSLMODE")
+	e.duration(&c.Database.ConnectTimeout, "DB_CONNECT_TIMEOUT")
+	e.int(&c.Database.MaxOpenConns, "DB_MAX_OPEN_CONNS")
+	e.int(&c.Database.MaxIdleConns, "DB_MAX_IDLE_CONNS")
+	e.duration(&c.Database.ConnMaxLifetime, "DB_CONN_MAX_LIFETIME")
+	e.duration(&c.Database.ConnMaxIdleTime, "DB_CONN_MAX_IDLE_TIME")
+
+	e.list(&c.CORS.AllowOrigins, "CORS_ALLOW_ORIGINS")
+	e.list(&c.CORS.AllowMethods, "CORS_ALLOW_METHODS")
+	e.list(&c.CORS.AllowHeaders, "CORS_ALLOW_HEADERS")
+	e.bool(&c.CORS.AllowCredentials, "CORS_ALLOW_CREDENTIALS")
+	e.int(&c.CORS.MaxAge, "CORS_MAX_AGE")
+
+	e.string(&c.Log.Level, "LOG_LEVEL")
+	e.string(&c.Log.Format, "LOG_FORMAT")
+
+	e.duration(&c.Health.Timeout, "HEALTH_TIMEOUT")
+
+	e.bool(&c.Metrics.Enabled, "METRICS_ENABLED")
+	e.string(&c.Metrics.Path, "METRICS_PATH")
+
+	e.string(&c.Tracing.Exporter, "TRACING_EXPORTER")
+	e.string(&c.Tracing.Endpoint, "TRACING_OTLP_ENDPOINT")
+	e.bool(&c.Tracing.Insecure, "TRACING_OTLP_INSECURE")
+	e.string(&c.Tracing.File, "TRACING_FILE")
+	e.float(&c.Tracing.SampleRatio, "TRACING_SAMPLE_RATIO")
+
+	e.string(&c.Pagination.CursorSecret, "PAGINATION_CURSOR_SECRET")
+
+	e.string(&c.Dedup.Mode, "DEDUP_MODE")
+	e.float(&c.Dedup.Threshold, "DEDUP_THRESHOLD")
+
+	e.string(&c.Encryption.KeyringFile, "PII_KEYRING_FILE")
+	e.string(&c.Encryption.MasterKeys, "PII_MASTER_KEYS")
+	e.string(&c.Encryption.ActiveKeyID, "PII_ACTIVE_KEY_ID")
+	e.string(&c.Encryption.BlindIndexKey, "PII_BLIND_INDEX_KEY")
+
+	e.bool(&c.Auth.Disabled, "AUTH_DISABLED")
+	e.string(&c.Auth.JWTIssuer, "AUTH_JWT_ISSUER")
+	e.string(&c.Auth.JWTAudience, "AUTH_JWT_AUDIENCE")
+	e.string(&c.Auth.JWTHS256SecretFile, "AUTH_JWT_HS256_SECRET_FILE")
+	e.string(&c.Auth.JWTRS256PublicKeyFile, "AUTH_JWT_RS256_PUBLIC_KEY_FILE")
+	e.string(&c.Auth.JWTJWKSFile, "AUTH_JWT_JWKS_FILE")
+	e.string(&c.Auth.APIKeysFile, "AUTH_API_KEYS_FILE")
+
+	e.string(&c.RBAC.PolicyFile, "RBAC_POLICY_FILE")
+	e.string(&c.Masking.PolicyFile, "PII_MASKING_POLICY_FILE")
+
+	return e.errs
+}
+
+// envReader parses environment variables into settings, collecting parse errors
+type envReader struct {
+	errs []error
+}
+
+func (e *envReader) lookup(key string) (string, bool) {
+	value, ok := os.LookupEnv(key)
+	if !ok || strings.TrimSpace(value) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(value), true
+}
+
+func (e *envReader) fail(key, value, want string) {
+	e.errs = append(e.errs, fmt.Errorf("%s: %q is not a valid %s", key, value, want))
+}
+
+func (e *envReader) string(dst *string, key string) {
+	if value, ok := e.lookup(key); ok {
+		*dst = value
+	}
+}
+
+func (e *envReader) int(dst *int, key string) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		e.fail(key, value, "integer")
+		return
+	}
+	*dst = n
+}
+
+func (e *envReader) bool(dst *bool, key string) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		e.fail(key, value, "boolean")
+		return
+	}
+	*dst = b
+}
+
+func (e *envReader) float(dst *float64, key string) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		e.fail(key, value, "number")
+		return
+	}
+	*dst = f
+}
+
+// duration accepts Go durations such as 30s or 5m
+func (e *envReader) duration(dst *time.Duration, key string) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		e.fail(key, value, "duration (e.g. 30s, 5m)")
+		return
+	}
+	*dst = d
+}
+
+// list accepts comma-separated values
+func (e *envReader) list(dst *[]string, key string) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*dst = items
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	logLevels = []string{"debug", "info", "warn", "error"}
+	exporters = []string{"none", "otlp", "stdout", "file"}
+	logFormat = []string{"json", "text"}
+	drivers   = []string{DriverPostgres, DriverMemory}
+	sslModes  = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	dedupMode = []string{"strict", "soft", "off"}
+)
+
+// Validate checks every setting and reports all problems at once
+func (c Config) Validate() error {
+	return invalid(c.validate())
+}
+
+// invalid combines configuration problems into one error, or nil when there are none
+func invalid(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+}
+
+func (c Config) validate() []error {
+	var errs []error
+	check := func(ok bool, field, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(c.Server.Port >= 1 && c.Server.Port <= 65535, "server.port (SERVER_PORT)", "must be between 1 and 65535, got %d", c.Server.Port)
+	check(c.Server.ReadTimeout > 0, "server.read_timeout (SERVER_READ_TIMEOUT)", "must be positive")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout (SERVER_WRITE_TIMEOUT)", "must be positive")
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout (SERVER_IDLE_TIMEOUT)", "must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout (SERVER_SHUTDOWN_TIMEOUT)", "must be positive")
+	check(c.Server.BodyLimit > 0, "server.body_limit (SERVER_BODY_LIMIT)", "must be a positive number of bytes")
+
+	check(slices.Contains(drivers, c.Database.Driver), "database.driver (DB_DRIVER)", "must be one of %s, got %q", strings.Join(drivers, ", "), c.Database.Driver)
+	check(c.Database.Host != "", "database.host (DB_HOST)", "is required")
+	check(c.Database.Port >= 1 && c.Database.Port <= 65535, "database.port (DB_PORT)", "must be between 1 and 65535, got %d", c.Database.Port)
+	check(c.Database.User != "", "database.user (DB_USER)", "is required")
+	check(c.Database.Name != "", "database.name (DB_NAME)", "is required")
+	check(slices.Contains(sslModes, c.Database.SSLMode), "database.sslmode (DB_SSLMODE)", "must be one of %s, got %q", strings.Join(sslModes, ", "), c.Database.SSLMode)
+	check(c.Database.ConnectTimeout >= 0, "database.connect_timeout (DB_CONNECT_TIMEOUT)", "must not be negative")
+	check(c.Database.MaxOpenConns >= 1, "database.max_open_conns (DB_MAX_OPEN_CONNS)", "must be at least 1")
+	check(c.Database.MaxIdleConns >= 0 && c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database.max_idle_conns (DB_MAX_IDLE_CONNS)", "must be between 0 and max_open_conns (%d), got %d", c.Database.MaxOpenConns, c.Database.MaxIdleConns)
+	check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime (DB_CONN_MAX_LIFETIME)", "must not be negative")
+	check(c.Database.ConnMaxIdleTime >= 0, "database.conn_max_idle_time (DB_CONN_MAX_IDLE_TIME)", "must not be negative")
+
+	check(len(c.CORS.AllowOrigins) > 0, "cors.allow_origins (CORS_ALLOW_ORIGINS)", "must list at least one origin")
+	check(!c.CORS.AllowCredentials || !slices.Contains(c.CORS.AllowOrigins, "*"),
+		"cors.allow_credentials (CORS_ALLOW_CREDENTIALS)", "cannot be used with the wildcard origin \"*\"")
+	check(len(c.CORS.AllowMethods) > 0, "cors.allow_methods (CORS_ALLOW_METHODS)", "must list at least one method")
+	check(c.CORS.MaxAge >= 0, "cors.max_age (CORS_MAX_AGE)", "must not be negative")
+
+	check(slices.Contains(logLevels, c.Log.Level), "log.level (LOG_LEVEL)", "must be one of %s, got %q", strings.Join(logLevels, ", "), c.Log.Level)
+	check(slices.Contains(logFormat, c.Log.Format), "log.format (LOG_FORMAT)", "must be one of %s, got %q", strings.Join(logFormat, ", "), c.Log.Format)
+
+	check(c.Health.Timeout > 0, "health.timeout (HEALTH_TIMEOUT)", "must be positive")
+	check(!c.Metrics.Enabled || (strings.HasPrefix(c.Metrics.Path, "/") && !strings.HasPrefix(c.Metrics.Path, "/aadhaar")),
+		"metrics.path (METRICS_PATH)", "must start with / and lie outside /aadhaar, got %q", c.Metrics.Path)
+	check(slices.Contains(exporters, c.Tracing.Exporter), "tracing.exporter (TRACING_EXPORTER)", "must be one of %s, got %q", strings.Join(exporters, ", "), c.Tracing.Exporter)
+	check(c.Tracing.Exporter != "file" || c.Tracing.File != "", "tracing.file (TRACING_FILE)", "is required with the file exporter")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio (TRACING_SAMPLE_RATIO)", "must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+
+	check(c.Pagination.CursorSecret == "" || len(c.Pagination.CursorSecret) >= 32,
+		"pagination.cursor_secret (PAGINATION_CURSOR_SECRET)", "must be at least 32 characters")
+
+	check(slices.Contains(dedupMode, c.Dedup.Mode), "dedup.mode (DEDUP_MODE)", "must be one of %s, got %q", strings.Join(dedupMode, ", "), c.Dedup.Mode)
+	check(c.Dedup.Threshold > 0 && c.Dedup.Threshold <= 1, "dedup.threshold (DEDUP_THRESHOLD)", "must be greater than 0 and at most 1, got %g", c.Dedup.Threshold)
+
+	return errs
+}
@@ -0,0 +1,79 @@
+// Package cursor seals list positions into opaque tokens. Tokens are encrypted with AES-GCM, so
+// clients can neither read the sort values they hold (which may be PII) nor forge them.
+package cursor
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"aadhaar-user-service/internals/dto"
+)
+
+// ErrInvalid is returned for tokens that were not sealed by the codec or were tampered with
+var ErrInvalid = errors.New("invalid cursor")
+
+// Codec seals and opens cursors. It is safe for concurrent use.
+type Codec struct {
+	aead cipher.AEAD
+}
+
+// New creates a codec keyed by secret, or by a random key when secret is empty, in which case
+// tokens only open on the instance that sealed them
+func New(secret string) (*Codec, error) {
+	key := make([]byte, 32)
+	if secret != "" {
+		sum := sha256.Sum256([]byte("cursor:" + secret))
+		key = sum[:]
+	} else if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Codec{aead: aead}, nil
+}
+
+// Encode seals c into a URL-safe token
+func (k *Codec) Encode(c dto.Cursor) (string, error) {
+	plaintext, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(k.aead.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+// Decode opens a token sealed by Encode
+func (k *Codec) Decode(token string) (dto.Cursor, error) {
+	var c dto.Cursor
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) < k.aead.NonceSize() {
+		return c, ErrInvalid
+	}
+
+	nonce, ciphertext := data[:k.aead.NonceSize()], data[k.aead.NonceSize():]
+	plaintext, err := k.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return c, ErrInvalid
+	}
+	if err := json.Unmarshal(plaintext, &c); err != nil {
+		return c, ErrInvalid
+	}
+	return c, nil
+}
@@ -0,0 +1,83 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"aadhaar-user-service/internals/dto"
+
+	"github.com/google/uuid"
+)
+
+func newCodec(t *testing.T, secret string) *Codec {
+	t.Helper()
+
+	k, err := New(secret)
+	if err != nil {
+		t.Fatalf("codec: %v", err)
+	}
+	return k
+}
+
+func TestRoundTrip(t *testing.T) {
+	k := newCodec(t, "secret")
+	want := dto.Cursor{Value: "Asha Rao", ID: uuid.New(), Backward: true, Query: "fingerprint"}
+
+	token, err := k.Encode(want)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if strings.Contains(token, "Asha") || strings.ContainsAny(token, "+/=") {
+		t.Errorf("token %q, want it opaque and URL-safe", token)
+	}
+
+	got, err := k.Decode(token)
+	if err != nil || got != want {
+		t.Errorf("decode = %+v, %v, want %+v", got, err, want)
+	}
+
+	// Instances sharing the secret open each other's cursors
+	if got, err := newCodec(t, "secret").Decode(token); err != nil || got != want {
+		t.Errorf("decode on another instance = %+v, %v, want %+v", got, err, want)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	k := newCodec(t, "secret")
+	token, err := k.Encode(dto.Cursor{Value: "2024-12-01T10:30:00Z", ID: uuid.New(), Query: "fingerprint"})
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		t.Fatalf("decode token: %v", err)
+	}
+	data[len(data)-1] ^= 1
+	tampered := base64.RawURLEncoding.EncodeToString(data)
+
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"v":"x","i":"00000000-0000-0000-0000-000000000000","q":"fingerprint"}`))
+
+	tests := []struct {
+		name  string
+		codec *Codec
+		token string
+	}{
+		{"empty", k, ""},
+		{"not base64", k, "not a cursor!"},
+		{"shorter than a nonce", k, "AAAA"},
+		{"tampered", k, tampered},
+		{"forged", k, forged},
+		{"other secret", newCodec(t, "other"), token},
+		{"random key", newCodec(t, ""), token},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.codec.Decode(tt.token); !errors.Is(err, ErrInvalid) {
+				t.Errorf("error %v, want %v", err, ErrInvalid)
+			}
+		})
+	}
+}
@@ -2,66 +2,71 @@ package database
 
 import (
 	"fmt"
-	"os"
+	"log/slog"
+
+	"aadhaar-user-service/internals/config"
+	"aadhaar-user-service/internals/logging"
+	"aadhaar-user-service/internals/metrics"
 
-	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	otelgorm "gorm.io/plugin/opentelemetry/tracing"
 )
 
-var DB *gorm.DB
-
-func Client() *gorm.DB {
-	return DB
-}
-
-func Connect() {
-
-	if err := godotenv.Load(); err != nil {
-		fmt.Println("No .env file found, using system environment variables")
+// Connect opens the Postgres connection pool described by cfg and checks that it is reachable.
+// Queries are recorded into m unless it is nil.
+func Connect(cfg config.Config, m *metrics.Metrics) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(cfg.Database.DSN()), &gorm.Config{
+		Logger: logging.NewGormLogger(cfg.Log.Level),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
 	}
 
-	host := getEnv("DB_HOST", "localhost")
-	port := getEnv("DB_PORT", "5432")
-	user := getEnv("DB_USER", "postgres")
-	password := getEnv("DB_PASSWORD", "")
-	dbname := getEnv("DB_NAME", "aadhaar_db")
-	sslmode := getEnv("DB_SSLMODE", "disable")
-
-	var dsn string
-	if password != "" {
-		dsn = fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-			host, port, user, password, dbname, sslmode)
-	} else {
-		dsn = fmt.Sprintf("host=%s port=%s user=%s dbname=%s sslmode=%s",
-			host, port, user, dbname, sslmode)
+	if m != nil {
+		if err := db.Use(m.GormPlugin()); err != nil {
+			return nil, fmt.Errorf("register metrics plugin: %w", err)
+		}
 	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if err != nil {
-		fmt.Printf("Unable to open database, err: %v\n", err)
-		panic(err)
+	// SQL spans carry the statement without its values, which may be PII
+	if err := db.Use(otelgorm.NewPlugin(
+		otelgorm.WithDBSystem("postgresql"),
+		otelgorm.WithoutQueryVariables(),
+		otelgorm.WithoutMetrics(),
+	)); err != nil {
+		return nil, fmt.Errorf("register tracing plugin: %w", err)
 	}
 
 	sql, err := db.DB()
 	if err != nil {
-		fmt.Printf("Unable to get sql database from gorm, err: %v\n", err)
-		panic(err)
+		return nil, fmt.Errorf("get sql database from gorm: %w", err)
 	}
 
+	sql.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	sql.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	sql.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
+	sql.SetConnMaxIdleTime(cfg.Database.ConnMaxIdleTime)
+
 	if err := sql.Ping(); err != nil {
-		fmt.Printf("Unable to connect to database, err: %v\n", err)
-		panic(err)
+		sql.Close()
+		return nil, fmt.Errorf("connect to database: %w", err)
 	}
 
-	fmt.Println("Successfully connected to the postgres db")
+	slog.Info("Successfully connected to the postgres db")
 
-	DB = db
+	return db, nil
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Close closes the connection pool, waiting for queries in progress to finish
+func Close(db *gorm.DB) error {
+	if db == nil {
+		return nil
+	}
+
+	sql, err := db.DB()
+	if err != nil {
+		return err
 	}
-	return defaultValue
+	return sql.Close()
 }
@@ -0,0 +1,260 @@
+// Package dedup scores how likely two applicants are the same person. Names are compared word by
+// word after transliteration, also by their phonetic keys, so spellings and scripts of a name
+// agree; dates of birth tolerate swapped day and month, and phone numbers a mistyped digit.
+package dedup
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"aadhaar-user-service/internals/names"
+)
+
+// Modes of duplicate detection on enrolment
+const (
+	// Strict rejects enrolments that likely duplicate a live user
+	Strict = "strict"
+	// Soft enrols them, flagging the likely duplicates
+	Soft = "soft"
+	// Off skips duplicate detection
+	Off = "off"
+)
+
+// Policy configures duplicate detection on enrolment
+type Policy struct {
+	Mode string
+
+	// Threshold is the score from which a user is a likely duplicate
+	Threshold float64
+}
+
+// Weights of the fields in the total score; they add up to 1
+const (
+	weightName        = 0.40
+	weightDateOfBirth = 0.25
+	weightPhone       = 0.15
+	weightAddress     = 0.10
+	weightGender      = 0.10
+)
+
+// Applicant is what an applicant is compared by
+type Applicant struct {
+	Name        string
+	DateOfBirth time.Time
+	Gender      string
+	Phone       string
+	Address     string
+}
+
+// Scores are the similarities of two applicants per field, from 0 to 1
+type Scores struct {
+	Name        float64
+	DateOfBirth float64
+	Gender      float64
+	Phone       float64
+	Address     float64
+}
+
+// Total returns the weighted score of all fields, from 0 to 1
+func (s Scores) Total() float64 {
+	return round(weightName*s.Name + weightDateOfBirth*s.DateOfBirth + weightGender*s.Gender +
+		weightPhone*s.Phone + weightAddress*s.Address)
+}
+
+// Compare scores each field of two applicants
+func Compare(a, b Applicant) Scores {
+	return Scores{
+		Name:        round(nameScore(a.Name, b.Name)),
+		DateOfBirth: dateScore(a.DateOfBirth, b.DateOfBirth),
+		Gender:      genderScore(a.Gender, b.Gender),
+		Phone:       phoneScore(a.Phone, b.Phone),
+		Address:     round(addressScore(a.Address, b.Address)),
+	}
+}
+
+// nameScore averages, over the words of both names, how well each matches its closest word in
+// the other name, so a missing middle name costs less than a different first name
+func nameScore(a, b string) float64 {
+	wa, wb := names.Words(a), names.Words(b)
+	if len(wa) == 0 || len(wb) == 0 {
+		return 0
+	}
+	return (closest(wa, wb) + closest(wb, wa)) / 2
+}
+
+// closest returns the mean score of the words of a against their best match in b
+func closest(a, b []string) float64 {
+	sum := 0.0
+	for _, x := range a {
+		best := 0.0
+		for _, y := range b {
+			best = max(best, wordScore(x, y))
+		}
+		sum += best
+	}
+	return sum / float64(len(a))
+}
+
+// wordScore compares two words of a name: the same word, the same sound, an initial, or a
+// spelling close enough to be a typo
+func wordScore(x, y string) float64 {
+	switch {
+	case x == y:
+		return 1
+	case names.PhoneticKey(x) != "" && names.PhoneticKey(x) == names.PhoneticKey(y):
+		return 0.9
+	case initial(x, y) || initial(y, x):
+		return 0.8
+	}
+	if jw := jaroWinkler(x, y); jw >= 0.85 {
+		return jw
+	}
+	return 0
+}
+
+// initial reports whether x is a single letter that starts y
+func initial(x, y string) bool {
+	return utf8.RuneCountInString(x) == 1 && strings.HasPrefix(y, x)
+}
+
+// dateScore matches equal dates, dates with day and month swapped, and the same birthday a year
+// apart
+func dateScore(a, b time.Time) float64 {
+	if a.IsZero() || b.IsZero() {
+		return 0
+	}
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	switch {
+	case ay == by && am == bm && ad == bd:
+		return 1
+	case ay == by && int(am) == bd && ad == int(bm):
+		return 0.8
+	case am == bm && ad == bd && (ay-by == 1 || by-ay == 1):
+		return 0.5
+	}
+	return 0
+}
+
+// genderScore matches equal genders
+func genderScore(a, b string) float64 {
+	if a != "" && strings.EqualFold(a, b) {
+		return 1
+	}
+	return 0
+}
+
+// phoneScore matches the last ten digits of two numbers, so +91 and trunk prefixes do not
+// matter, allowing one mistyped digit
+func phoneScore(a, b string) float64 {
+	da, db := subscriber(a), subscriber(b)
+	if da == "" || len(da) != len(db) {
+		return 0
+	}
+	diff := 0
+	for i := range da {
+		if da[i] != db[i] {
+			diff++
+		}
+	}
+	switch diff {
+	case 0:
+		return 1
+	case 1:
+		return 0.6
+	}
+	return 0
+}
+
+// subscriber returns the last ten digits of a phone number
+func subscriber(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+	if len(digits) > 10 {
+		digits = digits[len(digits)-10:]
+	}
+	return digits
+}
+
+// addressScore is the share of distinct words two addresses have in common
+func addressScore(a, b string) float64 {
+	wa, wb := set(names.Words(a)), set(names.Words(b))
+	if len(wa) == 0 || len(wb) == 0 {
+		return 0
+	}
+	shared := 0
+	for w := range wa {
+		if _, ok := wb[w]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wa)+len(wb)-shared)
+}
+
+// set returns the distinct words
+func set(words []string) map[string]struct{} {
+	s := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		s[w] = struct{}{}
+	}
+	return s
+}
+
+// jaroWinkler returns the Jaro-Winkler similarity of two words, which favours a shared prefix
+func jaroWinkler(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+
+	window := max(max(len(ra), len(rb))/2-1, 0)
+	matchedA, matchedB := make([]bool, len(ra)), make([]bool, len(rb))
+	matches := 0
+	for i := range ra {
+		for j := max(0, i-window); j < min(len(rb), i+window+1); j++ {
+			if !matchedB[j] && ra[i] == rb[j] {
+				matchedA[i], matchedB[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	// Matched letters out of order; each transposition counts twice
+	transpositions, j := 0, 0
+	for i := range ra {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if ra[i] != rb[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(ra)) + m/float64(len(rb)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(ra), len(rb)) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// round rounds a score to three decimals
+func round(score float64) float64 {
+	return math.Round(score*1000) / 1000
+}
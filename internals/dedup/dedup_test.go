@@ -0,0 +1,168 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestCompare(t *testing.T) {
+	asha := Applicant{
+		Name:        "Asha Rao",
+		DateOfBirth: date("1990-01-02"),
+		Gender:      "female",
+		Phone:       "9800000001",
+		Address:     "12 MG Road, Bengaluru",
+	}
+
+	tests := []struct {
+		name      string
+		a, b      Applicant
+		duplicate bool
+	}{
+		{"same applicant", asha, asha, true},
+		{
+			"transliterated names sharing no phonetic key",
+			Applicant{Name: "Xavier Dsouza", DateOfBirth: date("1985-06-15"), Gender: "male", Phone: "9811111111", Address: "4 Hill Road, Mumbai"},
+			Applicant{Name: "Zavier D'Souza", DateOfBirth: date("1985-06-15"), Gender: "male", Phone: "+91 98111 11111", Address: "4 Hill Road, Mumbai"},
+			true,
+		},
+		{
+			"spelling variants",
+			Applicant{Name: "Kavita Sharma", DateOfBirth: date("1992-03-04"), Gender: "female", Phone: "9822222222", Address: "7 Park Street, Kolkata"},
+			Applicant{Name: "Kavitha Sarma", DateOfBirth: date("1992-03-04"), Gender: "female", Phone: "9822222222", Address: "7 Park Street, Kolkata"},
+			true,
+		},
+		{
+			"same name, different person",
+			asha,
+			Applicant{Name: "Asha Rao", DateOfBirth: date("1971-11-20"), Gender: "female", Phone: "9833333333", Address: "3 Lake View, Chennai"},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scores := Compare(tt.a, tt.b)
+			if got := scores.Total() >= 0.85; got != tt.duplicate {
+				t.Errorf("Compare(%q, %q) = %+v, total %v; duplicate %v, want %v",
+					tt.a.Name, tt.b.Name, scores, scores.Total(), got, tt.duplicate)
+			}
+			if reversed := Compare(tt.b, tt.a); reversed != scores {
+				t.Errorf("Compare is not symmetric: %+v and %+v", scores, reversed)
+			}
+		})
+	}
+}
+
+func TestTotal(t *testing.T) {
+	if got := (Scores{Name: 1, DateOfBirth: 1, Gender: 1, Phone: 1, Address: 1}).Total(); got != 1 {
+		t.Errorf("all fields matching: total %v, want 1", got)
+	}
+	if got := (Scores{Name: 1, DateOfBirth: 1}).Total(); got != 0.65 {
+		t.Errorf("name and date of birth matching: total %v, want 0.65", got)
+	}
+}
+
+func TestNameScore(t *testing.T) {
+	tests := []struct {
+		a, b string
+		min  float64
+		max  float64
+	}{
+		{"Asha Rao", "Asha Rao", 1, 1},
+		{"Asha Rao", "rao asha", 1, 1},
+		{"Kavita Sharma", "Kavitha Sarma", 0.9, 1},
+		{"A Rao", "Asha Rao", 0.8, 0.95},
+		{"Asha Rao", "Ravi Kumar", 0, 0.3},
+		{"", "Asha Rao", 0, 0},
+	}
+	for _, tt := range tests {
+		if got := nameScore(tt.a, tt.b); got < tt.min || got > tt.max {
+			t.Errorf("nameScore(%q, %q) = %v, want from %v to %v", tt.a, tt.b, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestDateScore(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"1990-01-02", "1990-01-02", 1},
+		{"1990-01-02", "1990-02-01", 0.8},
+		{"1990-01-02", "1991-01-02", 0.5},
+		{"1990-01-02", "1989-01-02", 0.5},
+		{"1990-01-02", "1992-01-02", 0},
+		{"1990-01-02", "1990-01-03", 0},
+	}
+	for _, tt := range tests {
+		if got := dateScore(date(tt.a), date(tt.b)); got != tt.want {
+			t.Errorf("dateScore(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+	if got := dateScore(time.Time{}, time.Time{}); got != 0 {
+		t.Errorf("dateScore of unknown dates = %v, want 0", got)
+	}
+}
+
+func TestPhoneScore(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"9800000001", "9800000001", 1},
+		{"9800000001", "+91 98000 00001", 1},
+		{"9800000001", "09800000001", 1},
+		{"9800000001", "9800000007", 0.6},
+		{"9800000001", "9800000077", 0},
+		{"9800000001", "98000001", 0},
+		{"", "", 0},
+	}
+	for _, tt := range tests {
+		if got := phoneScore(tt.a, tt.b); got != tt.want {
+			t.Errorf("phoneScore(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestGenderScore(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"female", "female", 1},
+		{"Female", "female", 1},
+		{"female", "male", 0},
+		{"", "", 0},
+	}
+	for _, tt := range tests {
+		if got := genderScore(tt.a, tt.b); got != tt.want {
+			t.Errorf("genderScore(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestAddressScore(t *testing.T) {
+	if got := addressScore("12 MG Road, Bengaluru", "12 mg road bengaluru"); got != 1 {
+		t.Errorf("same address: score %v, want 1", got)
+	}
+	if got := addressScore("12 MG Road, Bengaluru", "3 Lake View, Chennai"); got != 0 {
+		t.Errorf("different addresses: score %v, want 0", got)
+	}
+}
+
+func TestJaroWinkler(t *testing.T) {
+	if got := round(jaroWinkler("martha", "marhta")); got != 0.961 {
+		t.Errorf("jaroWinkler(martha, marhta) = %v, want 0.961", got)
+	}
+	if got := jaroWinkler("abc", "xyz"); got != 0 {
+		t.Errorf("jaroWinkler(abc, xyz) = %v, want 0", got)
+	}
+}
@@ -0,0 +1,25 @@
+package dto
+
+import "strings"
+
+// Address represents a structured Indian postal address
+type Address struct {
+	House       string `json:"house" validate:"required,max=100"`
+	Street      string `json:"street" validate:"omitempty,max=150"`
+	Locality    string `json:"locality" validate:"omitempty,max=100"`
+	VillageTown string `json:"village_town" validate:"required,max=100"`
+	District    string `json:"district" validate:"required,max=100"`
+	State       string `json:"state" validate:"required,indian_state"`
+	PinCode     string `json:"pin_code" validate:"required,pincode"`
+}
+
+// String formats the address as a single line, used for the flat address field
+func (a Address) String() string {
+	var parts []string
+	for _, part := range []string{a.House, a.Street, a.Locality, a.VillageTown, a.District} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ") + ", " + a.State + " " + a.PinCode
+}
@@ -0,0 +1,65 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditFieldChange represents keyed hashes of a field before and after a change
+type AuditFieldChange struct {
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// AuditEvent represents an audit event response
+type AuditEvent struct {
+	ID            uint64                      `json:"id"`
+	OccurredAt    time.Time                   `json:"occurred_at"`
+	Actor         string                      `json:"actor"`
+	Action        string                      `json:"action"`
+	TargetUserID  *uuid.UUID                  `json:"target_user_id,omitempty"`
+	RequestID     string                      `json:"request_id,omitempty"`
+	IP            string                      `json:"ip,omitempty"`
+	ChangedFields map[string]AuditFieldChange `json:"changed_fields,omitempty"`
+	Details       map[string]any              `json:"details,omitempty"`
+	PrevHash      string                      `json:"prev_hash"`
+	Hash          string                      `json:"hash"`
+}
+
+// AuditEvents represents a page of audit events
+type AuditEvents struct {
+	Events     []AuditEvent `json:"events"`
+	Total      int64        `json:"total"`
+	Page       int          `json:"page"`
+	Limit      int          `json:"limit"`
+	TotalPages int          `json:"total_pages"`
+}
+
+// AuditFilter represents filtering and pagination parameters for audit events
+type AuditFilter struct {
+	Page         int        `query:"page" validate:"min=1"`
+	Limit        int        `query:"limit" validate:"min=1,max=100"`
+	Actor        string     `query:"actor" validate:"omitempty,max=255"`
+	Action       string     `query:"action" validate:"omitempty,oneof=create read list export update delete restore purge"`
+	TargetUserID string     `query:"target_user_id" validate:"omitempty,uuid"`
+	RequestID    string     `query:"request_id" validate:"omitempty,max=64"`
+	From         *time.Time `query:"from"`
+	To           *time.Time `query:"to"`
+}
+
+// AuditVerification represents the result of checking the audit hash chain
+type AuditVerification struct {
+	Valid      bool   `json:"valid"`
+	Checked    int64  `json:"checked"`
+	BrokenAtID uint64 `json:"broken_at_id,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// DefaultAuditFilter returns default audit pagination values
+func DefaultAuditFilter() AuditFilter {
+	return AuditFilter{
+		Page:  1,
+		Limit: 20,
+	}
+}
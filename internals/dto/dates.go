@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"errors"
+	"time"
+)
+
+// DateLayout is the canonical date format used in responses and storage
+const DateLayout = "2006-01-02"
+
+// dateInputLayouts are the accepted input formats, YYYY-MM-DD and DD-MM-YYYY
+var dateInputLayouts = []string{DateLayout, "02-01-2006"}
+
+// ErrInvalidDate is returned when a date is not in an accepted format or not a real calendar date
+var ErrInvalidDate = errors.New("date must be YYYY-MM-DD or DD-MM-YYYY")
+
+// ParseDate parses a calendar date in any accepted input format
+func ParseDate(value string) (time.Time, error) {
+	for _, layout := range dateInputLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, ErrInvalidDate
+}
+
+// Age returns the age in completed years on the given day for a date of birth
+func Age(dob, on time.Time) int {
+	age := on.Year() - dob.Year()
+	if on.Month() < dob.Month() || (on.Month() == dob.Month() && on.Day() < dob.Day()) {
+		age--
+	}
+	return age
+}
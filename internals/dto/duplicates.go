@@ -0,0 +1,38 @@
+package dto
+
+import "github.com/google/uuid"
+
+// Duplicate is a live user that is likely the same applicant, with the similarity of each field.
+// It holds no PII of the other user; reading it needs a request of its own.
+type Duplicate struct {
+	UserID uuid.UUID       `json:"user_id"`
+	Score  float64         `json:"score"`
+	Fields DuplicateFields `json:"fields"`
+}
+
+// DuplicateFields are the similarities of the fields of two applicants, from 0 to 1
+type DuplicateFields struct {
+	Name        float64 `json:"name"`
+	DateOfBirth float64 `json:"date_of_birth"`
+	Gender      float64 `json:"gender"`
+	Phone       float64 `json:"phone"`
+	Address     float64 `json:"address"`
+}
+
+// Duplicates represents the likely duplicates of a user, best match first
+type Duplicates struct {
+	UserID     uuid.UUID   `json:"user_id"`
+	MinScore   float64     `json:"min_score"`
+	Duplicates []Duplicate `json:"duplicates"`
+}
+
+// DuplicatesParams represents parameters for finding the duplicates of a user
+type DuplicatesParams struct {
+	MinScore float64 `query:"min_score" validate:"gt=0,lte=1"`
+}
+
+// DuplicatePair is a pair of live users in a deduplication report, the older one first
+type DuplicatePair struct {
+	UserID uuid.UUID
+	Duplicate
+}
@@ -1,6 +1,13 @@
 package dto
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,13 +15,98 @@ import (
 
 // UserCreate represents the request body for creating a new user
 type UserCreate struct {
-	AadhaarApplicationID string `json:"aadhaar_application_id" validate:"required,len=14"`
-	Name                 string `json:"name" validate:"required,min=2,max=100"`
-	Email                string `json:"email" validate:"required,email"`
-	Phone                string `json:"phone" validate:"required,len=10,numeric"`
-	Address              string `json:"address" validate:"required,max=500"`
-	DateOfBirth          string `json:"date_of_birth" validate:"required"`
-	Gender               string `json:"gender" validate:"required,oneof=male female other"`
+	AadhaarApplicationID string   `json:"aadhaar_application_id" validate:"required,aadhaar_application_id"`
+	Name                 string   `json:"name" validate:"required,min=2,max=100"`
+	Email                string   `json:"email" validate:"required,email"`
+	Phone                string   `json:"phone" validate:"required,len=10,numeric"`
+	Address              string   `json:"address" validate:"required_without=AddressDetails,max=500"`
+	AddressDetails       *Address `json:"address_details"`
+	DateOfBirth          string   `json:"date_of_birth" validate:"required,date_of_birth"`
+	Gender               string   `json:"gender" validate:"required,oneof=male female other"`
+}
+
+// UserUpdate represents the request body for replacing a user's editable fields
+type UserUpdate struct {
+	AadhaarApplicationID string   `json:"aadhaar_application_id" validate:"required,aadhaar_application_id"`
+	Name                 string   `json:"name" validate:"required,min=2,max=100"`
+	Email                string   `json:"email" validate:"required,email"`
+	Phone                string   `json:"phone" validate:"required,len=10,numeric"`
+	Address              string   `json:"address" validate:"required_without=AddressDetails,max=500"`
+	AddressDetails       *Address `json:"address_details"`
+	DateOfBirth          string   `json:"date_of_birth" validate:"required,date_of_birth"`
+	Gender               string   `json:"gender" validate:"required,oneof=male female other"`
+}
+
+// ErrInvalidMergePatch is returned when a merge patch document is not a JSON object
+var ErrInvalidMergePatch = errors.New("merge patch must be a JSON object")
+
+// NewUserUpdate builds an update payload holding the current values of a user
+func NewUserUpdate(u User) UserUpdate {
+	return UserUpdate{
+		AadhaarApplicationID: u.AadhaarApplicationID,
+		Name:                 u.Name,
+		Email:                u.Email,
+		Phone:                u.Phone,
+		Address:              u.Address,
+		AddressDetails:       u.AddressDetails,
+		DateOfBirth:          u.DateOfBirth,
+		Gender:               u.Gender,
+	}
+}
+
+// ApplyMergePatch applies a JSON Merge Patch (RFC 7396) document onto the payload.
+// Members set to null are removed, so required-field validation rejects them.
+func (u *UserUpdate) ApplyMergePatch(patch []byte) error {
+	var members map[string]any
+	if err := json.Unmarshal(patch, &members); err != nil || members == nil {
+		return ErrInvalidMergePatch
+	}
+
+	current, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+
+	var target map[string]any
+	if err := json.Unmarshal(current, &target); err != nil {
+		return err
+	}
+	mergePatch(target, members)
+
+	// A new flat address supersedes the structured one it was derived from
+	if _, ok := members["address"]; ok {
+		if _, ok := members["address_details"]; !ok {
+			delete(target, "address_details")
+		}
+	}
+
+	merged, err := json.Marshal(target)
+	if err != nil {
+		return err
+	}
+
+	*u = UserUpdate{}
+	return json.Unmarshal(merged, u)
+}
+
+// mergePatch recursively merges patch into target following RFC 7396
+func mergePatch(target, patch map[string]any) {
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+		if object, ok := value.(map[string]any); ok {
+			nested, ok := target[key].(map[string]any)
+			if !ok {
+				nested = map[string]any{}
+			}
+			mergePatch(nested, object)
+			target[key] = nested
+			continue
+		}
+		target[key] = value
+	}
 }
 
 // User represents a user response
@@ -25,36 +117,171 @@ type User struct {
 	Email                string     `json:"email"`
 	Phone                string     `json:"phone"`
 	Address              string     `json:"address"`
+	AddressDetails       *Address   `json:"address_details,omitempty"`
 	DateOfBirth          string     `json:"date_of_birth"`
+	Age                  *int       `json:"age,omitempty"`
 	Gender               string     `json:"gender"`
 	CreatedAt            *time.Time `json:"created_at,omitempty"`
 	UpdatedAt            *time.Time `json:"updated_at,omitempty"`
+	DeletedAt            *time.Time `json:"deleted_at,omitempty"`
+
+	// Score is the search relevance, only set when listing with a search term
+	Score *float64 `json:"score,omitempty"`
+
+	// PossibleDuplicate flags a user enrolled although it likely duplicates the Duplicates, only
+	// set on creation in the soft deduplication mode
+	PossibleDuplicate bool        `json:"possible_duplicate,omitempty"`
+	Duplicates        []Duplicate `json:"duplicates,omitempty"`
 }
 
-// Users represents a collection of users with pagination metadata
+// Users represents a collection of users with pagination metadata. Total and TotalPages are only
+// set when the total was counted, and Page only in offset mode.
 type Users struct {
 	Users      []User `json:"users"`
-	Total      int64  `json:"total"`
-	Page       int    `json:"page"`
+	Total      *int64 `json:"total,omitempty"`
+	Page       int    `json:"page,omitempty"`
 	Limit      int    `json:"limit"`
-	TotalPages int    `json:"total_pages"`
+	TotalPages *int   `json:"total_pages,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
 
 // PaginationParams represents pagination and sorting parameters
 type PaginationParams struct {
-	Page    int    `query:"page" validate:"min=1"`
-	Limit   int    `query:"limit" validate:"min=1,max=100"`
-	SortBy  string `query:"sort_by" validate:"omitempty,oneof=name email created_at aadhaar_application_id"`
-	Order   string `query:"order" validate:"omitempty,oneof=asc desc"`
-	Search  string `query:"search"`
+	Page   int    `query:"page" validate:"min=1"`
+	Limit  int    `query:"limit" validate:"min=1,max=100"`
+	SortBy string `query:"sort_by" validate:"omitempty,oneof=name email created_at aadhaar_application_id relevance"`
+	Order  string `query:"order" validate:"omitempty,oneof=asc desc"`
+	Search string `query:"search"`
+
+	// SearchMode selects how Search matches, one of the Search* modes
+	SearchMode string `query:"search_mode" validate:"omitempty,oneof=contains exact prefix fuzzy fulltext phonetic"`
+
+	// Similarity is the trigram similarity a fuzzy match needs, between 0 and 1
+	Similarity float64 `query:"similarity" validate:"gt=0,lte=1"`
+
+	// State filters users by the state of their structured address
+	State string `query:"state"`
+
+	// Filters are the typed filters on user fields
+	Filters UserFilters `query:"-"`
+
+	// IncludeDeleted also lists soft-deleted users
+	IncludeDeleted bool `query:"include_deleted"`
+
+	// Cursor continues the listing from a row of an earlier page, Page is ignored when set
+	Cursor *Cursor `query:"-"`
+
+	// WithTotal also counts all matching users
+	WithTotal bool `query:"include_total"`
+}
+
+// Fingerprint identifies the sorting and filters of a listing, so a cursor is only followed
+// with the query it was issued for
+func (p PaginationParams) Fingerprint() string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s\x00%s\x00%s\x00%s\x00%g\x00%s\x00%t\x00%s",
+		p.SortBy, p.Order, p.Search, p.SearchMode, p.Similarity, p.State, p.IncludeDeleted, p.Filters))
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+// UserFilters represents typed filters of the user list; every filter set must match. Timestamp
+// ranges include From and exclude To, like the audit filters, and date of birth ranges include
+// both days.
+type UserFilters struct {
+	Gender      string     `query:"gender" validate:"omitempty,oneof=male female other"`
+	CreatedFrom *time.Time `query:"created_from"`
+	CreatedTo   *time.Time `query:"created_to"`
+	UpdatedFrom *time.Time `query:"updated_from"`
+	UpdatedTo   *time.Time `query:"updated_to"`
+
+	// DateOfBirthFrom and DateOfBirthTo are dates in any format ParseDate accepts
+	DateOfBirthFrom string `query:"date_of_birth_from" validate:"omitempty,date"`
+	DateOfBirthTo   string `query:"date_of_birth_to" validate:"omitempty,date"`
+
+	// EmailDomain matches the domain of the email exactly, ignoring case
+	EmailDomain string `query:"email_domain" validate:"omitempty,hostname_rfc1123,max=255"`
+
+	PhonePrefix   string `query:"phone_prefix" validate:"omitempty,numeric,max=10"`
+	PinCodePrefix string `query:"pin_code_prefix" validate:"omitempty,numeric,max=6"`
+
+	// AadhaarApplicationIDs lists the users with any of the IDs, given comma-separated or repeated
+	AadhaarApplicationIDs []string `query:"aadhaar_application_id" validate:"max=100,dive,aadhaar_application_id"`
+}
+
+// String returns the filters in a canonical form, so equal filters fingerprint alike
+func (f UserFilters) String() string {
+	bound := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+	from, _ := ParseDate(f.DateOfBirthFrom)
+	to, _ := ParseDate(f.DateOfBirthTo)
+	ids := slices.Sorted(slices.Values(f.AadhaarApplicationIDs))
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s",
+		f.Gender, bound(f.CreatedFrom), bound(f.CreatedTo), bound(f.UpdatedFrom), bound(f.UpdatedTo),
+		bound(&from), bound(&to), strings.ToLower(f.EmailDomain), f.PhonePrefix, f.PinCodePrefix,
+		strings.Join(ids, ","))
 }
 
+// Cursor is a position in a user list: the sort value and ID of a row, and whether the page
+// lies after the row or, going backwards, before it
+type Cursor struct {
+	Value    string    `json:"v"`
+	ID       uuid.UUID `json:"i"`
+	Backward bool      `json:"b,omitempty"`
+
+	// Query is the Fingerprint of the listing the cursor belongs to
+	Query string `json:"q"`
+}
+
+// Search modes of the user list
+const (
+	// SearchContains matches substrings of the name, email or Aadhaar Application ID
+	SearchContains = "contains"
+	// SearchExact matches the whole name, email or Aadhaar Application ID, ignoring case
+	SearchExact = "exact"
+	// SearchPrefix matches words of the name or email, or the Aadhaar Application ID, by prefix
+	SearchPrefix = "prefix"
+	// SearchFuzzy matches by trigram similarity, tolerating typos
+	SearchFuzzy = "fuzzy"
+	// SearchFullText matches users whose name and email contain every word of the search
+	SearchFullText = "fulltext"
+	// SearchPhonetic matches names that sound like the search, in any spelling or Indic script
+	SearchPhonetic = "phonetic"
+)
+
+// DefaultSimilarity is the fuzzy search threshold, the pg_trgm default
+const DefaultSimilarity = 0.3
+
 // DefaultPaginationParams returns default pagination values
 func DefaultPaginationParams() PaginationParams {
 	return PaginationParams{
-		Page:   1,
-		Limit:  10,
-		SortBy: "created_at",
-		Order:  "desc",
+		Page:       1,
+		Limit:      10,
+		SortBy:     "created_at",
+		Order:      "desc",
+		SearchMode: SearchContains,
+		Similarity: DefaultSimilarity,
+		WithTotal:  true,
+	}
+}
+
+// PurgeParams represents parameters for permanently removing soft-deleted users
+type PurgeParams struct {
+	OlderThanDays int `query:"older_than_days" validate:"min=1"`
+}
+
+// PurgeResult represents the outcome of a purge
+type PurgeResult struct {
+	Purged int64     `json:"purged"`
+	Before time.Time `json:"before"`
+}
+
+// DefaultPurgeParams returns default purge values
+func DefaultPurgeParams() PurgeParams {
+	return PurgeParams{
+		OlderThanDays: 30,
 	}
 }
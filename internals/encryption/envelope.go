@@ -0,0 +1,155 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// prefix marks encrypted values: enc:v1:<master key id>:<wrapped data key>:<ciphertext>
+const prefix = "enc:v1:"
+
+// DataKey is a per-row AES-256 key, stored wrapped by a master key next to each value it encrypts
+type DataKey struct {
+	KeyID   string
+	plain   []byte
+	wrapped []byte
+}
+
+// NewDataKey generates a data key wrapped by the active master key
+func (k *Keyring) NewDataKey() (*DataKey, error) {
+	plain := make([]byte, 32)
+	if _, err := rand.Read(plain); err != nil {
+		return nil, err
+	}
+
+	wrapped, err := seal(k.masterKeys[k.activeKeyID], plain, []byte("dek:"+k.activeKeyID))
+	if err != nil {
+		return nil, err
+	}
+
+	return &DataKey{KeyID: k.activeKeyID, plain: plain, wrapped: wrapped}, nil
+}
+
+// unwrap recovers a data key wrapped by the given master key
+func (k *Keyring) unwrap(keyID string, wrapped []byte) (*DataKey, error) {
+	master, ok := k.masterKeys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	plain, err := open(master, wrapped, []byte("dek:"+keyID))
+	if err != nil {
+		return nil, err
+	}
+
+	return &DataKey{KeyID: keyID, plain: plain, wrapped: wrapped}, nil
+}
+
+// Cell names where a value is stored. Values are sealed with their cell as additional data, so
+// ciphertext copied into another column, row or table fails to decrypt there.
+type Cell struct {
+	Table  string
+	RowID  string
+	Column string
+}
+
+// additionalData encodes the cell for GCM; table and column names and row ids never contain NUL
+func (c Cell) additionalData() []byte {
+	return []byte(c.Table + "\x00" + c.RowID + "\x00" + c.Column)
+}
+
+// Encrypt seals plaintext with the data key, bound to the cell it is stored in
+func (k *Keyring) Encrypt(dk *DataKey, cell Cell, plaintext []byte) (string, error) {
+	ciphertext, err := seal(dk.plain, plaintext, cell.additionalData())
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + dk.KeyID + ":" +
+		base64.RawStdEncoding.EncodeToString(dk.wrapped) + ":" +
+		base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt opens a value produced by Encrypt for the same cell
+func (k *Keyring) Decrypt(cell Cell, value string) ([]byte, error) {
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if !IsEncrypted(value) || len(parts) != 3 {
+		return nil, ErrMalformedValue
+	}
+
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrMalformedValue
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedValue
+	}
+
+	dk, err := k.unwrap(parts[0], wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	return open(dk.plain, ciphertext, cell.additionalData())
+}
+
+// BlindIndex returns a deterministic keyed hash of value for equality lookups.
+// purpose separates the index spaces of different columns.
+func (k *Keyring) BlindIndex(purpose, value string) string {
+	mac := hmac.New(sha256.New, k.blindIndexKey)
+	mac.Write([]byte(purpose))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// IsEncrypted reports whether a stored value was produced by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// seal encrypts with AES-256-GCM, returning nonce || ciphertext
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts nonce || ciphertext produced by seal
+func open(key, sealed, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrMalformedValue
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, additionalData)
+}
+
+// newGCM creates an AES-GCM AEAD for the key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,164 @@
+package encryption
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// cell returns a column of the same users row for every test
+func cell(column string) Cell {
+	return Cell{Table: "users", RowID: "3f2504e0-4f89-41d3-9a0c-0305e82c3301", Column: column}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	k := testKeyring(t)
+	dk, err := k.NewDataKey()
+	if err != nil {
+		t.Fatalf("data key: %v", err)
+	}
+
+	sealed, err := k.Encrypt(dk, cell("name"), []byte("Asha Rao"))
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if !IsEncrypted(sealed) || !strings.HasPrefix(sealed, "enc:v1:k2:") || strings.Contains(sealed, "Asha") {
+		t.Errorf("sealed value %q, want an enc:v1 value under k2 without the plaintext", sealed)
+	}
+
+	plain, err := k.Decrypt(cell("name"), sealed)
+	if err != nil || string(plain) != "Asha Rao" {
+		t.Fatalf("decrypt = %q, %v, want Asha Rao", plain, err)
+	}
+
+	again, err := k.Encrypt(dk, cell("name"), []byte("Asha Rao"))
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if again == sealed {
+		t.Error("encrypting twice gave the same value, want a fresh nonce")
+	}
+}
+
+func TestDecryptBindsCell(t *testing.T) {
+	k := testKeyring(t)
+	dk, err := k.NewDataKey()
+	if err != nil {
+		t.Fatalf("data key: %v", err)
+	}
+	sealed, err := k.Encrypt(dk, cell("name"), []byte("Asha Rao"))
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	// A value copied into another column, row or table must not decrypt there
+	for _, moved := range []Cell{
+		cell("email"),
+		{Table: "users", RowID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Column: "name"},
+		{Table: "user_addresses", RowID: cell("name").RowID, Column: "name"},
+	} {
+		if _, err := k.Decrypt(moved, sealed); err == nil {
+			t.Errorf("decrypted a value moved to %+v", moved)
+		}
+	}
+}
+
+func TestDecryptAfterRotation(t *testing.T) {
+	old := testKeyring(t, "k1")
+	dk, err := old.NewDataKey()
+	if err != nil {
+		t.Fatalf("data key: %v", err)
+	}
+	sealed, err := old.Encrypt(dk, cell("phone"), []byte("9800000001"))
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	// Rows sealed under k1 stay readable once k2 is active, as long as k1 is configured
+	if plain, err := testKeyring(t, "k2").Decrypt(cell("phone"), sealed); err != nil || string(plain) != "9800000001" {
+		t.Errorf("decrypt after rotation = %q, %v", plain, err)
+	}
+
+	retired, err := NewKeyring(map[string]string{"k2": key('2')}, "k2", key('b'))
+	if err != nil {
+		t.Fatalf("keyring: %v", err)
+	}
+	if _, err := retired.Decrypt(cell("phone"), sealed); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("decrypt without k1: error %v, want %v", err, ErrUnknownKey)
+	}
+
+	// The same key id with other key material fails authentication
+	swapped, err := NewKeyring(map[string]string{"k1": key('x')}, "k1", key('b'))
+	if err != nil {
+		t.Fatalf("keyring: %v", err)
+	}
+	if _, err := swapped.Decrypt(cell("phone"), sealed); err == nil {
+		t.Error("decrypted with the wrong master key")
+	}
+}
+
+func TestDecryptMalformed(t *testing.T) {
+	k := testKeyring(t)
+	dk, err := k.NewDataKey()
+	if err != nil {
+		t.Fatalf("data key: %v", err)
+	}
+	sealed, err := k.Encrypt(dk, cell("name"), []byte("Asha Rao"))
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	for _, value := range []string{
+		"Asha Rao",
+		"enc:v1:k2",
+		"enc:v1:k2:!!:!!",
+		"enc:v1:k2:" + strings.Repeat("A", 8) + ":",
+	} {
+		if _, err := k.Decrypt(cell("name"), value); !errors.Is(err, ErrMalformedValue) {
+			t.Errorf("decrypt %q: error %v, want %v", value, err, ErrMalformedValue)
+		}
+	}
+
+	// Flipping a character of the ciphertext fails authentication
+	tampered := []byte(sealed)
+	last := len(tampered) - 2
+	if tampered[last] == 'A' {
+		tampered[last] = 'B'
+	} else {
+		tampered[last] = 'A'
+	}
+	if _, err := k.Decrypt(cell("name"), string(tampered)); err == nil {
+		t.Error("decrypted a tampered value")
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	k := testKeyring(t)
+
+	index := k.BlindIndex("email", "asha@example.com")
+	if len(index) != 64 || index != k.BlindIndex("email", "asha@example.com") {
+		t.Errorf("blind index %q, want a deterministic hex SHA-256", index)
+	}
+	if index == k.BlindIndex("name", "asha@example.com") {
+		t.Error("blind indexes of different purposes collide")
+	}
+	if index == k.BlindIndex("email", "ravi@example.com") {
+		t.Error("blind indexes of different values collide")
+	}
+
+	// The index depends on the blind index key only, not on the master keys
+	other, err := NewKeyring(map[string]string{"k9": key('9')}, "k9", key('b'))
+	if err != nil {
+		t.Fatalf("keyring: %v", err)
+	}
+	if other.BlindIndex("email", "asha@example.com") != index {
+		t.Error("blind index changed with the master keys")
+	}
+	rekeyed, err := NewKeyring(map[string]string{"k2": key('2')}, "k2", key('c'))
+	if err != nil {
+		t.Fatalf("keyring: %v", err)
+	}
+	if rekeyed.BlindIndex("email", "asha@example.com") == index {
+		t.Error("blind index unchanged under another blind index key")
+	}
+}
@@ -0,0 +1,144 @@
+package encryption
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"aadhaar-user-service/internals/config"
+)
+
+var (
+	ErrUnknownKey     = errors.New("unknown master key id")
+	ErrInvalidKey     = errors.New("encryption keys must be base64-encoded 32-byte values")
+	ErrInvalidKeyID   = errors.New("master key id must be non-empty and must not contain ':'")
+	ErrNoBlindIndex   = errors.New("blind index key is required when master keys are configured")
+	ErrNotConfigured  = errors.New("encryption keys are not configured")
+	ErrMalformedValue = errors.New("malformed encrypted value")
+	ErrNoRowID        = errors.New("encrypted column read or written without its row's primary key")
+)
+
+// Keyring holds the master keys used to wrap data keys and the key used for blind indexes
+type Keyring struct {
+	masterKeys    map[string][]byte
+	activeKeyID   string
+	blindIndexKey []byte
+}
+
+// keyringFile is the on-disk representation of a keyring
+type keyringFile struct {
+	ActiveKeyID   string            `json:"active_key_id"`
+	MasterKeys    map[string]string `json:"master_keys"`
+	BlindIndexKey string            `json:"blind_index_key"`
+}
+
+type keyringKey struct{}
+
+// WithKeyring returns a context carrying k, the keyring the pii serializer and the models' hooks
+// encrypt with. Repositories set it on every query; a nil k stores PII in plaintext.
+func WithKeyring(ctx context.Context, k *Keyring) context.Context {
+	return context.WithValue(ctx, keyringKey{}, k)
+}
+
+// FromContext returns the keyring carried by ctx, nil when encryption is disabled
+func FromContext(ctx context.Context) *Keyring {
+	k, _ := ctx.Value(keyringKey{}).(*Keyring)
+	return k
+}
+
+// Load builds the keyring described by cfg, from cfg.KeyringFile (JSON) or from cfg.MasterKeys
+// ("id:base64,id:base64"), cfg.ActiveKeyID and cfg.BlindIndexKey. Without keys it returns
+// ErrNotConfigured.
+func Load(cfg config.Encryption) (*Keyring, error) {
+	if cfg.KeyringFile != "" {
+		data, err := os.ReadFile(cfg.KeyringFile)
+		if err != nil {
+			return nil, fmt.Errorf("read keyring file: %w", err)
+		}
+
+		var file keyringFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("parse keyring file: %w", err)
+		}
+		return NewKeyring(file.MasterKeys, file.ActiveKeyID, file.BlindIndexKey)
+	}
+
+	if cfg.MasterKeys == "" {
+		return nil, ErrNotConfigured
+	}
+
+	keys := make(map[string]string)
+	var lastID string
+	for _, entry := range strings.Split(cfg.MasterKeys, ",") {
+		id, key, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return nil, ErrInvalidKeyID
+		}
+		keys[id] = key
+		lastID = id
+	}
+
+	// The last listed key is active unless stated otherwise
+	activeKeyID := cfg.ActiveKeyID
+	if activeKeyID == "" {
+		activeKeyID = lastID
+	}
+
+	return NewKeyring(keys, activeKeyID, cfg.BlindIndexKey)
+}
+
+// NewKeyring builds a keyring from base64-encoded 32-byte keys
+func NewKeyring(masterKeys map[string]string, activeKeyID, blindIndexKey string) (*Keyring, error) {
+	if len(masterKeys) == 0 {
+		return nil, ErrNotConfigured
+	}
+
+	k := &Keyring{
+		masterKeys:  make(map[string][]byte, len(masterKeys)),
+		activeKeyID: activeKeyID,
+	}
+
+	for id, encoded := range masterKeys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, ErrInvalidKeyID
+		}
+		key, err := decodeKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("master key %q: %w", id, err)
+		}
+		k.masterKeys[id] = key
+	}
+
+	if _, ok := k.masterKeys[activeKeyID]; !ok {
+		return nil, fmt.Errorf("active key %q: %w", activeKeyID, ErrUnknownKey)
+	}
+
+	if blindIndexKey == "" {
+		return nil, ErrNoBlindIndex
+	}
+	key, err := decodeKey(blindIndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("blind index key: %w", err)
+	}
+	k.blindIndexKey = key
+
+	return k, nil
+}
+
+// ActiveKeyID returns the id of the master key used for new data keys
+func (k *Keyring) ActiveKeyID() string {
+	return k.activeKeyID
+}
+
+// decodeKey decodes a base64 AES-256 key
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != 32 {
+		return nil, ErrInvalidKey
+	}
+	return key, nil
+}
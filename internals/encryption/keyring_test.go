@@ -0,0 +1,98 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"aadhaar-user-service/internals/config"
+)
+
+// key returns a base64 AES-256 key of the repeated byte b
+func key(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))
+}
+
+// testKeyring returns a keyring with the master keys k1 and k2, k2 active unless given
+func testKeyring(t *testing.T, active ...string) *Keyring {
+	t.Helper()
+
+	activeKeyID := "k2"
+	if len(active) > 0 {
+		activeKeyID = active[0]
+	}
+	k, err := NewKeyring(map[string]string{"k1": key('1'), "k2": key('2')}, activeKeyID, key('b'))
+	if err != nil {
+		t.Fatalf("keyring: %v", err)
+	}
+	return k
+}
+
+func TestNewKeyring(t *testing.T) {
+	tests := []struct {
+		name       string
+		masterKeys map[string]string
+		active     string
+		blindIndex string
+		want       error
+	}{
+		{"valid", map[string]string{"k1": key('1')}, "k1", key('b'), nil},
+		{"no master keys", nil, "k1", key('b'), ErrNotConfigured},
+		{"empty key id", map[string]string{"": key('1')}, "", key('b'), ErrInvalidKeyID},
+		{"key id with a colon", map[string]string{"k:1": key('1')}, "k:1", key('b'), ErrInvalidKeyID},
+		{"short master key", map[string]string{"k1": base64.StdEncoding.EncodeToString([]byte("short"))}, "k1", key('b'), ErrInvalidKey},
+		{"master key not base64", map[string]string{"k1": "not base64!"}, "k1", key('b'), ErrInvalidKey},
+		{"unknown active key", map[string]string{"k1": key('1')}, "k2", key('b'), ErrUnknownKey},
+		{"no blind index key", map[string]string{"k1": key('1')}, "k1", "", ErrNoBlindIndex},
+		{"short blind index key", map[string]string{"k1": key('1')}, "k1", "c2hvcnQ=", ErrInvalidKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := NewKeyring(tt.masterKeys, tt.active, tt.blindIndex)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("error %v, want %v", err, tt.want)
+			}
+			if err == nil && k.ActiveKeyID() != tt.active {
+				t.Errorf("active key %q, want %q", k.ActiveKeyID(), tt.active)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	if _, err := Load(config.Encryption{}); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("no keys: error %v, want %v", err, ErrNotConfigured)
+	}
+
+	k, err := Load(config.Encryption{MasterKeys: "k1:" + key('1') + ", k2:" + key('2'), BlindIndexKey: key('b')})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if k.ActiveKeyID() != "k2" {
+		t.Errorf("active key %q, want the last listed k2", k.ActiveKeyID())
+	}
+
+	k, err = Load(config.Encryption{MasterKeys: "k1:" + key('1') + ",k2:" + key('2'), ActiveKeyID: "k1", BlindIndexKey: key('b')})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if k.ActiveKeyID() != "k1" {
+		t.Errorf("active key %q, want k1 as configured", k.ActiveKeyID())
+	}
+
+	if _, err := Load(config.Encryption{MasterKeys: key('1'), BlindIndexKey: key('b')}); !errors.Is(err, ErrInvalidKeyID) {
+		t.Errorf("key without id: error %v, want %v", err, ErrInvalidKeyID)
+	}
+
+	file := filepath.Join(t.TempDir(), "keyring.json")
+	data := `{"active_key_id": "k1", "master_keys": {"k1": "` + key('1') + `"}, "blind_index_key": "` + key('b') + `"}`
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if k, err = Load(config.Encryption{KeyringFile: file}); err != nil || k.ActiveKeyID() != "k1" {
+		t.Errorf("keyring file: %v, %v, want active key k1", k, err)
+	}
+}
@@ -0,0 +1,163 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+func init() {
+	schema.RegisterSerializer("pii", PIISerializer{})
+}
+
+// dateLayout is how time.Time fields are stored in encrypted columns
+const dateLayout = "2006-01-02"
+
+// Envelope is embedded in models with encrypted columns and carries the row's data key.
+// PIIKeyID records which master key wrapped it, so rows can be found for rotation.
+type Envelope struct {
+	PIIKeyID *string `gorm:"column:pii_key_id;size:64;index" json:"-"`
+
+	dataKey *DataKey
+}
+
+// SealEnvelope prepares the row's data key under k before its encrypted columns are written.
+// It is called from the models' BeforeSave hooks; a nil k leaves the row in plaintext.
+func (e *Envelope) SealEnvelope(k *Keyring) error {
+	if k == nil {
+		e.PIIKeyID = nil
+		return nil
+	}
+
+	if e.dataKey == nil || e.dataKey.KeyID != k.ActiveKeyID() {
+		dk, err := k.NewDataKey()
+		if err != nil {
+			return err
+		}
+		e.dataKey = dk
+	}
+
+	keyID := e.dataKey.KeyID
+	e.PIIKeyID = &keyID
+	return nil
+}
+
+// envelopeCarrier is implemented by models embedding Envelope
+type envelopeCarrier interface {
+	envelope() *Envelope
+}
+
+func (e *Envelope) envelope() *Envelope {
+	return e
+}
+
+// PIISerializer encrypts string and date fields tagged `serializer:pii` with the keyring in the
+// statement context (see WithKeyring), binding each value to its table, row and column. Rows must
+// have their primary key set before they are written and read it ahead of encrypted columns, as
+// SELECT * does. Plaintext values are read as-is, so rows written before encryption was enabled
+// stay readable.
+type PIISerializer struct{}
+
+// cellOf returns where field of the row dst is stored
+func cellOf(ctx context.Context, field *schema.Field, dst reflect.Value) (Cell, error) {
+	pk := field.Schema.PrioritizedPrimaryField
+	if pk == nil {
+		return Cell{}, fmt.Errorf("column %s: %w", field.DBName, ErrNoRowID)
+	}
+	id, zero := pk.ValueOf(ctx, dst)
+	if zero {
+		return Cell{}, fmt.Errorf("column %s: %w", field.DBName, ErrNoRowID)
+	}
+	return Cell{Table: field.Schema.Table, RowID: fmt.Sprint(id), Column: field.DBName}, nil
+}
+
+// Scan implements serializer interface
+func (PIISerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+		return nil
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	case time.Time:
+		stored = v.Format(dateLayout)
+	default:
+		return fmt.Errorf("unsupported value %T for encrypted column %s", dbValue, field.DBName)
+	}
+
+	plaintext := stored
+	if IsEncrypted(stored) {
+		k := FromContext(ctx)
+		if k == nil {
+			return fmt.Errorf("column %s: %w", field.DBName, ErrNotConfigured)
+		}
+		cell, err := cellOf(ctx, field, dst)
+		if err != nil {
+			return err
+		}
+		decrypted, err := k.Decrypt(cell, stored)
+		if err != nil {
+			return fmt.Errorf("decrypt column %s: %w", field.DBName, err)
+		}
+		plaintext = string(decrypted)
+	}
+
+	fieldValue := field.ReflectValueOf(ctx, dst)
+	switch fieldValue.Interface().(type) {
+	case string:
+		fieldValue.SetString(plaintext)
+	case time.Time:
+		t, err := time.Parse(dateLayout, plaintext)
+		if err != nil {
+			return fmt.Errorf("parse column %s: %w", field.DBName, err)
+		}
+		fieldValue.Set(reflect.ValueOf(t))
+	default:
+		return fmt.Errorf("unsupported field type %s for encrypted column %s", field.FieldType, field.DBName)
+	}
+	return nil
+}
+
+// Value implements serializer interface
+func (PIISerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	var plaintext string
+	switch v := fieldValue.(type) {
+	case string:
+		plaintext = v
+	case time.Time:
+		plaintext = v.Format(dateLayout)
+	default:
+		return nil, fmt.Errorf("unsupported field type %T for encrypted column %s", fieldValue, field.DBName)
+	}
+
+	k := FromContext(ctx)
+	if k == nil {
+		return plaintext, nil
+	}
+
+	cell, err := cellOf(ctx, field, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	var dk *DataKey
+	if dst.CanAddr() {
+		if carrier, ok := dst.Addr().Interface().(envelopeCarrier); ok {
+			dk = carrier.envelope().dataKey
+		}
+	}
+
+	// Fall back to a data key for this value alone when the row has none
+	if dk == nil {
+		if dk, err = k.NewDataKey(); err != nil {
+			return nil, err
+		}
+	}
+
+	return k.Encrypt(dk, cell, []byte(plaintext))
+}
@@ -0,0 +1,59 @@
+package geo
+
+import (
+	_ "embed"
+	"encoding/json"
+	"strings"
+)
+
+//go:embed data/districts.json
+var districtsJSON []byte
+
+// stateDistricts lists the districts of one state or union territory, by state code. Aliases map
+// former names and common spellings that still appear in addresses to the current district.
+type stateDistricts struct {
+	State     string            `json:"state"`
+	Districts []string          `json:"districts"`
+	Aliases   map[string]string `json:"aliases"`
+}
+
+// districtsByState maps a state code and a normalized district name or alias to the district
+var districtsByState map[string]map[string]string
+
+func init() {
+	var all []stateDistricts
+	if err := json.Unmarshal(districtsJSON, &all); err != nil {
+		panic(err)
+	}
+
+	districtsByState = make(map[string]map[string]string, len(all))
+	for _, s := range all {
+		names := make(map[string]string, len(s.Districts)+len(s.Aliases))
+		for _, d := range s.Districts {
+			names[normalizeDistrict(d)] = d
+		}
+		for alias, d := range s.Aliases {
+			names[normalizeDistrict(alias)] = d
+		}
+		districtsByState[s.State] = names
+	}
+}
+
+// LookupDistrict finds a district of the state by its current name or an alias, ignoring case,
+// dots and hyphens, and returns its current name
+func LookupDistrict(district, nameOrCode string) (string, bool) {
+	state, ok := LookupState(nameOrCode)
+	if !ok {
+		return "", false
+	}
+	name, ok := districtsByState[state.Code][normalizeDistrict(district)]
+	return name, ok
+}
+
+// normalizeDistrict folds a district name like normalize, also ignoring the dots of initials and
+// the hyphens of joined names
+func normalizeDistrict(s string) string {
+	s = strings.ReplaceAll(s, ".", "")
+	s = strings.ReplaceAll(s, "-", " ")
+	return normalize(s)
+}
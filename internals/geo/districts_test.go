@@ -0,0 +1,62 @@
+package geo
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestDistrictData(t *testing.T) {
+	var all []stateDistricts
+	if err := json.Unmarshal(districtsJSON, &all); err != nil {
+		t.Fatal(err)
+	}
+
+	listed := make(map[string]bool)
+	for _, s := range all {
+		if _, ok := LookupState(s.State); !ok || listed[s.State] {
+			t.Errorf("districts of unknown or repeated state %q", s.State)
+		}
+		listed[s.State] = true
+
+		if len(s.Districts) == 0 {
+			t.Errorf("%s has no districts", s.State)
+		}
+		for alias, district := range s.Aliases {
+			if !slices.Contains(s.Districts, district) {
+				t.Errorf("%s: alias %q of unlisted district %q", s.State, alias, district)
+			}
+		}
+	}
+	for _, s := range States() {
+		if !listed[s.Code] {
+			t.Errorf("no districts for %s", s.Name)
+		}
+	}
+}
+
+func TestLookupDistrict(t *testing.T) {
+	tests := []struct {
+		district, state string
+		want            string
+		ok              bool
+	}{
+		{"Bengaluru Urban", "Karnataka", "Bengaluru Urban", true},
+		{"bangalore urban", "KA", "Bengaluru Urban", true},
+		{"Gurgaon", "Haryana", "Gurugram", true},
+		{"medchal malkajgiri", "Telangana", "Medchal-Malkajgiri", true},
+		{"Dr B.R. Ambedkar Konaseema", "AP", "Dr. B.R. Ambedkar Konaseema", true},
+		{"Aurangabad", "Bihar", "Aurangabad", true},
+		{"Aurangabad", "Maharashtra", "Chhatrapati Sambhajinagar", true},
+		{"Mumbai City", "Karnataka", "", false},
+		{"Bengaluru Urban", "Atlantis", "", false},
+		{"", "Karnataka", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := LookupDistrict(tt.district, tt.state)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("LookupDistrict(%q, %q) = %q, %v, want %q, %v", tt.district, tt.state, got, ok, tt.want, tt.ok)
+		}
+	}
+}
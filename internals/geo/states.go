@@ -0,0 +1,82 @@
+package geo
+
+import (
+	_ "embed"
+	"encoding/json"
+	"strings"
+)
+
+//go:embed data/states.json
+var statesJSON []byte
+
+// State represents an Indian state or union territory and the PIN code prefixes it is served by
+type State struct {
+	Code        string   `json:"code"`
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	PinPrefixes []string `json:"pin_prefixes"`
+}
+
+var (
+	states      []State
+	statesByKey map[string]*State
+)
+
+func init() {
+	if err := json.Unmarshal(statesJSON, &states); err != nil {
+		panic(err)
+	}
+
+	statesByKey = make(map[string]*State, len(states)*2)
+	for i := range states {
+		statesByKey[normalize(states[i].Name)] = &states[i]
+		statesByKey[normalize(states[i].Code)] = &states[i]
+	}
+}
+
+// States returns all states and union territories in the reference dataset
+func States() []State {
+	return states
+}
+
+// LookupState finds a state or union territory by name or two-letter code, ignoring case
+func LookupState(nameOrCode string) (State, bool) {
+	state, ok := statesByKey[normalize(nameOrCode)]
+	if !ok {
+		return State{}, false
+	}
+	return *state, true
+}
+
+// ValidPinCode reports whether pin is a 6-digit PIN code with a non-zero leading digit
+func ValidPinCode(pin string) bool {
+	if len(pin) != 6 || pin[0] < '1' || pin[0] > '9' {
+		return false
+	}
+	for i := 1; i < len(pin); i++ {
+		if pin[i] < '0' || pin[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// PinMatchesState reports whether pin falls in one of the PIN prefixes of the state
+func PinMatchesState(pin, nameOrCode string) bool {
+	state, ok := LookupState(nameOrCode)
+	if !ok || !ValidPinCode(pin) {
+		return false
+	}
+	for _, prefix := range state.PinPrefixes {
+		if strings.HasPrefix(pin, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize folds case and whitespace so lookups are forgiving of input formatting
+func normalize(s string) string {
+	s = strings.ReplaceAll(s, "&", "and")
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormLogger writes GORM messages through the request-scoped logger. SQL is logged with
+// placeholders only, so bound values (PII) never reach the logs.
+type GormLogger struct {
+	SlowThreshold time.Duration
+	level         gormlogger.LogLevel
+}
+
+var (
+	_ gormlogger.Interface = (*GormLogger)(nil)
+	_ gorm.ParamsFilter    = (*GormLogger)(nil)
+)
+
+// NewGormLogger returns a GORM logger for the configured level; debug logs every statement
+func NewGormLogger(cfg string) *GormLogger {
+	l := &GormLogger{SlowThreshold: 200 * time.Millisecond, level: gormlogger.Warn}
+	switch cfg {
+	case "debug":
+		l.level = gormlogger.Info
+	case "error":
+		l.level = gormlogger.Error
+	}
+	return l
+}
+
+// LogMode implements gormlogger.Interface
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	copy := *l
+	copy.level = level
+	return &copy
+}
+
+// Info implements gormlogger.Interface
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...any) {
+	if l.level >= gormlogger.Info {
+		FromContext(ctx).InfoContext(ctx, "gorm", slog.String("message", msg))
+	}
+}
+
+// Warn implements gormlogger.Interface
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...any) {
+	if l.level >= gormlogger.Warn {
+		FromContext(ctx).WarnContext(ctx, "gorm", slog.String("message", msg))
+	}
+}
+
+// Error implements gormlogger.Interface
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...any) {
+	if l.level >= gormlogger.Error {
+		FromContext(ctx).ErrorContext(ctx, "gorm", slog.String("message", msg))
+	}
+}
+
+// Trace implements gormlogger.Interface, logging failed, slow or (at debug) all statements
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	logger := FromContext(ctx)
+
+	switch {
+	case err != nil && l.level >= gormlogger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		logger.ErrorContext(ctx, "query failed", slog.String("sql", sql), slog.Int64("rows", rows),
+			slog.Duration("elapsed", elapsed), Err(err))
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold && l.level >= gormlogger.Warn:
+		sql, rows := fc()
+		logger.WarnContext(ctx, "slow query", slog.String("sql", sql), slog.Int64("rows", rows),
+			slog.Duration("elapsed", elapsed))
+	case l.level >= gormlogger.Info:
+		sql, rows := fc()
+		logger.DebugContext(ctx, "query", slog.String("sql", sql), slog.Int64("rows", rows),
+			slog.Duration("elapsed", elapsed))
+	}
+}
+
+// ParamsFilter implements gorm.ParamsFilter by dropping the bound values from logged SQL
+func (l *GormLogger) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	return sql, nil
+}
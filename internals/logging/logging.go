@@ -0,0 +1,110 @@
+// Package logging provides the structured JSON logger of the service. Request-scoped loggers
+// carry the request and trace IDs through the context, and attributes that may hold PII are
+// redacted before they are written.
+package logging
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"aadhaar-user-service/internals/config"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// redacted replaces the value of attributes that may hold PII
+const redacted = "[REDACTED]"
+
+// piiKeys are attribute keys whose values are never written
+var piiKeys = map[string]bool{
+	"name":                   true,
+	"email":                  true,
+	"phone":                  true,
+	"address":                true,
+	"address_details":        true,
+	"date_of_birth":          true,
+	"dob":                    true,
+	"aadhaar_number":         true,
+	"aadhaar_application_id": true,
+	"search":                 true,
+}
+
+// Setup installs the process-wide logger
+func Setup(cfg config.Log) {
+	slog.SetDefault(New(os.Stdout, cfg))
+}
+
+// New returns a logger writing to w at the configured level and format
+func New(w io.Writer, cfg config.Log) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level:       level(cfg.Level),
+		ReplaceAttr: redact,
+	}
+
+	var handler slog.Handler = slog.NewJSONHandler(w, opts)
+	if cfg.Format == "text" {
+		handler = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(handler)
+}
+
+// level maps the configured level name onto slog
+func level(name string) slog.Level {
+	switch name {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// redact drops the value of PII attributes, whatever group they are in
+func redact(_ []string, a slog.Attr) slog.Attr {
+	if piiKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+	return a
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a context carrying l
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the request-scoped logger in ctx, or the process logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+			return l
+		}
+	}
+	return slog.Default()
+}
+
+// Err returns an error attribute. Postgres errors are reduced to their message, code and
+// constraint, as their detail repeats the offending values.
+func Err(err error) slog.Attr {
+	if err == nil {
+		return slog.String("error", "")
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return slog.Group("error",
+			slog.String("message", pgErr.Message),
+			slog.String("code", pgErr.Code),
+			slog.String("constraint", pgErr.ConstraintName),
+		)
+	}
+	return slog.String("error", err.Error())
+}
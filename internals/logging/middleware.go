@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// validRequestID limits client-supplied request IDs to a safe charset and the audit column size
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the request ID stored by RequestID
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestID honors a well-formed X-Request-ID header or generates one, echoes it in the response
+// and stores it, with a logger carrying it and the trace ID, in the request's user context
+func RequestID(c *fiber.Ctx) error {
+	id := c.Get(fiber.HeaderXRequestID)
+	if !validRequestID.MatchString(id) {
+		id = uuid.NewString()
+	}
+	c.Set(fiber.HeaderXRequestID, id)
+
+	ctx := context.WithValue(c.UserContext(), requestIDKey{}, id)
+
+	l := slog.Default().With(slog.String("request_id", id))
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		l = l.With(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+
+	c.SetUserContext(WithLogger(ctx, l))
+	return c.Next()
+}
+
+// AccessLog logs each completed request. The query string is left out, as search terms may be PII.
+func AccessLog(c *fiber.Ctx) error {
+	start := time.Now()
+	err := c.Next()
+
+	// The error handler sets the status only after the middleware chain returns
+	status := c.Response().StatusCode()
+	if err != nil {
+		status = fiber.StatusInternalServerError
+		var fe *fiber.Error
+		if errors.As(err, &fe) {
+			status = fe.Code
+		}
+	}
+
+	level := slog.LevelInfo
+	switch {
+	case status >= fiber.StatusInternalServerError:
+		level = slog.LevelError
+	case status >= fiber.StatusBadRequest:
+		level = slog.LevelWarn
+	}
+
+	ctx := c.UserContext()
+	FromContext(ctx).LogAttrs(ctx, level, "request",
+		slog.String("method", c.Method()),
+		slog.String("route", c.Route().Path),
+		slog.String("path", c.Path()),
+		slog.Int("status", status),
+		slog.Duration("latency", time.Since(start)),
+		slog.String("ip", c.IP()),
+	)
+
+	return err
+}
@@ -0,0 +1,100 @@
+package masking
+
+import (
+	"context"
+	"strings"
+
+	"aadhaar-user-service/internals/auth"
+	"aadhaar-user-service/internals/dto"
+)
+
+// User masks the PII of a user response according to the caller in ctx
+func User(ctx context.Context, u *dto.User) {
+	roles := auth.RolesFromContext(ctx)
+	p := FromContext(ctx)
+
+	switch p.RuleFor(roles, FieldName) {
+	case Mask:
+		u.Name = maskName(u.Name)
+	case Hidden:
+		u.Name = ""
+	}
+
+	switch p.RuleFor(roles, FieldEmail) {
+	case Mask:
+		u.Email = maskEmail(u.Email)
+	case Hidden:
+		u.Email = ""
+	}
+
+	switch p.RuleFor(roles, FieldPhone) {
+	case Mask:
+		u.Phone = maskPhone(u.Phone)
+	case Hidden:
+		u.Phone = ""
+	}
+
+	switch p.RuleFor(roles, FieldAddress) {
+	case Mask:
+		maskAddress(u)
+	case Hidden:
+		u.Address = ""
+		u.AddressDetails = nil
+	}
+
+	switch p.RuleFor(roles, FieldDateOfBirth) {
+	case Mask:
+		// Only the year is shown; age would narrow it down further
+		if len(u.DateOfBirth) >= 4 {
+			u.DateOfBirth = u.DateOfBirth[:4]
+		}
+		u.Age = nil
+	case Hidden:
+		u.DateOfBirth = ""
+		u.Age = nil
+	}
+}
+
+// maskPhone keeps the last four digits: XXXXXX3210
+func maskPhone(phone string) string {
+	if len(phone) <= 4 {
+		return strings.Repeat("X", len(phone))
+	}
+	return strings.Repeat("X", len(phone)-4) + phone[len(phone)-4:]
+}
+
+// maskEmail keeps the first character of the local part and the domain: r****@example.com
+func maskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return "****"
+	}
+	return local[:1] + "****@" + domain
+}
+
+// maskName keeps the initial of each word: R**** K****
+func maskName(name string) string {
+	words := strings.Fields(name)
+	for i, w := range words {
+		r := []rune(w)
+		words[i] = string(r[:1]) + strings.Repeat("*", len(r)-1)
+	}
+	return strings.Join(words, " ")
+}
+
+// maskAddress keeps only district, state and PIN code. A flat address without
+// structured details cannot be split safely and is redacted.
+func maskAddress(u *dto.User) {
+	if u.AddressDetails == nil {
+		u.Address = "[REDACTED]"
+		return
+	}
+
+	masked := &dto.Address{
+		District: u.AddressDetails.District,
+		State:    u.AddressDetails.State,
+		PinCode:  u.AddressDetails.PinCode,
+	}
+	u.AddressDetails = masked
+	u.Address = masked.District + ", " + masked.State + " " + masked.PinCode
+}
@@ -0,0 +1,161 @@
+package masking
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"aadhaar-user-service/internals/config"
+)
+
+// Rule decides how much of a PII field a caller sees
+type Rule string
+
+const (
+	Hidden Rule = "hidden" // field is blanked
+	Mask   Rule = "mask"   // field is partially shown, e.g. XXXXXX3210
+	Full   Rule = "full"   // field is shown unmasked
+)
+
+// Maskable fields of a user
+const (
+	FieldName        = "name"
+	FieldEmail       = "email"
+	FieldPhone       = "phone"
+	FieldAddress     = "address"
+	FieldDateOfBirth = "date_of_birth"
+
+	// AllFields applies a rule to every field of a role
+	AllFields = "*"
+)
+
+var fields = []string{FieldName, FieldEmail, FieldPhone, FieldAddress, FieldDateOfBirth}
+
+// ranks orders rules from most to least restrictive
+var ranks = map[Rule]int{Hidden: 0, Mask: 1, Full: 2}
+
+// Policy maps roles to per-field rules. Callers without a listed role get Default.
+// With several roles, the least restrictive rule per field wins.
+type Policy struct {
+	Default map[string]Rule            `json:"default"`
+	Roles   map[string]map[string]Rule `json:"roles"`
+}
+
+// DefaultPolicy masks phone, email, address and date of birth for everyone except
+// supervisors and admins, who may view PII unmasked
+func DefaultPolicy() *Policy {
+	return &Policy{
+		Default: map[string]Rule{
+			FieldName:        Full,
+			FieldEmail:       Mask,
+			FieldPhone:       Mask,
+			FieldAddress:     Mask,
+			FieldDateOfBirth: Mask,
+		},
+		Roles: map[string]map[string]Rule{
+			"admin":      {AllFields: Full},
+			"supervisor": {AllFields: Full},
+		},
+	}
+}
+
+// New loads the masking policy from cfg.PolicyFile, or returns the default without one
+func New(cfg config.Masking) (*Policy, error) {
+	if cfg.PolicyFile == "" {
+		return DefaultPolicy(), nil
+	}
+
+	data, err := os.ReadFile(cfg.PolicyFile)
+	if err != nil {
+		return nil, fmt.Errorf("read masking policy: %w", err)
+	}
+
+	p := &Policy{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("parse masking policy: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+type policyKey struct{}
+
+// WithPolicy returns a context carrying the masking policy responses are masked by
+func WithPolicy(ctx context.Context, p *Policy) context.Context {
+	return context.WithValue(ctx, policyKey{}, p)
+}
+
+// FromContext returns the masking policy carried by ctx, the default policy without one
+func FromContext(ctx context.Context) *Policy {
+	if p, ok := ctx.Value(policyKey{}).(*Policy); ok && p != nil {
+		return p
+	}
+	return DefaultPolicy()
+}
+
+// Validate checks that the policy only uses known fields and rules
+func (p *Policy) Validate() error {
+	check := func(scope string, rules map[string]Rule) error {
+		for field, rule := range rules {
+			if _, ok := ranks[rule]; !ok {
+				return fmt.Errorf("masking policy %s: unknown rule %q for %s", scope, rule, field)
+			}
+			if field != AllFields && !knownField(field) {
+				return fmt.Errorf("masking policy %s: unknown field %q", scope, field)
+			}
+		}
+		return nil
+	}
+
+	if err := check("default", p.Default); err != nil {
+		return err
+	}
+	for role, rules := range p.Roles {
+		if err := check("role "+role, rules); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RuleFor returns the rule for a field given the caller's roles
+func (p *Policy) RuleFor(roles []string, field string) Rule {
+	best, found := Hidden, false
+	for _, role := range roles {
+		rules, ok := p.Roles[role]
+		if !ok {
+			continue
+		}
+		rule, ok := rules[field]
+		if !ok {
+			rule, ok = rules[AllFields]
+		}
+		if ok && (!found || ranks[rule] > ranks[best]) {
+			best, found = rule, true
+		}
+	}
+	if found {
+		return best
+	}
+
+	if rule, ok := p.Default[field]; ok {
+		return rule
+	}
+	if rule, ok := p.Default[AllFields]; ok {
+		return rule
+	}
+	return Mask
+}
+
+// knownField reports whether field is maskable
+func knownField(field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
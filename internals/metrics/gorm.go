@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const startKey = "metrics:start"
+
+// GormPlugin records the latency and errors of every GORM query
+type GormPlugin struct {
+	metrics *Metrics
+}
+
+// GormPlugin returns the plugin recording queries into m
+func (m *Metrics) GormPlugin() GormPlugin {
+	return GormPlugin{metrics: m}
+}
+
+// Name implements gorm.Plugin
+func (GormPlugin) Name() string {
+	return "metrics"
+}
+
+// Initialize implements gorm.Plugin by wrapping each callback chain
+func (p GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	chains := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+
+	for _, chain := range chains {
+		if err := chain.before("metrics:before_"+chain.operation, before); err != nil {
+			return err
+		}
+		if err := chain.after("metrics:after_"+chain.operation, p.after(chain.operation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func before(db *gorm.DB) {
+	db.InstanceSet(startKey, time.Now())
+}
+
+func (p GormPlugin) after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+
+		p.metrics.DBQueryDuration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			p.metrics.DBQueryErrors.WithLabelValues(operation, table).Inc()
+		}
+	}
+}
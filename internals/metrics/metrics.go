@@ -0,0 +1,148 @@
+// Package metrics defines the Prometheus metrics of the service and the /metrics handler
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "aadhaar"
+
+// Duplicate fields counted by DuplicateRejections
+const (
+	FieldEmail                = "email"
+	FieldAadhaarApplicationID = "aadhaar_application_id"
+	FieldApplicant            = "applicant"
+)
+
+// Metrics are the metrics of one instance of the service, on a registry of its own, so several
+// instances can run in one process. The methods of a nil *Metrics record nothing.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	HTTPRequests        *prometheus.CounterVec
+	HTTPDuration        *prometheus.HistogramVec
+	DBQueryDuration     *prometheus.HistogramVec
+	DBQueryErrors       *prometheus.CounterVec
+	UsersCreated        prometheus.Counter
+	DuplicateRejections *prometheus.CounterVec
+	AuditReadBatchSize  prometheus.Histogram
+}
+
+// New creates the metrics on a new registry, together with the Go runtime and process collectors
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		HTTPRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests by method, route template and status code.",
+		}, []string{"method", "route", "status"}),
+
+		HTTPDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by method, route template and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+
+		DBQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      "query_duration_seconds",
+			Help:      "GORM query latency by operation and table.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "table"}),
+
+		DBQueryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      "query_errors_total",
+			Help:      "GORM queries that failed, by operation and table. Record not found is not an error.",
+		}, []string{"operation", "table"}),
+
+		UsersCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "users",
+			Name:      "created_total",
+			Help:      "Users created.",
+		}),
+
+		DuplicateRejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "users",
+			Name:      "duplicate_rejections_total",
+			Help:      "Creates, updates and restores rejected because the email or Aadhaar Application ID is taken, or the applicant is likely enrolled already.",
+		}, []string{"field", "operation"}),
+
+		AuditReadBatchSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "audit",
+			Name:      "read_batch_size",
+			Help:      "Read, list and export audit events appended together in one transaction.",
+			Buckets:   []float64{1, 2, 5, 10, 25, 50, 100},
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.HTTPRequests,
+		m.HTTPDuration,
+		m.DBQueryDuration,
+		m.DBQueryErrors,
+		m.UsersCreated,
+		m.DuplicateRejections,
+		m.AuditReadBatchSize,
+	)
+	return m
+}
+
+// Registerer returns the registry the metrics are exposed from, for registering more collectors
+func (m *Metrics) Registerer() prometheus.Registerer {
+	return m.registry
+}
+
+// UserCreated counts a created user
+func (m *Metrics) UserCreated() {
+	if m != nil {
+		m.UsersCreated.Inc()
+	}
+}
+
+// DuplicateRejected counts a write rejected as a duplicate of field
+func (m *Metrics) DuplicateRejected(field, operation string) {
+	if m != nil {
+		m.DuplicateRejections.WithLabelValues(field, operation).Inc()
+	}
+}
+
+// AuditReadBatch records the size of a batch of read audit events appended together
+func (m *Metrics) AuditReadBatch(n int) {
+	if m != nil {
+		m.AuditReadBatchSize.Observe(float64(n))
+	}
+}
+
+// RegisterDBStats exposes the connection pool statistics of db as gauges and counters.
+// The returned function removes them again once the pool is closed.
+func (m *Metrics) RegisterDBStats(db *sql.DB, name string) (func(), error) {
+	c := collectors.NewDBStatsCollector(db, name)
+	if err := m.registry.Register(c); err != nil {
+		return nil, err
+	}
+	return func() { m.registry.Unregister(c) }, nil
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// unmatchedRoute labels requests that matched no route, so arbitrary paths don't create series
+const unmatchedRoute = "unmatched"
+
+// Middleware records the count and latency of every request, labelled by route template
+func (m *Metrics) Middleware(c *fiber.Ctx) error {
+	start := time.Now()
+	err := c.Next()
+
+	// The error handler sets the status only after the middleware chain returns
+	status := c.Response().StatusCode()
+	if err != nil {
+		status = fiber.StatusInternalServerError
+		var fe *fiber.Error
+		if errors.As(err, &fe) {
+			status = fe.Code
+		}
+	}
+
+	route := c.Route().Path
+	if status == fiber.StatusNotFound && route == "/" {
+		route = unmatchedRoute
+	}
+
+	labels := []string{c.Method(), route, strconv.Itoa(status)}
+	m.HTTPRequests.WithLabelValues(labels...).Inc()
+	m.HTTPDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+
+	return err
+}
@@ -0,0 +1,383 @@
+// Package migrator applies the numbered SQL migrations in order and records them in the
+// schema_migrations table, so every replica converges on the same schema.
+package migrator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// lockKey is the Postgres advisory lock held while migrating
+const lockKey = 7243000
+
+var (
+	ErrChecksumMismatch = errors.New("applied migration has been modified")
+	ErrNoDownMigration  = errors.New("migration has no down file")
+	ErrUnknownVersion   = errors.New("unknown migration version")
+	ErrDuplicateVersion = errors.New("duplicate migration version")
+)
+
+// fileName matches NNN_name.sql and NNN_name.down.sql
+var fileName = regexp.MustCompile(`^(\d+)_(.+?)(\.down)?\.sql$`)
+
+// Migration is a numbered SQL migration with its optional down script
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Status describes a migration and whether it has been applied
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	Modified  bool       `json:"modified,omitempty"`
+	Unknown   bool       `json:"unknown,omitempty"`
+}
+
+// record represents a row of the schema_migrations table
+type record struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	Checksum  string    `gorm:"size:64;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName specifies the table name for applied migrations
+func (record) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrator runs migrations against a database
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New loads the migrations in fsys
+func New(db *gorm.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Migrations returns the known migrations in version order
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Latest returns the highest known migration version, or 0 when there are none
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies all pending migrations and returns how many were applied
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.locked(ctx, func(db *gorm.DB) error {
+		records, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(records); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := records[migration.Version]; ok {
+				continue
+			}
+
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := execScript(tx, migration.Up); err != nil {
+					return err
+				}
+				return tx.Create(&record{
+					Version:   migration.Version,
+					Name:      migration.Name,
+					Checksum:  migration.Checksum,
+					AppliedAt: time.Now(),
+				}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("applying migration %03d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns how many were reverted
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	reverted := 0
+	err := m.locked(ctx, func(db *gorm.DB) error {
+		records, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(records); err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && reverted < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := records[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("%w: %03d_%s", ErrNoDownMigration, migration.Version, migration.Name)
+			}
+
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := execScript(tx, migration.Down); err != nil {
+					return err
+				}
+				return tx.Delete(&record{}, migration.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("reverting migration %03d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted++
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Baseline records every migration up to version as applied without running it,
+// for databases whose schema was created by hand
+func (m *Migrator) Baseline(ctx context.Context, version int) error {
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	return m.locked(ctx, func(db *gorm.DB) error {
+		records, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+
+		return db.Transaction(func(tx *gorm.DB) error {
+			for _, migration := range m.migrations {
+				if migration.Version > version {
+					break
+				}
+				if _, ok := records[migration.Version]; ok {
+					continue
+				}
+				if err := tx.Create(&record{
+					Version:   migration.Version,
+					Name:      migration.Name,
+					Checksum:  migration.Checksum,
+					AppliedAt: time.Now(),
+				}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// Status reports every known migration, plus applied versions missing from this build
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	records, err := m.applied(m.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if r, ok := records[migration.Version]; ok {
+			appliedAt := r.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+			status.Modified = r.Checksum != migration.Checksum
+		}
+		statuses = append(statuses, status)
+	}
+
+	for version, r := range records {
+		if m.find(version) != nil {
+			continue
+		}
+		appliedAt := r.AppliedAt
+		statuses = append(statuses, Status{
+			Version:   version,
+			Name:      r.Name,
+			Applied:   true,
+			AppliedAt: &appliedAt,
+			Unknown:   true,
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+// Version returns the highest applied migration version, or 0 when none has been applied
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	db := m.db.WithContext(ctx)
+	if !db.Migrator().HasTable(&record{}) {
+		return 0, nil
+	}
+
+	var version int
+	err := db.Model(&record{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// locked runs fn on a single connection holding the migration advisory lock,
+// so concurrent replicas migrate one at a time
+func (m *Migrator) locked(ctx context.Context, fn func(db *gorm.DB) error) error {
+	return m.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", lockKey).Error; err != nil {
+			return err
+		}
+		// Unlock even if ctx was cancelled, the connection goes back to the pool
+		defer conn.WithContext(context.Background()).Exec("SELECT pg_advisory_unlock(?)", lockKey)
+
+		if err := ensureTable(conn); err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+// applied returns the recorded migrations keyed by version
+func (m *Migrator) applied(db *gorm.DB) (map[int]record, error) {
+	if !db.Migrator().HasTable(&record{}) {
+		return map[int]record{}, nil
+	}
+
+	var records []record
+	if err := db.Order("version").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]record, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// verify rejects applied migrations whose file has changed since they ran
+func (m *Migrator) verify(records map[int]record) error {
+	for _, migration := range m.migrations {
+		if r, ok := records[migration.Version]; ok && r.Checksum != migration.Checksum {
+			return fmt.Errorf("%w: %03d_%s", ErrChecksumMismatch, migration.Version, migration.Name)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// execScript runs a multi-statement SQL script as is, bypassing GORM's placeholder handling
+func execScript(tx *gorm.DB, script string) error {
+	_, err := tx.Statement.ConnPool.ExecContext(tx.Statement.Context, script)
+	return err
+}
+
+func ensureTable(db *gorm.DB) error {
+	return db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`).Error
+}
+
+// load reads the migration files in fsys, pairing each up file with its down file
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, err
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version}
+			byVersion[version] = migration
+		}
+
+		if match[3] != "" {
+			if migration.Down != "" {
+				return nil, fmt.Errorf("%w: %s", ErrDuplicateVersion, entry.Name())
+			}
+			migration.Down = string(content)
+			continue
+		}
+
+		if migration.Up != "" {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateVersion, entry.Name())
+		}
+		sum := sha256.Sum256(content)
+		migration.Name = match[2]
+		migration.Up = string(content)
+		migration.Checksum = hex.EncodeToString(sum[:])
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("down migration %03d has no up file", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
@@ -0,0 +1,121 @@
+package migrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"aadhaar-user-service/migrations"
+)
+
+func file(content string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(content)}
+}
+
+func TestLoad(t *testing.T) {
+	m, err := New(nil, fstest.MapFS{
+		"010_add_index.sql":         file("CREATE INDEX i ON t(c);"),
+		"002_add_column.sql":        file("ALTER TABLE t ADD COLUMN c TEXT;"),
+		"002_add_column.down.sql":   file("ALTER TABLE t DROP COLUMN c;"),
+		"001_create_table.sql":      file("CREATE TABLE t (id INT);"),
+		"001_create_table.down.sql": file("DROP TABLE t;"),
+		"README.md":                 file("not a migration"),
+		"migrations.go":             file("package migrations"),
+	})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	got := m.Migrations()
+	if len(got) != 3 {
+		t.Fatalf("%d migrations, want 3", len(got))
+	}
+	for i, want := range []struct {
+		version  int
+		name     string
+		withDown bool
+	}{{1, "create_table", true}, {2, "add_column", true}, {10, "add_index", false}} {
+		if got[i].Version != want.version || got[i].Name != want.name || (got[i].Down != "") != want.withDown {
+			t.Errorf("migration %d = %d_%s (down %t), want %d_%s (down %t)",
+				i, got[i].Version, got[i].Name, got[i].Down != "", want.version, want.name, want.withDown)
+		}
+	}
+	if got[1].Up != "ALTER TABLE t ADD COLUMN c TEXT;" || got[1].Down != "ALTER TABLE t DROP COLUMN c;" {
+		t.Errorf("migration 2 = %q / %q, want its up and down scripts", got[1].Up, got[1].Down)
+	}
+	if m.Latest() != 10 {
+		t.Errorf("latest %d, want 10", m.Latest())
+	}
+}
+
+func TestChecksumCoversUpScript(t *testing.T) {
+	load := func(up, down string) string {
+		t.Helper()
+		m, err := New(nil, fstest.MapFS{"001_a.sql": file(up), "001_a.down.sql": file(down)})
+		if err != nil {
+			t.Fatalf("load: %v", err)
+		}
+		return m.Migrations()[0].Checksum
+	}
+
+	base := load("SELECT 1;", "SELECT 2;")
+	if len(base) != 64 {
+		t.Errorf("checksum %q, want a hex SHA-256", base)
+	}
+	if load("SELECT 1;", "SELECT 3;") != base {
+		t.Error("checksum changed with the down script")
+	}
+	if load("SELECT 1; ", "SELECT 2;") == base {
+		t.Error("checksum unchanged after editing the up script")
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		want error
+	}{
+		{"duplicate version", fstest.MapFS{"001_a.sql": file("SELECT 1;"), "001_b.sql": file("SELECT 2;")}, ErrDuplicateVersion},
+		{"duplicate down", fstest.MapFS{"001_a.sql": file("SELECT 1;"), "001_a.down.sql": file("SELECT 2;"), "001_b.down.sql": file("SELECT 3;")}, ErrDuplicateVersion},
+		{"down without up", fstest.MapFS{"001_a.down.sql": file("SELECT 1;")}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(nil, tt.fsys)
+			if err == nil {
+				t.Fatal("loaded, want an error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("error %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBaselineUnknownVersion(t *testing.T) {
+	m, err := New(nil, fstest.MapFS{"001_a.sql": file("SELECT 1;")})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if err := m.Baseline(context.Background(), 2); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("error %v, want %v", err, ErrUnknownVersion)
+	}
+}
+
+// TestShippedMigrations checks the embedded migrations: numbered without gaps, each revertible
+func TestShippedMigrations(t *testing.T) {
+	m, err := New(nil, migrations.FS)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	for i, migration := range m.Migrations() {
+		if migration.Version != i+1 {
+			t.Errorf("migration %03d_%s follows version %d", migration.Version, migration.Name, i)
+		}
+		if migration.Down == "" {
+			t.Errorf("migration %03d_%s has no down file", migration.Version, migration.Name)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Package names normalizes applicant names for matching. Names in Indic scripts are
+// transliterated to Latin letters, and each word gets a phonetic key that spellings of the same
+// name share, e.g. Mohammad, Mohammed and Muhammad, or Lakshmi, Laxmi and लक्ष्मी.
+package names
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalize returns the words of a name transliterated to Latin letters, without diacritics,
+// lower-cased and separated by single spaces. Anything that is not a letter or digit separates
+// words.
+func Normalize(name string) string {
+	return strings.Join(Words(name), " ")
+}
+
+// Words returns the words of a name as Normalize spells them
+func Words(name string) []string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(Transliterate(name)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Diacritics, e.g. the macron of ā
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteByte(' ')
+		}
+	}
+	return strings.Fields(b.String())
+}
+
+// Keys returns the distinct phonetic keys of the words of a name, in order
+func Keys(name string) []string {
+	var keys []string
+	for _, w := range Words(name) {
+		if k := PhoneticKey(w); k != "" && !slices.Contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// Spellings that sound alike in Indian names, longest first so ksh is not read as k and sh
+var sounds = strings.NewReplacer(
+	"ksh", "ks", "chh", "c", "ch", "c", "sh", "s", "zh", "l", "ph", "f", "bh", "b", "dh", "d",
+	"th", "t", "kh", "k", "gh", "g", "jh", "j", "ck", "k", "x", "ks", "q", "k", "w", "v", "z", "j",
+)
+
+// PhoneticKey returns the phonetic key of a single normalized word: aspirated consonants lose
+// their h, similar consonants merge, vowels after the first letter are dropped, a leading vowel
+// keeps only its class (a, i or u) and repeated letters collapse. Digits are ignored, so a word
+// without letters has no key.
+func PhoneticKey(word string) string {
+	word = sounds.Replace(strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r
+		}
+		return -1
+	}, word))
+
+	key := make([]byte, 0, len(word))
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		if i == 0 {
+			switch c {
+			case 'e':
+				c = 'i'
+			case 'o':
+				c = 'u'
+			}
+		} else if strings.IndexByte("aeiouy", c) >= 0 {
+			// After the first letter y is a vowel, as in Vijay
+			continue
+		}
+		if len(key) > 0 && key[len(key)-1] == c {
+			continue
+		}
+		key = append(key, c)
+	}
+	return string(key)
+}
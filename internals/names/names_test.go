@@ -0,0 +1,96 @@
+package names
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct {
+		script, text, want string
+	}{
+		{"Devanagari", "मोहम्मद", "mohammad"},
+		{"Devanagari conjunct", "लक्ष्मी", "lakshmi"},
+		{"Devanagari vocalic r", "कृष्ण", "krishna"},
+		{"Devanagari nukta", "ज़ोया", "zoya"},
+		{"Devanagari final schwa", "राम", "ram"},
+		{"Telugu final schwa", "రామ", "rama"},
+		{"Tamil", "லக்ஷ்மி", "lakshmi"},
+		{"Bengali", "সুব্রত", "subrat"},
+		{"Gurmukhi", "ਸਿੰਘ", "singh"},
+		{"Gujarati anusvara", "ગાંધી", "gandhi"},
+		{"Kannada", "ಕಾವ್ಯ", "kavya"},
+		{"Malayalam", "മുരളി", "murali"},
+		{"Latin is kept", "Asha Rao", "Asha Rao"},
+		{"mixed", "Asha राव", "Asha rav"},
+	}
+	for _, tt := range tests {
+		if got := Transliterate(tt.text); got != tt.want {
+			t.Errorf("%s: Transliterate(%q) = %q, want %q", tt.script, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct{ name, want string }{
+		{"Asha Rao", "asha rao"},
+		{"  ÁSHA   rāo ", "asha rao"},
+		{"D'Souza-Pinto", "d souza pinto"},
+		{"Flat 12B", "flat 12b"},
+		{"लक्ष्मी  Iyer", "lakshmi iyer"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.name); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPhoneticKey(t *testing.T) {
+	// Spellings of a name share a key
+	groups := [][]string{
+		{"mohammad", "mohammed", "muhammad"},
+		{"lakshmi", "laxmi"},
+		{"vijay", "vijai"},
+		{"khan", "kan"},
+		{"elango", "ilango"},
+		{"omkar", "umkar"},
+	}
+	for _, group := range groups {
+		key := PhoneticKey(group[0])
+		if key == "" {
+			t.Errorf("PhoneticKey(%q) is empty", group[0])
+		}
+		for _, word := range group[1:] {
+			if got := PhoneticKey(word); got != key {
+				t.Errorf("PhoneticKey(%q) = %q, want %q as for %q", word, got, key, group[0])
+			}
+		}
+	}
+
+	if PhoneticKey("asha") == PhoneticKey("usha") {
+		t.Error("asha and usha share a key, want leading vowels kept apart")
+	}
+	if got := PhoneticKey("123"); got != "" {
+		t.Errorf("PhoneticKey(123) = %q, want none", got)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"Mohammed Khan", []string{"mhmd", "kn"}},
+		{"मोहम्मद ख़ान", []string{"mhmd", "kn"}},
+		{"Lakshmi Laxmi", []string{"lksm"}},
+		{"Flat 12", []string{"flt"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := Keys(tt.name); !slices.Equal(got, tt.want) {
+			t.Errorf("Keys(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package names
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Brahmic scripts encoded in Unicode on the ISCII layout, where the same offset within each block
+// is the same letter: Devanagari, Bengali, Gurmukhi, Gujarati, Oriya, Tamil, Telugu, Kannada
+// and Malayalam
+const (
+	indicFirst = 0x0900
+	indicLast  = 0x0D7F
+	blockSize  = 0x80
+)
+
+// Scripts of languages that drop the inherent vowel at the end of a word, e.g. Hindi राम is
+// "ram", while Telugu రామ is "rama"
+var schwaDeleting = map[rune]bool{
+	0x0900: true, // Devanagari
+	0x0980: true, // Bengali
+	0x0A00: true, // Gurmukhi
+	0x0A80: true, // Gujarati
+}
+
+// Independent vowels, by offset within the block. Long and short vowels are spelled alike, as
+// names usually are, e.g. Ram and Lakshmi rather than Raam and Lakshmii.
+var vowels = map[rune]string{
+	0x05: "a", 0x06: "a", 0x07: "i", 0x08: "i", 0x09: "u", 0x0A: "u", 0x0B: "ri", 0x0C: "li",
+	0x0D: "e", 0x0E: "e", 0x0F: "e", 0x10: "ai", 0x11: "o", 0x12: "o", 0x13: "o", 0x14: "au",
+	0x60: "ri", 0x61: "li",
+}
+
+// Dependent vowel signs, which replace the inherent vowel of the consonant before them
+var vowelSigns = map[rune]string{
+	0x3E: "a", 0x3F: "i", 0x40: "i", 0x41: "u", 0x42: "u", 0x43: "ri", 0x44: "ri", 0x45: "e",
+	0x46: "e", 0x47: "e", 0x48: "ai", 0x49: "o", 0x4A: "o", 0x4B: "o", 0x4C: "au", 0x57: "au",
+	0x62: "li", 0x63: "li",
+}
+
+// Consonants, which carry the inherent vowel a until a vowel sign or virama follows
+var consonants = map[rune]string{
+	0x15: "k", 0x16: "kh", 0x17: "g", 0x18: "gh", 0x19: "n",
+	0x1A: "ch", 0x1B: "chh", 0x1C: "j", 0x1D: "jh", 0x1E: "n",
+	0x1F: "t", 0x20: "th", 0x21: "d", 0x22: "dh", 0x23: "n",
+	0x24: "t", 0x25: "th", 0x26: "d", 0x27: "dh", 0x28: "n", 0x29: "n",
+	0x2A: "p", 0x2B: "ph", 0x2C: "b", 0x2D: "bh", 0x2E: "m",
+	0x2F: "y", 0x30: "r", 0x31: "r", 0x32: "l", 0x33: "l", 0x34: "l", 0x35: "v",
+	0x36: "sh", 0x37: "sh", 0x38: "s", 0x39: "h",
+	// Precomposed nukta forms (Devanagari क़ ख़ ग़ ज़ ड़ ढ़ फ़ य़, Bengali ড় ঢ় য়)
+	0x58: "q", 0x59: "kh", 0x5A: "gh", 0x5B: "z", 0x5C: "r", 0x5D: "rh", 0x5E: "f", 0x5F: "y",
+}
+
+// Consonants changed by a following nukta
+var nukta = map[rune]string{
+	0x15: "q", 0x16: "kh", 0x17: "gh", 0x1C: "z", 0x21: "r", 0x22: "rh", 0x2B: "f", 0x2F: "y",
+}
+
+// Letters that end a syllable without a vowel: Bengali khanda ta and the Malayalam chillus
+var finals = map[rune]string{
+	0x4E: "t", 0x7A: "n", 0x7B: "n", 0x7C: "r", 0x7D: "l", 0x7E: "l", 0x7F: "k",
+}
+
+const (
+	candrabindu = 0x01
+	anusvara    = 0x02
+	visarga     = 0x03
+	nuktaSign   = 0x3C
+	virama      = 0x4D
+	tippi       = 0x70 // Gurmukhi nasal
+	addak       = 0x71 // Gurmukhi gemination
+)
+
+// Transliterate spells text in Indic scripts in Latin letters the way names are commonly
+// romanised, e.g. मोहम्मद becomes "mohammad" and லக்ஷ்மி becomes "lakshmi".
+// Other characters are kept as they are.
+func Transliterate(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+
+	// inherent is set while the last consonant still carries its inherent vowel
+	inherent, deleting := false, false
+	flush := func(wordEnd bool) {
+		if inherent && !(wordEnd && deleting) {
+			b.WriteByte('a')
+		}
+		inherent = false
+	}
+
+	// A word ending in a conjunct keeps its vowel, e.g. कृष्ण is "krishna"
+	conjunct := false
+
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+
+		block, offset, ok := indic(r)
+		if !ok {
+			flush(true)
+			b.WriteRune(r)
+			conjunct = false
+			continue
+		}
+
+		switch {
+		case consonants[offset] != "":
+			flush(false)
+			letter := consonants[offset]
+			if next, n := utf8.DecodeRuneInString(text[i:]); isSign(next, nuktaSign) {
+				if l, ok := nukta[offset]; ok {
+					letter = l
+				}
+				i += n
+			}
+			b.WriteString(letter)
+			inherent, deleting = true, schwaDeleting[block] && !conjunct
+
+		case vowelSigns[offset] != "":
+			inherent = false
+			b.WriteString(vowelSigns[offset])
+
+		case offset == virama:
+			inherent = false
+
+		case vowels[offset] != "":
+			flush(false)
+			b.WriteString(vowels[offset])
+
+		case offset == anusvara || offset == candrabindu || offset == tippi:
+			flush(false)
+			// Labial before p, b and m, dental elsewhere
+			nasal := "n"
+			if _, next, ok := indic(peek(text[i:])); ok {
+				switch consonants[next] {
+				case "p", "ph", "b", "bh", "m":
+					nasal = "m"
+				}
+			}
+			b.WriteString(nasal)
+
+		case offset == visarga:
+			flush(false)
+			b.WriteByte('h')
+
+		case finals[offset] != "":
+			flush(false)
+			b.WriteString(finals[offset])
+
+		case offset >= 0x66 && offset <= 0x6F:
+			flush(true)
+			b.WriteRune('0' + offset - 0x66)
+
+		case offset == addak:
+			// Doubles the next consonant, which phonetic keys collapse anyway
+
+		default:
+			// Avagraha, accents and punctuation such as the danda end a word
+			flush(true)
+		}
+		conjunct = offset == virama
+	}
+	flush(true)
+
+	return b.String()
+}
+
+// indic returns the block and offset of a letter in one of the Brahmic scripts
+func indic(r rune) (block, offset rune, ok bool) {
+	if r < indicFirst || r > indicLast {
+		return 0, 0, false
+	}
+	offset = (r - indicFirst) % blockSize
+	return r - offset, offset, true
+}
+
+// isSign reports whether r is the sign at offset in any of the Brahmic scripts
+func isSign(r, offset rune) bool {
+	_, o, ok := indic(r)
+	return ok && o == offset
+}
+
+// peek returns the first rune of s
+func peek(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
@@ -0,0 +1,15 @@
+package rbac
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Require returns a handler that rejects callers lacking the permission with 403
+func Require(perm Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !Allowed(c.UserContext(), perm) {
+			return fiber.NewError(fiber.StatusForbidden, "Forbidden: missing permission "+string(perm))
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,162 @@
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"aadhaar-user-service/internals/auth"
+	"aadhaar-user-service/internals/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Permission names an operation on applicant records
+type Permission string
+
+const (
+	UsersCreate      Permission = "users:create"
+	UsersRead        Permission = "users:read"
+	UsersList        Permission = "users:list"
+	UsersUpdate      Permission = "users:update"
+	UsersDelete      Permission = "users:delete"
+	UsersRestore     Permission = "users:restore"
+	UsersPurge       Permission = "users:purge"
+	UsersExport      Permission = "users:export"
+	UsersReadDeleted Permission = "users:read_deleted"
+	AuditRead        Permission = "audit:read"
+	AuditVerify      Permission = "audit:verify"
+
+	// All grants every permission
+	All Permission = "*"
+)
+
+// Roles
+const (
+	RoleOperator   = "operator"
+	RoleSupervisor = "supervisor"
+	RoleAuditor    = "auditor"
+	RoleAdmin      = "admin"
+)
+
+var permissions = []Permission{
+	UsersCreate, UsersRead, UsersList, UsersUpdate, UsersDelete,
+	UsersRestore, UsersPurge, UsersExport, UsersReadDeleted, AuditRead, AuditVerify, All,
+}
+
+// Policy maps roles to the permissions they grant
+type Policy struct {
+	Roles map[string][]Permission `json:"roles" yaml:"roles"`
+
+	// unrestricted grants everything to everyone, see Unrestricted
+	unrestricted bool
+}
+
+// DefaultPolicy returns the built-in role definitions
+func DefaultPolicy() *Policy {
+	return &Policy{
+		Roles: map[string][]Permission{
+			RoleOperator:   {UsersCreate, UsersRead, UsersList, UsersUpdate},
+			RoleSupervisor: {UsersCreate, UsersRead, UsersList, UsersUpdate, UsersDelete, UsersRestore, UsersExport},
+			RoleAuditor:    {UsersRead, UsersList, UsersExport, UsersReadDeleted, AuditRead, AuditVerify},
+			RoleAdmin:      {All},
+		},
+	}
+}
+
+// Unrestricted returns a policy granting every permission to every caller, in effect while
+// authentication is disabled
+func Unrestricted() *Policy {
+	return &Policy{unrestricted: true}
+}
+
+// New loads the policy from cfg.PolicyFile (.yaml, .yml or .json), or returns the default without one
+func New(cfg config.RBAC) (*Policy, error) {
+	if cfg.PolicyFile == "" {
+		return DefaultPolicy(), nil
+	}
+	return Load(cfg.PolicyFile)
+}
+
+type policyKey struct{}
+
+// WithPolicy returns a context carrying the policy Allowed checks callers against
+func WithPolicy(ctx context.Context, p *Policy) context.Context {
+	return context.WithValue(ctx, policyKey{}, p)
+}
+
+// Load reads and validates a policy file
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read RBAC policy: %w", err)
+	}
+
+	p := &Policy{}
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, p)
+	default:
+		err = json.Unmarshal(data, p)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse RBAC policy: %w", err)
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate checks that the policy only grants known permissions
+func (p *Policy) Validate() error {
+	if len(p.Roles) == 0 {
+		return fmt.Errorf("RBAC policy defines no roles")
+	}
+	for role, perms := range p.Roles {
+		for _, perm := range perms {
+			if !known(perm) {
+				return fmt.Errorf("RBAC policy role %s: unknown permission %q", role, perm)
+			}
+		}
+	}
+	return nil
+}
+
+// Allowed reports whether any of the roles grants the permission
+func (p *Policy) Allowed(roles []string, perm Permission) bool {
+	if p.unrestricted {
+		return true
+	}
+	for _, role := range roles {
+		for _, granted := range p.Roles[role] {
+			if granted == perm || granted == All {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Allowed reports whether the caller in ctx holds the permission under the policy in ctx.
+// Nothing is allowed without a policy.
+func Allowed(ctx context.Context, perm Permission) bool {
+	p, _ := ctx.Value(policyKey{}).(*Policy)
+	if p == nil {
+		return false
+	}
+	return p.Allowed(auth.RolesFromContext(ctx), perm)
+}
+
+// known reports whether perm is a defined permission
+func known(perm Permission) bool {
+	for _, p := range permissions {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
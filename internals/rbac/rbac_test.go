@@ -0,0 +1,129 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"aadhaar-user-service/internals/auth"
+	"aadhaar-user-service/internals/config"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestDefaultPolicy(t *testing.T) {
+	p := DefaultPolicy()
+	tests := []struct {
+		roles []string
+		perm  Permission
+		want  bool
+	}{
+		{[]string{RoleOperator}, UsersCreate, true},
+		{[]string{RoleOperator}, UsersDelete, false},
+		{[]string{RoleOperator}, UsersExport, false},
+		{[]string{RoleSupervisor}, UsersExport, true},
+		{[]string{RoleSupervisor}, UsersPurge, false},
+		{[]string{RoleAuditor}, AuditVerify, true},
+		{[]string{RoleAuditor}, UsersCreate, false},
+		{[]string{RoleAdmin}, UsersPurge, true},
+		{[]string{RoleOperator, RoleAuditor}, UsersReadDeleted, true},
+		{[]string{"unknown"}, UsersRead, false},
+		{nil, UsersRead, false},
+	}
+	for _, tt := range tests {
+		if got := p.Allowed(tt.roles, tt.perm); got != tt.want {
+			t.Errorf("Allowed(%v, %s) = %t, want %t", tt.roles, tt.perm, got, tt.want)
+		}
+	}
+
+	if !Unrestricted().Allowed(nil, UsersPurge) {
+		t.Error("unrestricted policy refused an anonymous caller")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	yamlPolicy := write("rbac.yaml", "roles:\n  clerk: [users:read, users:list]\n")
+	p, err := New(config.RBAC{PolicyFile: yamlPolicy})
+	if err != nil {
+		t.Fatalf("load YAML: %v", err)
+	}
+	if !p.Allowed([]string{"clerk"}, UsersList) || p.Allowed([]string{"clerk"}, UsersCreate) || p.Allowed([]string{RoleAdmin}, UsersRead) {
+		t.Errorf("YAML policy %+v, want only clerk with read and list", p.Roles)
+	}
+
+	jsonPolicy := write("rbac.json", `{"roles": {"root": ["*"]}}`)
+	if p, err = Load(jsonPolicy); err != nil || !p.Allowed([]string{"root"}, AuditVerify) {
+		t.Errorf("JSON policy = %+v, %v, want root with every permission", p, err)
+	}
+
+	if p, err = New(config.RBAC{}); err != nil || !p.Allowed([]string{RoleAuditor}, AuditRead) {
+		t.Errorf("no policy file = %+v, %v, want the default policy", p, err)
+	}
+
+	for name, content := range map[string]string{
+		"unknown.yaml": "roles:\n  clerk: [users:fly]\n",
+		"broken.json":  `{"roles": `,
+		"empty.yaml":   "roles: {}\n",
+	} {
+		if _, err := Load(write(name, content)); err == nil {
+			t.Errorf("%s: loaded, want an error", name)
+		}
+	}
+	if _, err := Load(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("missing file: loaded, want an error")
+	}
+}
+
+func TestAllowedFromContext(t *testing.T) {
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "asha", Roles: []string{RoleOperator}})
+	if Allowed(ctx, UsersRead) {
+		t.Error("allowed without a policy in the context")
+	}
+
+	ctx = WithPolicy(ctx, DefaultPolicy())
+	if !Allowed(ctx, UsersRead) || Allowed(ctx, UsersDelete) {
+		t.Error("operator permissions not applied from the context")
+	}
+}
+
+func TestRequire(t *testing.T) {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		ctx := WithPolicy(c.UserContext(), DefaultPolicy())
+		if role := c.Get("X-Role"); role != "" {
+			ctx = auth.WithPrincipal(ctx, &auth.Principal{Subject: role, Roles: []string{role}})
+		}
+		c.SetUserContext(ctx)
+		return c.Next()
+	})
+	app.Get("/export", Require(UsersExport), func(c *fiber.Ctx) error { return c.SendStatus(http.StatusOK) })
+
+	for role, want := range map[string]int{
+		RoleAuditor:  http.StatusOK,
+		RoleOperator: http.StatusForbidden,
+		"":           http.StatusForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/export", nil)
+		req.Header.Set("X-Role", role)
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("role %q: status %d, want %d", role, resp.StatusCode, want)
+		}
+	}
+}
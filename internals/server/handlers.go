@@ -1,7 +1,11 @@
 package server
 
 import (
+	"aadhaar-user-service/controllers/audit"
+	"aadhaar-user-service/controllers/users"
+	"aadhaar-user-service/internals/config"
 	"aadhaar-user-service/routes"
+	auditService "aadhaar-user-service/services/audit"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -32,16 +36,21 @@ var notFoundHandler = func(ctx *fiber.Ctx) error {
 }
 
 // addRoutes registers all routes
-func addRoutes(app *fiber.App) {
-	// Health check endpoint
-	app.Get("/health", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"status":  "healthy",
-			"service": "aadhaar-user-service",
-		})
-	})
+func addRoutes(app *fiber.App, cfg config.Config, deps Dependencies) {
+	// Health check endpoints, public; /health is kept as an alias of the liveness probe
+	h := &health{db: deps.DB, timeout: cfg.Health.Timeout}
+	app.Get("/health", h.live)
+	app.Get("/health/live", h.live)
+	app.Get("/health/ready", h.ready)
+
+	// Prometheus metrics, public like the probes
+	if cfg.Metrics.Enabled {
+		app.Get(cfg.Metrics.Path, deps.Metrics.Handler())
+	}
 
-	// API routes
-	baseRouter := app.Group("/aadhaar")
-	routes.Users(baseRouter)
+	// API routes, all require authentication
+	baseRouter := app.Group("/aadhaar", authenticate(deps))
+	recorder := auditService.NewRecorder(deps.Audit, deps.Keys, deps.Metrics)
+	routes.Users(baseRouter, users.NewHandler(deps.Users, recorder, deps.Validator, deps.Cursors, deps.Dedup, deps.Metrics))
+	routes.Audit(baseRouter, audit.NewHandler(deps.Audit, deps.Validator))
 }
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"aadhaar-user-service/internals/migrator"
+	"aadhaar-user-service/internals/version"
+	"aadhaar-user-service/migrations"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const serviceName = "aadhaar-user-service"
+
+// Check statuses
+const (
+	checkUp   = "up"
+	checkDown = "down"
+)
+
+var errPendingMigrations = errors.New("schema is behind the expected migration version")
+
+// HealthResponse is the body of the health endpoints
+type HealthResponse struct {
+	Status  string                 `json:"status"`
+	Service string                 `json:"service"`
+	Build   version.Info           `json:"build"`
+	Checks  map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult is the outcome of a single readiness check
+type CheckResult struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+	Details   any    `json:"details,omitempty"`
+}
+
+// PoolStats reports the database connection pool
+type PoolStats struct {
+	MaxOpen           int   `json:"max_open"`
+	Open              int   `json:"open"`
+	InUse             int   `json:"in_use"`
+	Idle              int   `json:"idle"`
+	WaitCount         int64 `json:"wait_count"`
+	WaitDurationMs    int64 `json:"wait_duration_ms"`
+	MaxIdleClosed     int64 `json:"max_idle_closed"`
+	MaxIdleTimeClosed int64 `json:"max_idle_time_closed"`
+	MaxLifetimeClosed int64 `json:"max_lifetime_closed"`
+}
+
+// MigrationStatus reports the applied schema version against the one this build expects
+type MigrationStatus struct {
+	Current  int `json:"current"`
+	Expected int `json:"expected"`
+}
+
+// health serves the liveness and readiness probes
+type health struct {
+	db      *gorm.DB
+	timeout time.Duration
+}
+
+// live reports that the process is up and serving; it never touches dependencies
+func (h *health) live(c *fiber.Ctx) error {
+	return c.JSON(HealthResponse{
+		Status:  "healthy",
+		Service: serviceName,
+		Build:   version.Get(),
+	})
+}
+
+// ready reports whether the service can handle traffic, with a breakdown per dependency
+func (h *health) ready(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), h.timeout)
+	defer cancel()
+
+	// The memory driver has no dependencies to check
+	checks := map[string]CheckResult{}
+	if h.db != nil {
+		checks["database"] = timed(func() (any, error) { return h.database(ctx) })
+		checks["migrations"] = timed(func() (any, error) { return h.migrations(ctx) })
+	}
+
+	status, code := "ready", fiber.StatusOK
+	for _, check := range checks {
+		if check.Status != checkUp {
+			status, code = "not_ready", fiber.StatusServiceUnavailable
+		}
+	}
+
+	return c.Status(code).JSON(HealthResponse{
+		Status:  status,
+		Service: serviceName,
+		Build:   version.Get(),
+		Checks:  checks,
+	})
+}
+
+// database pings Postgres and reports the pool statistics
+func (h *health) database(ctx context.Context) (any, error) {
+	sql, err := h.db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	s := sql.Stats()
+	stats := PoolStats{
+		MaxOpen:           s.MaxOpenConnections,
+		Open:              s.OpenConnections,
+		InUse:             s.InUse,
+		Idle:              s.Idle,
+		WaitCount:         s.WaitCount,
+		WaitDurationMs:    s.WaitDuration.Milliseconds(),
+		MaxIdleClosed:     s.MaxIdleClosed,
+		MaxIdleTimeClosed: s.MaxIdleTimeClosed,
+		MaxLifetimeClosed: s.MaxLifetimeClosed,
+	}
+
+	return stats, sql.PingContext(ctx)
+}
+
+// migrations checks that the schema is at least at the version this build was shipped with
+func (h *health) migrations(ctx context.Context) (any, error) {
+	m, err := migrator.New(h.db, migrations.FS)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := m.Version(ctx)
+	status := MigrationStatus{Current: current, Expected: m.Latest()}
+	if err != nil {
+		return status, err
+	}
+	if current < status.Expected {
+		return status, errPendingMigrations
+	}
+	return status, nil
+}
+
+// timed runs a check and records its outcome and latency
+func timed(check func() (any, error)) CheckResult {
+	start := time.Now()
+	details, err := check()
+
+	result := CheckResult{
+		Status:    checkUp,
+		LatencyMs: time.Since(start).Milliseconds(),
+		Details:   details,
+	}
+	if err != nil {
+		result.Status = checkDown
+		result.Error = err.Error()
+	}
+	return result
+}
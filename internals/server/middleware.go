@@ -1,23 +1,75 @@
 package server
 
 import (
+	"strings"
+
+	"aadhaar-user-service/internals/auth"
+	"aadhaar-user-service/internals/config"
+	"aadhaar-user-service/internals/logging"
+	"aadhaar-user-service/internals/masking"
+	"aadhaar-user-service/internals/rbac"
+	"aadhaar-user-service/internals/tracing"
+	"aadhaar-user-service/services/audit"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 )
 
 // middlewares sets up application middleware
-func middlewares(app *fiber.App) {
-	// Request logging
-	app.Use(logger.New(logger.Config{
-		Format:     "${time} | ${status} | ${latency} | ${method} | ${path}\n",
-		TimeFormat: "2006-01-02 15:04:05",
-	}))
+func middlewares(app *fiber.App, cfg config.Config, deps Dependencies) {
+	// Request metrics, first so they cover every other middleware
+	if cfg.Metrics.Enabled {
+		app.Use(deps.Metrics.Middleware)
+	}
+
+	// Request tracing, continuing the caller's trace from traceparent
+	app.Use(tracing.Middleware)
+
+	// Request ID and request-scoped logger, then one access log line per request
+	app.Use(logging.RequestID)
+	app.Use(logging.AccessLog)
+
+	// Request metadata for the audit trail
+	app.Use(requestMeta)
 
 	// CORS configuration
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders: "Origin,Content-Type,Accept,Authorization",
+		AllowOrigins:     strings.Join(cfg.CORS.AllowOrigins, ","),
+		AllowMethods:     strings.Join(cfg.CORS.AllowMethods, ","),
+		AllowHeaders:     strings.Join(cfg.CORS.AllowHeaders, ","),
+		ExposeHeaders:    fiber.HeaderXRequestID,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		MaxAge:           cfg.CORS.MaxAge,
 	}))
 }
+
+// authenticate identifies the caller by JWT or API key and stores it in the request context,
+// together with the RBAC and masking policies its permissions and view of PII are decided by
+func authenticate(deps Dependencies) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := masking.WithPolicy(c.UserContext(), deps.Masking)
+
+		if deps.Auth.Disabled() {
+			c.SetUserContext(rbac.WithPolicy(ctx, rbac.Unrestricted()))
+			return c.Next()
+		}
+
+		p, err := deps.Auth.Authenticate(c.Get(fiber.HeaderAuthorization), c.Get("X-API-Key"))
+		if err != nil {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="aadhaar-user-service"`)
+			return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+				Error: "Unauthorized: " + err.Error(),
+			})
+		}
+
+		c.SetUserContext(rbac.WithPolicy(auth.WithPrincipal(ctx, p), deps.RBAC))
+		return c.Next()
+	}
+}
+
+// requestMeta stores the request ID and client IP in the request context for the audit trail
+func requestMeta(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	c.SetUserContext(audit.WithRequest(ctx, logging.RequestIDFromContext(ctx), c.IP()))
+	return c.Next()
+}
@@ -1,31 +1,68 @@
 package server
 
 import (
+	"aadhaar-user-service/internals/auth"
+	"aadhaar-user-service/internals/config"
+	"aadhaar-user-service/internals/cursor"
+	"aadhaar-user-service/internals/dedup"
+	"aadhaar-user-service/internals/encryption"
+	"aadhaar-user-service/internals/masking"
+	"aadhaar-user-service/internals/metrics"
+	"aadhaar-user-service/internals/rbac"
+	"aadhaar-user-service/internals/validator"
+	"aadhaar-user-service/models/audit"
+	"aadhaar-user-service/models/users"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"gorm.io/gorm"
 )
 
-var app *fiber.App
+// Dependencies are what the routes are served from
+type Dependencies struct {
+	// DB is checked by the readiness probe, nil with the memory driver
+	DB        *gorm.DB
+	Users     users.UserRepository
+	Audit     audit.EventRepository
+	Validator *validator.Validator
+	Cursors   *cursor.Codec
 
-func New() *fiber.App {
-	return app
+	// Dedup is the duplicate detection policy of enrolments
+	Dedup dedup.Policy
+
+	// Keys encrypt PII and key the audit trail's value hashes, nil while encryption is disabled
+	Keys *encryption.Keyring
+
+	// Auth identifies callers, RBAC decides what they may do and Masking what PII they see
+	Auth    *auth.Authenticator
+	RBAC    *rbac.Policy
+	Masking *masking.Policy
+
+	// Metrics are recorded for every request and exposed on the metrics path
+	Metrics *metrics.Metrics
 }
 
-func Setup() {
-	app = fiber.New(fiber.Config{
+// New builds the Fiber app with its middleware and routes
+func New(cfg config.Config, deps Dependencies) *fiber.App {
+	app := fiber.New(fiber.Config{
 		ErrorHandler: errHandler,
-		BodyLimit:    16 * 1024 * 1024, // 16MB
+		BodyLimit:    cfg.Server.BodyLimit,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	})
 
 	// Add recovery middleware first
 	app.Use(recover.New())
 
 	// Add other middleware
-	middlewares(app)
+	middlewares(app, cfg, deps)
 
 	// Add routes
-	addRoutes(app)
+	addRoutes(app, cfg, deps)
 
 	// 404 handler
 	app.Use(notFoundHandler)
+
+	return app
 }
@@ -0,0 +1,79 @@
+package validator
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+// Verhoeff multiplication table d(j, k) of the dihedral group D5
+var verhoeffD = [10][10]int{
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+	{1, 2, 3, 4, 0, 6, 7, 8, 9, 5},
+	{2, 3, 4, 0, 1, 7, 8, 9, 5, 6},
+	{3, 4, 0, 1, 2, 8, 9, 5, 6, 7},
+	{4, 0, 1, 2, 3, 9, 5, 6, 7, 8},
+	{5, 9, 8, 7, 6, 0, 4, 3, 2, 1},
+	{6, 5, 9, 8, 7, 1, 0, 4, 3, 2},
+	{7, 6, 5, 9, 8, 2, 1, 0, 4, 3},
+	{8, 7, 6, 5, 9, 3, 2, 1, 0, 4},
+	{9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+}
+
+// Verhoeff permutation table p(pos mod 8, digit)
+var verhoeffP = [8][10]int{
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+	{1, 5, 7, 6, 2, 8, 3, 0, 9, 4},
+	{5, 8, 0, 3, 7, 9, 6, 1, 4, 2},
+	{8, 9, 1, 6, 0, 4, 3, 5, 2, 7},
+	{9, 4, 5, 3, 1, 2, 7, 6, 8, 0},
+	{4, 2, 8, 6, 5, 7, 3, 9, 0, 1},
+	{2, 7, 9, 3, 8, 0, 6, 4, 1, 5},
+	{7, 0, 4, 6, 9, 1, 5, 8, 3, 2},
+}
+
+// VerhoeffValid reports whether a digit string carries a valid trailing Verhoeff check digit
+func VerhoeffValid(digits string) bool {
+	if !isDigits(digits) {
+		return false
+	}
+
+	c := 0
+	for i := 0; i < len(digits); i++ {
+		digit := int(digits[len(digits)-1-i] - '0')
+		c = verhoeffD[c][verhoeffP[i%8][digit]]
+	}
+	return c == 0
+}
+
+// isDigits reports whether s is non-empty and contains only ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// validateAadhaarNumber checks a 12-digit Aadhaar number: no leading 0 or 1, valid Verhoeff checksum
+func validateAadhaarNumber(fl validator.FieldLevel) bool {
+	value := fl.Field().String()
+	if len(value) != 12 || !isDigits(value) {
+		return false
+	}
+	if value[0] == '0' || value[0] == '1' {
+		return false
+	}
+	return VerhoeffValid(value)
+}
+
+// validateAadhaarApplicationID checks a 14-digit enrolment/application ID with no leading 0 or 1
+func validateAadhaarApplicationID(fl validator.FieldLevel) bool {
+	value := fl.Field().String()
+	if len(value) != 14 || !isDigits(value) {
+		return false
+	}
+	return value[0] != '0' && value[0] != '1'
+}
@@ -0,0 +1,96 @@
+package validator
+
+import "testing"
+
+func TestAadhaarApplicationID(t *testing.T) {
+	type enrolment struct {
+		ID string `validate:"aadhaar_application_id"`
+	}
+
+	tests := []struct {
+		name  string
+		id    string
+		valid bool
+	}{
+		{"valid", "20000000000001", true},
+		{"valid with nines", "99999999999999", true},
+		{"leading zero", "01234567890123", false},
+		{"leading one", "12345678901234", false},
+		{"too short", "2000000000001", false},
+		{"too long", "200000000000011", false},
+		{"letters", "2000000000000A", false},
+		{"space", "2000000 000001", false},
+		{"empty", "", false},
+	}
+
+	v := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := v.Payload(enrolment{ID: tt.id})
+			if valid := len(errs) == 0; valid != tt.valid {
+				t.Fatalf("%q valid = %v, want %v (%v)", tt.id, valid, tt.valid, errs)
+			}
+			if !tt.valid && errs[0].Message != "ID must be 14 digits and must not start with 0 or 1" {
+				t.Errorf("message %q", errs[0].Message)
+			}
+		})
+	}
+}
+
+func TestVerhoeffValid(t *testing.T) {
+	tests := []struct {
+		digits string
+		valid  bool
+	}{
+		{"2363", true},
+		{"2365", false},
+		{"234123412346", true},
+		{"234123412347", false},
+		{"234123412364", false}, // adjacent digits swapped
+		{"", false},
+		{"23a3", false},
+	}
+
+	for _, tt := range tests {
+		if valid := VerhoeffValid(tt.digits); valid != tt.valid {
+			t.Errorf("VerhoeffValid(%q) = %v, want %v", tt.digits, valid, tt.valid)
+		}
+	}
+}
+
+func TestAadhaarNumber(t *testing.T) {
+	type resident struct {
+		Number string `validate:"aadhaar_number"`
+	}
+
+	tests := []struct {
+		name   string
+		number string
+		valid  bool
+	}{
+		{"valid", "234123412346", true},
+		{"valid with nines", "999941057058", true},
+		{"bad check digit", "234123412347", false},
+		{"transposed digits", "234123412364", false},
+		{"leading zero with valid checksum", "034123412341", false},
+		{"leading one with valid checksum", "123412341234", false},
+		{"too short", "23412341234", false},
+		{"too long", "2341234123460", false},
+		{"application ID length", "20000000000001", false},
+		{"letters", "23412341234A", false},
+		{"empty", "", false},
+	}
+
+	v := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := v.Payload(resident{Number: tt.number})
+			if valid := len(errs) == 0; valid != tt.valid {
+				t.Fatalf("%q valid = %v, want %v (%v)", tt.number, valid, tt.valid, errs)
+			}
+			if !tt.valid && errs[0].Message != "Number must be a valid 12-digit Aadhaar number" {
+				t.Errorf("message %q", errs[0].Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package validator
+
+import (
+	"aadhaar-user-service/internals/dto"
+	"aadhaar-user-service/internals/geo"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validateIndianState checks that the value names an Indian state or union territory
+func validateIndianState(fl validator.FieldLevel) bool {
+	_, ok := geo.LookupState(fl.Field().String())
+	return ok
+}
+
+// validatePinCode checks the 6-digit PIN code format
+func validatePinCode(fl validator.FieldLevel) bool {
+	return geo.ValidPinCode(fl.Field().String())
+}
+
+// validateAddress checks that the district and PIN code belong to the given state
+func validateAddress(sl validator.StructLevel) {
+	address := sl.Current().Interface().(dto.Address)
+
+	// Format errors are reported by the field validators
+	if _, ok := geo.LookupState(address.State); !ok {
+		return
+	}
+
+	if address.District != "" {
+		if _, ok := geo.LookupDistrict(address.District, address.State); !ok {
+			sl.ReportError(address.District, "District", "District", "district_state", address.State)
+		}
+	}
+
+	if geo.ValidPinCode(address.PinCode) && !geo.PinMatchesState(address.PinCode, address.State) {
+		sl.ReportError(address.PinCode, "PinCode", "PinCode", "pincode_state", address.State)
+	}
+}
@@ -0,0 +1,53 @@
+package validator
+
+import (
+	"testing"
+
+	"aadhaar-user-service/internals/dto"
+)
+
+func TestAddress(t *testing.T) {
+	valid := dto.Address{House: "12", VillageTown: "Bengaluru", District: "Bengaluru Urban", State: "Karnataka", PinCode: "560001"}
+
+	tests := []struct {
+		name    string
+		modify  func(a *dto.Address)
+		message string
+	}{
+		{"valid", func(a *dto.Address) {}, ""},
+		{"state code", func(a *dto.Address) { a.State = "ka" }, ""},
+		{"district in other case", func(a *dto.Address) { a.District = "bengaluru  urban" }, ""},
+		{"former district name", func(a *dto.Address) { a.District = "Bangalore Urban" }, ""},
+		{"hyphenated district", func(a *dto.Address) {
+			a.District, a.State, a.PinCode = "Medchal Malkajgiri", "Telangana", "500001"
+		}, ""},
+		{"district with initials", func(a *dto.Address) {
+			a.District, a.State, a.PinCode = "Dr BR Ambedkar Konaseema", "Andhra Pradesh", "533201"
+		}, ""},
+		{"district of another state", func(a *dto.Address) { a.District = "Mumbai City" }, "District is not a district of Karnataka"},
+		{"unknown district", func(a *dto.Address) { a.District = "Gotham" }, "District is not a district of Karnataka"},
+		{"missing district", func(a *dto.Address) { a.District = "" }, "District is required"},
+		{"PIN of another state", func(a *dto.Address) { a.PinCode = "400001" }, "PinCode does not belong to Karnataka"},
+		{"malformed PIN", func(a *dto.Address) { a.PinCode = "056001" }, "PinCode must be a 6-digit PIN code not starting with 0"},
+		{"unknown state", func(a *dto.Address) { a.State = "Atlantis" }, "State must be an Indian state or union territory"},
+	}
+
+	v := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address := valid
+			tt.modify(&address)
+
+			errs := v.Payload(address)
+			if tt.message == "" {
+				if len(errs) != 0 {
+					t.Fatalf("errors %v, want none", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Message != tt.message {
+				t.Errorf("errors %v, want only %q", errs, tt.message)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package validator
+
+import (
+	"time"
+
+	"aadhaar-user-service/internals/dto"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// MaxAgeYears is the oldest plausible age for an applicant
+const MaxAgeYears = 125
+
+// validateDateOfBirth checks that a date of birth parses, is not in the future and is not implausibly old
+func validateDateOfBirth(fl validator.FieldLevel) bool {
+	dob, err := dto.ParseDate(fl.Field().String())
+	if err != nil {
+		return false
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if dob.After(today) {
+		return false
+	}
+	return !dob.Before(today.AddDate(-MaxAgeYears, 0, 0))
+}
@@ -0,0 +1,40 @@
+package validator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDateOfBirth(t *testing.T) {
+	type applicant struct {
+		DateOfBirth string `validate:"date_of_birth"`
+	}
+
+	today := time.Now().UTC()
+	tests := []struct {
+		name  string
+		date  string
+		valid bool
+	}{
+		{"YYYY-MM-DD", "1990-06-15", true},
+		{"DD-MM-YYYY", "15-06-1990", true},
+		{"leap day", "2000-02-29", true},
+		{"today", today.Format("2006-01-02"), true},
+		{"tomorrow", today.AddDate(0, 0, 1).Format("2006-01-02"), false},
+		{"oldest plausible", today.AddDate(-MaxAgeYears, 0, 0).Format("2006-01-02"), true},
+		{"too old", today.AddDate(-MaxAgeYears, 0, -1).Format("2006-01-02"), false},
+		{"no such leap day", "1990-02-29", false},
+		{"month out of range", "1990-13-01", false},
+		{"slashes", "1990/06/15", false},
+		{"empty", "", false},
+	}
+
+	v := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if valid := len(v.Payload(applicant{DateOfBirth: tt.date})) == 0; valid != tt.valid {
+				t.Errorf("%q valid = %v, want %v", tt.date, valid, tt.valid)
+			}
+		})
+	}
+}
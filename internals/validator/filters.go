@@ -0,0 +1,38 @@
+package validator
+
+import (
+	"aadhaar-user-service/internals/dto"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validateDate checks that a calendar date parses in an accepted format
+func validateDate(fl validator.FieldLevel) bool {
+	_, err := dto.ParseDate(fl.Field().String())
+	return err == nil
+}
+
+// validateUserFilters checks that no range of the user list filters ends before it starts
+func validateUserFilters(sl validator.StructLevel) {
+	f := sl.Current().Interface().(dto.UserFilters)
+
+	if f.CreatedFrom != nil && f.CreatedTo != nil && f.CreatedTo.Before(*f.CreatedFrom) {
+		sl.ReportError(f.CreatedTo, "CreatedTo", "CreatedTo", "range", "CreatedFrom")
+	}
+	if f.UpdatedFrom != nil && f.UpdatedTo != nil && f.UpdatedTo.Before(*f.UpdatedFrom) {
+		sl.ReportError(f.UpdatedTo, "UpdatedTo", "UpdatedTo", "range", "UpdatedFrom")
+	}
+
+	// Format errors are reported by the field validators
+	from, err := dto.ParseDate(f.DateOfBirthFrom)
+	if err != nil {
+		return
+	}
+	to, err := dto.ParseDate(f.DateOfBirthTo)
+	if err != nil {
+		return
+	}
+	if to.Before(from) {
+		sl.ReportError(f.DateOfBirthTo, "DateOfBirthTo", "DateOfBirthTo", "range", "DateOfBirthFrom")
+	}
+}
@@ -2,6 +2,7 @@ package validator
 
 import (
 	"fmt"
+	"reflect"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -13,10 +14,10 @@ type ValidationError struct {
 }
 
 // Payload validates a struct and returns validation errors
-func Payload(s interface{}) []ValidationError {
+func (v *Validator) Payload(s interface{}) []ValidationError {
 	var errors []ValidationError
 
-	err := _validator.Struct(s)
+	err := v.validate.Struct(s)
 	if err != nil {
 		for _, e := range err.(validator.ValidationErrors) {
 			var msg string
@@ -28,13 +29,43 @@ func Payload(s interface{}) []ValidationError {
 			case "min":
 				msg = fmt.Sprintf("%s must be at least %s characters", e.Field(), e.Param())
 			case "max":
-				msg = fmt.Sprintf("%s must be at most %s characters", e.Field(), e.Param())
+				if e.Kind() == reflect.Slice {
+					msg = fmt.Sprintf("%s must have at most %s items", e.Field(), e.Param())
+				} else {
+					msg = fmt.Sprintf("%s must be at most %s characters", e.Field(), e.Param())
+				}
 			case "len":
 				msg = fmt.Sprintf("%s must be exactly %s characters", e.Field(), e.Param())
+			case "gt":
+				msg = fmt.Sprintf("%s must be greater than %s", e.Field(), e.Param())
+			case "lte":
+				msg = fmt.Sprintf("%s must be at most %s", e.Field(), e.Param())
 			case "oneof":
 				msg = fmt.Sprintf("%s must be one of: %s", e.Field(), e.Param())
 			case "numeric":
 				msg = fmt.Sprintf("%s must contain only numbers", e.Field())
+			case "aadhaar_application_id":
+				msg = fmt.Sprintf("%s must be 14 digits and must not start with 0 or 1", e.Field())
+			case "aadhaar_number":
+				msg = fmt.Sprintf("%s must be a valid 12-digit Aadhaar number", e.Field())
+			case "date":
+				msg = fmt.Sprintf("%s must be a date in YYYY-MM-DD or DD-MM-YYYY format", e.Field())
+			case "hostname_rfc1123":
+				msg = fmt.Sprintf("%s must be a domain name", e.Field())
+			case "range":
+				msg = fmt.Sprintf("%s must not be before %s", e.Field(), e.Param())
+			case "date_of_birth":
+				msg = fmt.Sprintf("%s must be a past date in YYYY-MM-DD or DD-MM-YYYY format, at most %d years ago", e.Field(), MaxAgeYears)
+			case "required_without":
+				msg = fmt.Sprintf("%s is required when %s is not provided", e.Field(), e.Param())
+			case "indian_state":
+				msg = fmt.Sprintf("%s must be an Indian state or union territory", e.Field())
+			case "pincode":
+				msg = fmt.Sprintf("%s must be a 6-digit PIN code not starting with 0", e.Field())
+			case "district_state":
+				msg = fmt.Sprintf("%s is not a district of %s", e.Field(), e.Param())
+			case "pincode_state":
+				msg = fmt.Sprintf("%s does not belong to %s", e.Field(), e.Param())
 			default:
 				msg = fmt.Sprintf("%s is invalid", e.Field())
 			}
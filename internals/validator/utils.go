@@ -1,9 +1,36 @@
 package validator
 
-import "github.com/go-playground/validator/v10"
+import (
+	"aadhaar-user-service/internals/dto"
 
-var _validator *validator.Validate
+	"github.com/go-playground/validator/v10"
+)
 
-func init() {
-	_validator = validator.New()
+// Validator checks request payloads against their struct tags and the custom rules below.
+// It is safe for concurrent use.
+type Validator struct {
+	validate *validator.Validate
+}
+
+// New creates a Validator with the custom rules registered
+func New() *Validator {
+	v := validator.New()
+
+	// Aadhaar identifier formats
+	v.RegisterValidation("aadhaar_number", validateAadhaarNumber)
+	v.RegisterValidation("aadhaar_application_id", validateAadhaarApplicationID)
+
+	// Calendar dates
+	v.RegisterValidation("date", validateDate)
+	v.RegisterValidation("date_of_birth", validateDateOfBirth)
+
+	// Structured addresses
+	v.RegisterValidation("indian_state", validateIndianState)
+	v.RegisterValidation("pincode", validatePinCode)
+	v.RegisterStructValidation(validateAddress, dto.Address{})
+
+	// User list filters
+	v.RegisterStructValidation(validateUserFilters, dto.UserFilters{})
+
+	return &Validator{validate: v}
 }
@@ -0,0 +1,7 @@
+-- Migration: Create users table for Aadhaar User Service
+-- Version: 001 (down)
+-- Description: Drops the users table and its trigger function
+
+DROP TRIGGER IF EXISTS update_users_updated_at ON users;
+DROP TABLE IF EXISTS users;
+DROP FUNCTION IF EXISTS update_updated_at_column();
@@ -0,0 +1,23 @@
+-- Migration: Soft delete for users
+-- Version: 002 (down)
+-- Description: Removes deleted_at and makes email / Aadhaar Application ID unique across all rows again
+
+-- Refuse while soft-deleted rows exist; purge or restore them first
+DO $$
+DECLARE
+    deleted_count INTEGER;
+BEGIN
+    SELECT COUNT(*) INTO deleted_count FROM users WHERE deleted_at IS NOT NULL;
+
+    IF deleted_count > 0 THEN
+        RAISE EXCEPTION '% users are soft-deleted; purge or restore them before reverting migration 002', deleted_count;
+    END IF;
+END $$;
+
+DROP INDEX IF EXISTS idx_users_email;
+DROP INDEX IF EXISTS idx_users_aadhaar_application_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(email);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_aadhaar_application_id ON users(aadhaar_application_id);
+
+DROP INDEX IF EXISTS idx_users_deleted_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,18 @@
+-- Migration: Soft delete for users
+-- Version: 002
+-- Description: Adds deleted_at so user records can be restored, and scopes uniqueness to live rows
+
+-- Add soft delete column
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users(deleted_at);
+
+-- Email and Aadhaar Application ID only need to be unique among live users,
+-- otherwise a soft-deleted record would block re-enrolment
+DROP INDEX IF EXISTS idx_users_email;
+DROP INDEX IF EXISTS idx_users_aadhaar_application_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(email) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_aadhaar_application_id ON users(aadhaar_application_id) WHERE deleted_at IS NULL;
+
+-- Comments for documentation
+COMMENT ON COLUMN users.deleted_at IS 'Soft delete timestamp (NULL for live users)';
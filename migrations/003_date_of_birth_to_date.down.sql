@@ -0,0 +1,12 @@
+-- Migration: Store date of birth as DATE
+-- Version: 003 (down)
+-- Description: Converts users.date_of_birth back to VARCHAR(10) in YYYY-MM-DD format
+
+DROP INDEX IF EXISTS idx_users_date_of_birth;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_date_of_birth;
+
+ALTER TABLE users
+    ALTER COLUMN date_of_birth TYPE VARCHAR(10) USING to_char(date_of_birth, 'YYYY-MM-DD');
+
+-- Comments for documentation
+COMMENT ON COLUMN users.date_of_birth IS 'Date of birth (YYYY-MM-DD format)';
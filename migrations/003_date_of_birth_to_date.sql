@@ -0,0 +1,45 @@
+-- Migration: Store date of birth as DATE
+-- Version: 003
+-- Description: Converts users.date_of_birth from VARCHAR(10) to DATE, accepting YYYY-MM-DD and DD-MM-YYYY values
+
+-- Parse a legacy date of birth string, returning NULL when it is not a real calendar date
+CREATE OR REPLACE FUNCTION parse_legacy_date_of_birth(value TEXT)
+RETURNS DATE AS $$
+BEGIN
+    IF value ~ '^\d{4}-\d{2}-\d{2}$' THEN
+        RETURN to_date(value, 'YYYY-MM-DD');
+    ELSIF value ~ '^\d{2}-\d{2}-\d{4}$' THEN
+        RETURN to_date(value, 'DD-MM-YYYY');
+    END IF;
+    RETURN NULL;
+EXCEPTION WHEN OTHERS THEN
+    RETURN NULL;
+END;
+$$ language 'plpgsql' IMMUTABLE;
+
+-- Refuse to convert while rows hold unparseable dates, so nothing is silently lost
+DO $$
+DECLARE
+    invalid_count INTEGER;
+BEGIN
+    SELECT COUNT(*) INTO invalid_count
+    FROM users
+    WHERE parse_legacy_date_of_birth(date_of_birth) IS NULL;
+
+    IF invalid_count > 0 THEN
+        RAISE EXCEPTION '% users have an unparseable date_of_birth; fix them before applying migration 003', invalid_count;
+    END IF;
+END $$;
+
+ALTER TABLE users
+    ALTER COLUMN date_of_birth TYPE DATE USING parse_legacy_date_of_birth(date_of_birth);
+
+DROP FUNCTION parse_legacy_date_of_birth(TEXT);
+
+-- Reject implausibly old dates (future dates are rejected by the service, as CHECK must be immutable)
+ALTER TABLE users ADD CONSTRAINT chk_date_of_birth CHECK (date_of_birth >= DATE '1900-01-01');
+
+CREATE INDEX IF NOT EXISTS idx_users_date_of_birth ON users(date_of_birth);
+
+-- Comments for documentation
+COMMENT ON COLUMN users.date_of_birth IS 'Date of birth';
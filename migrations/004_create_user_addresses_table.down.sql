@@ -0,0 +1,6 @@
+-- Migration: Create user_addresses table
+-- Version: 004 (down)
+-- Description: Drops structured addresses; users.address keeps the single-line form
+
+DROP TRIGGER IF EXISTS update_user_addresses_updated_at ON user_addresses;
+DROP TABLE IF EXISTS user_addresses;
@@ -0,0 +1,43 @@
+-- Migration: Create user_addresses table
+-- Version: 004
+-- Description: Structured Indian postal address per user; users.address keeps the single-line form
+
+CREATE TABLE IF NOT EXISTS user_addresses (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    house VARCHAR(100) NOT NULL,
+    street VARCHAR(150),
+    locality VARCHAR(100),
+    village_town VARCHAR(100) NOT NULL,
+    district VARCHAR(100) NOT NULL,
+    state VARCHAR(60) NOT NULL,
+    pin_code VARCHAR(6) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One structured address per user
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_addresses_user_id ON user_addresses(user_id);
+
+-- Create indexes for filtering
+CREATE INDEX IF NOT EXISTS idx_user_addresses_state ON user_addresses(state);
+CREATE INDEX IF NOT EXISTS idx_user_addresses_district ON user_addresses(district);
+CREATE INDEX IF NOT EXISTS idx_user_addresses_pin_code ON user_addresses(pin_code);
+
+-- Add constraint for PIN code format
+ALTER TABLE user_addresses ADD CONSTRAINT chk_pin_code CHECK (pin_code ~ '^[1-9][0-9]{5}$');
+
+-- Trigger to automatically update updated_at
+DROP TRIGGER IF EXISTS update_user_addresses_updated_at ON user_addresses;
+CREATE TRIGGER update_user_addresses_updated_at
+    BEFORE UPDATE ON user_addresses
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Comments for documentation
+COMMENT ON TABLE user_addresses IS 'Structured postal addresses of Aadhaar applicants';
+COMMENT ON COLUMN user_addresses.user_id IS 'Owning user';
+COMMENT ON COLUMN user_addresses.house IS 'House / flat number and building';
+COMMENT ON COLUMN user_addresses.village_town IS 'Village, town or city';
+COMMENT ON COLUMN user_addresses.state IS 'Canonical state or union territory name';
+COMMENT ON COLUMN user_addresses.pin_code IS '6-digit PIN code';
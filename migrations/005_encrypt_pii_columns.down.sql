@@ -0,0 +1,51 @@
+-- Migration: Field-level encryption of PII
+-- Version: 005 (down)
+-- Description: Restores the original PII column types and drops the key id and blind index columns.
+-- Encrypted rows cannot be converted back; decrypt them first.
+
+-- Refuse while encrypted rows exist, so no ciphertext is truncated or lost
+DO $$
+DECLARE
+    encrypted_count INTEGER;
+BEGIN
+    SELECT (SELECT COUNT(*) FROM users WHERE pii_key_id IS NOT NULL)
+         + (SELECT COUNT(*) FROM user_addresses WHERE pii_key_id IS NOT NULL)
+    INTO encrypted_count;
+
+    IF encrypted_count > 0 THEN
+        RAISE EXCEPTION '% rows are encrypted; decrypt them before reverting migration 005', encrypted_count;
+    END IF;
+END $$;
+
+DROP INDEX IF EXISTS idx_user_addresses_pii_key_id;
+ALTER TABLE user_addresses DROP COLUMN IF EXISTS pii_key_id;
+
+ALTER TABLE user_addresses
+    ALTER COLUMN house TYPE VARCHAR(100),
+    ALTER COLUMN street TYPE VARCHAR(150),
+    ALTER COLUMN locality TYPE VARCHAR(100),
+    ALTER COLUMN village_town TYPE VARCHAR(100);
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_date_of_birth;
+DROP INDEX IF EXISTS idx_users_pii_key_id;
+DROP INDEX IF EXISTS idx_users_email_bidx;
+DROP INDEX IF EXISTS idx_users_name_bidx;
+ALTER TABLE users DROP COLUMN IF EXISTS pii_key_id;
+ALTER TABLE users DROP COLUMN IF EXISTS email_bidx;
+ALTER TABLE users DROP COLUMN IF EXISTS name_bidx;
+
+ALTER TABLE users
+    ALTER COLUMN name TYPE VARCHAR(100),
+    ALTER COLUMN email TYPE VARCHAR(255),
+    ALTER COLUMN phone TYPE VARCHAR(10),
+    ALTER COLUMN address TYPE VARCHAR(500),
+    ALTER COLUMN date_of_birth TYPE DATE USING date_of_birth::DATE;
+
+ALTER TABLE users ADD CONSTRAINT chk_date_of_birth CHECK (date_of_birth >= DATE '1900-01-01');
+
+-- Comments for documentation
+COMMENT ON COLUMN users.name IS 'Full name of the applicant';
+COMMENT ON COLUMN users.email IS 'Email address (unique)';
+COMMENT ON COLUMN users.phone IS '10-digit phone number';
+COMMENT ON COLUMN users.address IS 'Residential address';
+COMMENT ON COLUMN users.date_of_birth IS 'Date of birth';
@@ -0,0 +1,58 @@
+-- Migration: Field-level encryption of PII
+-- Version: 005
+-- Description: Widens PII columns to TEXT so they can hold encrypted values, and adds the
+-- master key id and blind index columns used for lookups on encrypted data.
+-- Existing plaintext rows stay readable; run `rotate-keys` to encrypt them.
+
+-- Encrypted values do not fit the original column sizes.
+--
+-- Trade-off: ciphertext is not a date, so date_of_birth gives up the DATE type from migration 003
+-- and becomes TEXT. The dates 003 parsed are written back in its canonical YYYY-MM-DD form, which
+-- still sorts chronologically under idx_users_date_of_birth, and chk_date_of_birth is replaced by
+-- a check that plaintext rows keep that form within 1900-2099. Encrypted rows are checked by the
+-- service before sealing; migration 009 adds birth_year as their queryable, checked year.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_date_of_birth;
+ALTER TABLE users
+    ALTER COLUMN name TYPE TEXT,
+    ALTER COLUMN email TYPE TEXT,
+    ALTER COLUMN phone TYPE TEXT,
+    ALTER COLUMN address TYPE TEXT,
+    ALTER COLUMN date_of_birth TYPE TEXT USING to_char(date_of_birth, 'YYYY-MM-DD');
+
+-- Master key that wrapped the row's data key
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pii_key_id VARCHAR(64);
+
+-- Plaintext dates of birth stay canonical YYYY-MM-DD dates from 1900-2099
+ALTER TABLE users ADD CONSTRAINT chk_date_of_birth CHECK (
+    pii_key_id IS NOT NULL
+    OR date_of_birth ~ '^(19|20)\d{2}-(0[1-9]|1[0-2])-(0[1-9]|[12]\d|3[01])$'
+);
+
+-- Keyed hashes for equality lookups on encrypted columns
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_bidx VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS name_bidx VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_users_pii_key_id ON users(pii_key_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_bidx ON users(email_bidx) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_users_name_bidx ON users(name_bidx);
+
+-- Structured addresses: street-level parts are encrypted, district/state/PIN stay filterable
+ALTER TABLE user_addresses
+    ALTER COLUMN house TYPE TEXT,
+    ALTER COLUMN street TYPE TEXT,
+    ALTER COLUMN locality TYPE TEXT,
+    ALTER COLUMN village_town TYPE TEXT;
+
+ALTER TABLE user_addresses ADD COLUMN IF NOT EXISTS pii_key_id VARCHAR(64);
+CREATE INDEX IF NOT EXISTS idx_user_addresses_pii_key_id ON user_addresses(pii_key_id);
+
+-- Comments for documentation
+COMMENT ON COLUMN users.name IS 'Full name of the applicant (encrypted)';
+COMMENT ON COLUMN users.email IS 'Email address (encrypted)';
+COMMENT ON COLUMN users.phone IS '10-digit phone number (encrypted)';
+COMMENT ON COLUMN users.address IS 'Residential address (encrypted)';
+COMMENT ON COLUMN users.date_of_birth IS 'Date of birth, YYYY-MM-DD (encrypted)';
+COMMENT ON COLUMN users.pii_key_id IS 'Master key id wrapping the row data key (NULL for plaintext rows)';
+COMMENT ON COLUMN users.email_bidx IS 'HMAC-SHA256 blind index of the normalized email (unique)';
+COMMENT ON COLUMN users.name_bidx IS 'HMAC-SHA256 blind index of the normalized name';
+COMMENT ON COLUMN user_addresses.pii_key_id IS 'Master key id wrapping the row data key (NULL for plaintext rows)';
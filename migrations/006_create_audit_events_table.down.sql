@@ -0,0 +1,6 @@
+-- Migration: Create audit_events table
+-- Version: 006 (down)
+-- Description: Drops the audit trail. This destroys the recorded history.
+
+DROP TABLE IF EXISTS audit_events;
+DROP FUNCTION IF EXISTS reject_audit_events_change();
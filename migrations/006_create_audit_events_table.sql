@@ -0,0 +1,59 @@
+-- Migration: Create audit_events table
+-- Version: 006
+-- Description: Append-only, hash-chained audit trail of reads and writes of applicant data
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(32) NOT NULL,
+    target_user_id UUID,
+    request_id VARCHAR(64),
+    ip VARCHAR(45),
+    changed_fields JSONB,
+    details JSONB,
+    prev_hash VARCHAR(64) NOT NULL,
+    hash VARCHAR(64) NOT NULL
+);
+
+-- Every entry links to exactly one predecessor
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_events_hash ON audit_events(hash);
+
+-- Create indexes for filtering
+CREATE INDEX IF NOT EXISTS idx_audit_events_occurred_at ON audit_events(occurred_at);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events(actor);
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events(action);
+CREATE INDEX IF NOT EXISTS idx_audit_events_target_user_id ON audit_events(target_user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_request_id ON audit_events(request_id);
+
+-- Add constraint for valid actions
+ALTER TABLE audit_events ADD CONSTRAINT chk_audit_action
+    CHECK (action IN ('create', 'read', 'list', 'update', 'delete', 'restore', 'purge'));
+
+-- Reject any change to recorded events
+CREATE OR REPLACE FUNCTION reject_audit_events_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events;
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW
+    EXECUTE FUNCTION reject_audit_events_change();
+
+DROP TRIGGER IF EXISTS audit_events_no_truncate ON audit_events;
+CREATE TRIGGER audit_events_no_truncate
+    BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION reject_audit_events_change();
+
+-- Comments for documentation
+COMMENT ON TABLE audit_events IS 'Append-only audit trail of access to applicant data';
+COMMENT ON COLUMN audit_events.actor IS 'Authenticated subject that performed the action';
+COMMENT ON COLUMN audit_events.target_user_id IS 'User the action applied to, if any';
+COMMENT ON COLUMN audit_events.changed_fields IS 'Changed fields with hashes of their old and new values';
+COMMENT ON COLUMN audit_events.prev_hash IS 'Hash of the preceding event';
+COMMENT ON COLUMN audit_events.hash IS 'SHA-256 over prev_hash and this event';
@@ -0,0 +1,12 @@
+-- Migration: Indexed applicant search
+-- Version: 007 (down)
+-- Description: Drops the search indexes and column. The pg_trgm extension is left installed.
+
+DROP INDEX IF EXISTS idx_users_search_vector;
+ALTER TABLE users DROP COLUMN IF EXISTS search_vector;
+
+DROP INDEX IF EXISTS idx_users_aadhaar_application_id_trgm;
+DROP INDEX IF EXISTS idx_users_email_trgm;
+DROP INDEX IF EXISTS idx_users_name_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_users_name ON users(name);
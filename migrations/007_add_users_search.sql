@@ -0,0 +1,34 @@
+-- Migration: Indexed applicant search
+-- Version: 007
+-- Description: Adds pg_trgm trigram indexes for substring, prefix and fuzzy matching, and a
+-- search_vector column for full-text search over the words of the name and email.
+-- search_vector is maintained by the application. With encryption enabled it holds keyed hashes
+-- of the words, so rows already encrypted are indexed by the next `rotate-keys` run.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Trigram indexes serve ILIKE with leading wildcards as well as the % similarity operator
+CREATE INDEX IF NOT EXISTS idx_users_name_trgm ON users USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_users_email_trgm ON users USING GIN (email gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_users_aadhaar_application_id_trgm ON users USING GIN (aadhaar_application_id gin_trgm_ops);
+
+-- Superseded by idx_users_name_trgm
+DROP INDEX IF EXISTS idx_users_name;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+
+-- Plaintext rows: the same words the application indexes, lower-cased and split on anything
+-- that is not a letter or digit. The backfill does not change the applicants' data, so it must
+-- not move updated_at; the trigger still fires on every column here, so it is off meanwhile.
+-- Migrations run in a transaction, so a failed backfill leaves the trigger enabled.
+ALTER TABLE users DISABLE TRIGGER update_users_updated_at;
+
+UPDATE users
+SET search_vector = to_tsvector('simple', regexp_replace(lower(name || ' ' || email), '[^[:alnum:]]+', ' ', 'g'))
+WHERE pii_key_id IS NULL;
+
+ALTER TABLE users ENABLE TRIGGER update_users_updated_at;
+
+CREATE INDEX IF NOT EXISTS idx_users_search_vector ON users USING GIN (search_vector);
+
+COMMENT ON COLUMN users.search_vector IS 'Words of name and email for full-text search (keyed hashes when encrypted)';
@@ -0,0 +1,12 @@
+-- Migration: Phonetic name search
+-- Version: 008 (down)
+-- Description: Drops the name_phonetic column and restores the updated_at trigger on every update
+
+DROP TRIGGER IF EXISTS update_users_updated_at ON users;
+CREATE TRIGGER update_users_updated_at
+    BEFORE UPDATE ON users
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+DROP INDEX IF EXISTS idx_users_name_phonetic;
+ALTER TABLE users DROP COLUMN IF EXISTS name_phonetic;
@@ -0,0 +1,19 @@
+-- Migration: Phonetic name search
+-- Version: 008
+-- Description: Adds a name_phonetic column holding the phonetic keys of the words of the name,
+-- after transliteration of Indic scripts, for spelling-tolerant name search.
+-- The keys are computed by the application; run `reindex` after this migration to fill them in
+-- for existing rows, which also adds transliterated names to search_vector.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS name_phonetic TSVECTOR;
+
+CREATE INDEX IF NOT EXISTS idx_users_name_phonetic ON users USING GIN (name_phonetic);
+
+-- Only changes to the applicant's data count as updates, so reindexing leaves updated_at alone
+DROP TRIGGER IF EXISTS update_users_updated_at ON users;
+CREATE TRIGGER update_users_updated_at
+    BEFORE UPDATE OF aadhaar_application_id, name, email, phone, address, date_of_birth, gender, deleted_at ON users
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON COLUMN users.name_phonetic IS 'Phonetic keys of the name (keyed hashes when encrypted)';
@@ -0,0 +1,7 @@
+-- Migration: Queryable birth year
+-- Version: 009 (down)
+-- Description: Drops the birth_year column
+
+DROP INDEX IF EXISTS idx_users_birth_year;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_birth_year;
+ALTER TABLE users DROP COLUMN IF EXISTS birth_year;
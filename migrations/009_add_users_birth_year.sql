@@ -0,0 +1,19 @@
+-- Migration: Queryable birth year
+-- Version: 009
+-- Description: Adds a birth_year column beside the encrypted date_of_birth, so age and date of
+-- birth queries keep an indexed, checked column. Migration 005 turned date_of_birth into TEXT to
+-- hold ciphertext and dropped its CHECK; the full date stays encrypted, only the year is exposed.
+-- birth_year is maintained by the application. Encrypted rows get theirs on the next `reindex`.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS birth_year SMALLINT;
+
+-- Plaintext rows hold the date as YYYY-MM-DD
+UPDATE users
+SET birth_year = substr(date_of_birth, 1, 4)::SMALLINT
+WHERE pii_key_id IS NULL;
+
+ALTER TABLE users ADD CONSTRAINT chk_birth_year CHECK (birth_year >= 1900);
+
+CREATE INDEX IF NOT EXISTS idx_users_birth_year ON users(birth_year);
+
+COMMENT ON COLUMN users.birth_year IS 'Year of date_of_birth, kept in plaintext for range queries (NULL until reindexed)';
@@ -0,0 +1,9 @@
+-- Migration: Trigram indexes over plaintext only
+-- Version: 010 (down)
+-- Description: Restores the name and email trigram indexes over every row
+
+DROP INDEX IF EXISTS idx_users_name_trgm;
+DROP INDEX IF EXISTS idx_users_email_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_users_name_trgm ON users USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_users_email_trgm ON users USING GIN (email gin_trgm_ops);
@@ -0,0 +1,13 @@
+-- Migration: Trigram indexes over plaintext only
+-- Version: 010
+-- Description: Rebuilds the name and email trigram indexes of migration 007 as partial indexes
+-- over plaintext rows. Trigrams of ciphertext match nothing a search could ask for, and only
+-- grew the indexes and leaked the shape of encrypted values. The queries using these indexes
+-- repeat the pii_key_id IS NULL predicate; Aadhaar Application IDs are never encrypted and stay
+-- fully indexed.
+
+DROP INDEX IF EXISTS idx_users_name_trgm;
+DROP INDEX IF EXISTS idx_users_email_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_users_name_trgm ON users USING GIN (name gin_trgm_ops) WHERE pii_key_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_users_email_trgm ON users USING GIN (email gin_trgm_ops) WHERE pii_key_id IS NULL;
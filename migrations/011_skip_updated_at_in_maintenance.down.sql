@@ -0,0 +1,11 @@
+-- Migration: Maintenance rewrites keep updated_at
+-- Version: 011 (down)
+-- Description: Restores the updated_at trigger function that bumps every updated row
+
+CREATE OR REPLACE FUNCTION update_updated_at_column()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
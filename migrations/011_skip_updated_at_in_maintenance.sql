@@ -0,0 +1,18 @@
+-- Migration: Maintenance rewrites keep updated_at
+-- Version: 011
+-- Description: Lets the updated_at triggers of users and user_addresses skip rows rewritten by
+-- maintenance. `rotate-keys` re-encrypts name, email, phone, address and date of birth, which
+-- the trigger of migration 008 counted as updates of the applicant's data. Maintenance commands
+-- set the transaction-local aadhaar.maintenance setting to on; other writes are unaffected.
+
+CREATE OR REPLACE FUNCTION update_updated_at_column()
+RETURNS TRIGGER AS $$
+BEGIN
+    -- The setting is unknown, so NULL, until a maintenance transaction sets it
+    IF current_setting('aadhaar.maintenance', true) = 'on' THEN
+        RETURN NEW;
+    END IF;
+    NEW.updated_at = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
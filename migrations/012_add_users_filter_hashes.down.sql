@@ -0,0 +1,8 @@
+-- Migration: List filters on encrypted PII
+-- Version: 012 (down)
+-- Description: Drops the email domain and phone prefix hashes
+
+DROP INDEX IF EXISTS idx_users_phone_prefixes;
+DROP INDEX IF EXISTS idx_users_email_domain_bidx;
+ALTER TABLE users DROP COLUMN IF EXISTS phone_prefixes;
+ALTER TABLE users DROP COLUMN IF EXISTS email_domain_bidx;
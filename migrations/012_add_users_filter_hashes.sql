@@ -0,0 +1,16 @@
+-- Migration: List filters on encrypted PII
+-- Version: 012
+-- Description: Adds keyed hashes backing the email_domain and phone_prefix list filters on
+-- encrypted rows: email_domain_bidx, a blind index of the lower-cased email domain, and
+-- phone_prefixes, truncated blind indexes of every prefix of the phone number. Both are computed
+-- by the application and left empty on plaintext rows, which the filters match by value. Rows
+-- encrypted before this migration get theirs on the next `rotate-keys` run.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_domain_bidx VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_prefixes TSVECTOR;
+
+CREATE INDEX IF NOT EXISTS idx_users_email_domain_bidx ON users(email_domain_bidx);
+CREATE INDEX IF NOT EXISTS idx_users_phone_prefixes ON users USING GIN (phone_prefixes);
+
+COMMENT ON COLUMN users.email_domain_bidx IS 'HMAC-SHA256 blind index of the lower-cased email domain (NULL for plaintext rows)';
+COMMENT ON COLUMN users.phone_prefixes IS 'Keyed hashes of every prefix of the phone number (empty for plaintext rows)';
@@ -0,0 +1,8 @@
+-- Migration: Duplicate candidates by phone and date of birth
+-- Version: 013 (down)
+-- Description: Drops the phone and date of birth blind indexes
+
+DROP INDEX IF EXISTS idx_users_phone_dob;
+DROP INDEX IF EXISTS idx_users_phone_dob_bidx;
+ALTER TABLE users DROP COLUMN IF EXISTS dob_bidx;
+ALTER TABLE users DROP COLUMN IF EXISTS phone_bidx;
@@ -0,0 +1,16 @@
+-- Migration: Duplicate candidates by phone and date of birth
+-- Version: 013
+-- Description: Adds blind indexes of the phone number and date of birth, so duplicate detection
+-- also finds applicants with the same phone and birth date whose names share no phonetic key,
+-- such as Xavier Dsouza and Zavier D'Souza. Both are computed by the application and stay NULL
+-- on plaintext rows, which are matched by value through a partial index. Rows encrypted before
+-- this migration get theirs on the next `rotate-keys` run.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_bidx VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS dob_bidx VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_users_phone_dob_bidx ON users(phone_bidx, dob_bidx);
+CREATE INDEX IF NOT EXISTS idx_users_phone_dob ON users(phone, date_of_birth) WHERE pii_key_id IS NULL;
+
+COMMENT ON COLUMN users.phone_bidx IS 'HMAC-SHA256 blind index of the phone number (NULL for plaintext rows)';
+COMMENT ON COLUMN users.dob_bidx IS 'HMAC-SHA256 blind index of the date of birth (NULL for plaintext rows)';
@@ -0,0 +1,8 @@
+-- Migration: Audit user exports
+-- Version: 014 (down)
+-- Description: Disallows the export action again. The trail is append-only and hash-chained, so
+-- recorded exports are kept and the constraint only applies to new events.
+
+ALTER TABLE audit_events DROP CONSTRAINT IF EXISTS chk_audit_action;
+ALTER TABLE audit_events ADD CONSTRAINT chk_audit_action
+    CHECK (action IN ('create', 'read', 'list', 'update', 'delete', 'restore', 'purge')) NOT VALID;
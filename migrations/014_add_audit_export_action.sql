@@ -0,0 +1,7 @@
+-- Migration: Audit user exports
+-- Version: 014
+-- Description: Allows the export action in the audit trail, recorded by GET /users/export
+
+ALTER TABLE audit_events DROP CONSTRAINT IF EXISTS chk_audit_action;
+ALTER TABLE audit_events ADD CONSTRAINT chk_audit_action
+    CHECK (action IN ('create', 'read', 'list', 'export', 'update', 'delete', 'restore', 'purge'));
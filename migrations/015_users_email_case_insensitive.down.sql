@@ -0,0 +1,6 @@
+-- Migration: Case-insensitive unique emails
+-- Version: 015 (down)
+-- Description: Restores the case-sensitive unique email index of live users
+
+DROP INDEX IF EXISTS idx_users_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(email) WHERE deleted_at IS NULL;
@@ -0,0 +1,26 @@
+-- Migration: Case-insensitive unique emails
+-- Version: 015
+-- Description: Makes the unique email index of live users ignore case, as the blind index of
+-- encrypted emails already does, so plaintext and encrypted rows treat emails alike.
+
+-- Refuse while live users hold emails differing only in case, so no duplicate is kept silently
+DO $$
+DECLARE
+    duplicate_count INTEGER;
+BEGIN
+    SELECT COUNT(*) INTO duplicate_count
+    FROM (
+        SELECT lower(email)
+        FROM users
+        WHERE deleted_at IS NULL
+        GROUP BY lower(email)
+        HAVING COUNT(*) > 1
+    ) duplicates;
+
+    IF duplicate_count > 0 THEN
+        RAISE EXCEPTION '% emails are held by several live users in different case; merge or delete them before applying migration 015', duplicate_count;
+    END IF;
+END $$;
+
+DROP INDEX IF EXISTS idx_users_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(lower(email)) WHERE deleted_at IS NULL;
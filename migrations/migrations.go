@@ -0,0 +1,8 @@
+// Package migrations embeds the numbered SQL migrations. Each NNN_name.sql file may have a
+// matching NNN_name.down.sql that reverts it.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
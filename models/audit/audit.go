@@ -0,0 +1,224 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"aadhaar-user-service/internals/database"
+	"aadhaar-user-service/internals/dto"
+	"aadhaar-user-service/internals/logging"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GenesisHash is the previous hash of the first event in the chain
+var GenesisHash = strings.Repeat("0", 64)
+
+// chainLockKey serializes appends so every event links to its true predecessor
+const chainLockKey = 7243001
+
+// FieldChange holds keyed hashes of a field's value before and after a change
+type FieldChange struct {
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// Event represents the database model for the append-only audit_events table
+type Event struct {
+	ID            uint64                 `gorm:"primaryKey;autoIncrement" json:"id"`
+	OccurredAt    time.Time              `gorm:"not null;index" json:"occurred_at"`
+	Actor         string                 `gorm:"size:255;not null;index" json:"actor"`
+	Action        string                 `gorm:"size:32;not null;index" json:"action"`
+	TargetUserID  *uuid.UUID             `gorm:"type:uuid;index" json:"target_user_id"`
+	RequestID     string                 `gorm:"size:64" json:"request_id"`
+	IP            string                 `gorm:"size:45" json:"ip"`
+	ChangedFields map[string]FieldChange `gorm:"type:jsonb;serializer:json" json:"changed_fields"`
+	Details       map[string]any         `gorm:"type:jsonb;serializer:json" json:"details"`
+	PrevHash      string                 `gorm:"size:64;not null" json:"prev_hash"`
+	Hash          string                 `gorm:"size:64;not null;uniqueIndex" json:"hash"`
+}
+
+// TableName specifies the table name for the Event model
+func (Event) TableName() string {
+	return "audit_events"
+}
+
+// New creates a new Event instance
+func New() *Event {
+	return &Event{}
+}
+
+// ComputeHash returns the chain hash of the event: SHA-256 over the previous hash and
+// a canonical JSON encoding of the event's content
+func (e *Event) ComputeHash() string {
+	var target string
+	if e.TargetUserID != nil {
+		target = e.TargetUserID.String()
+	}
+
+	// json.Marshal sorts map keys, so the encoding survives a round trip through jsonb
+	content, _ := json.Marshal(struct {
+		OccurredAt    string                 `json:"occurred_at"`
+		Actor         string                 `json:"actor"`
+		Action        string                 `json:"action"`
+		TargetUserID  string                 `json:"target_user_id"`
+		RequestID     string                 `json:"request_id"`
+		IP            string                 `json:"ip"`
+		ChangedFields map[string]FieldChange `json:"changed_fields"`
+		Details       map[string]any         `json:"details"`
+	}{
+		OccurredAt:    e.OccurredAt.UTC().Format(time.RFC3339Nano),
+		Actor:         e.Actor,
+		Action:        e.Action,
+		TargetUserID:  target,
+		RequestID:     e.RequestID,
+		IP:            e.IP,
+		ChangedFields: e.ChangedFields,
+		Details:       normalizeDetails(e.Details),
+	})
+
+	sum := sha256.Sum256(append([]byte(e.PrevHash+"\n"), content...))
+	return hex.EncodeToString(sum[:])
+}
+
+// GormRepository stores audit events in Postgres through GORM
+type GormRepository struct {
+	db *gorm.DB
+}
+
+// NewGormRepository creates a repository backed by db
+func NewGormRepository(db *gorm.DB) *GormRepository {
+	return &GormRepository{db: db}
+}
+
+// Append links the event to the end of the chain and inserts it
+func (r *GormRepository) Append(ctx context.Context, e *Event) error {
+	return r.AppendBatch(ctx, []*Event{e})
+}
+
+// AppendBatch links the events to the end of the chain and inserts them, taking the chain lock
+// and reading its head once for all of them
+func (r *GormRepository) AppendBatch(ctx context.Context, events []*Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	return database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", chainLockKey).Error; err != nil {
+			return err
+		}
+
+		var last Event
+		err := tx.Select("hash").Order("id DESC").Limit(1).Find(&last).Error
+		if err != nil {
+			logging.FromContext(ctx).Error("Error reading audit chain head", logging.Err(err))
+			return err
+		}
+
+		prev := GenesisHash
+		if last.Hash != "" {
+			prev = last.Hash
+		}
+		for _, e := range events {
+			// Postgres keeps microseconds; hash what will be read back
+			e.OccurredAt = e.OccurredAt.UTC().Truncate(time.Microsecond)
+			e.PrevHash = prev
+			e.Hash = e.ComputeHash()
+			prev = e.Hash
+		}
+
+		if err := tx.Create(events).Error; err != nil {
+			logging.FromContext(ctx).Error("Unable to append audit event", logging.Err(err))
+			return err
+		}
+		return nil
+	})
+}
+
+// List retrieves events matching the filter, newest first
+func (r *GormRepository) List(ctx context.Context, filter dto.AuditFilter) ([]Event, int64, error) {
+	var events []Event
+	var total int64
+
+	db := database.Conn(ctx, r.db).Model(&Event{})
+
+	if filter.Actor != "" {
+		db = db.Where("actor = ?", filter.Actor)
+	}
+	if filter.Action != "" {
+		db = db.Where("action = ?", filter.Action)
+	}
+	if filter.TargetUserID != "" {
+		db = db.Where("target_user_id = ?", filter.TargetUserID)
+	}
+	if filter.RequestID != "" {
+		db = db.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.From != nil {
+		db = db.Where("occurred_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		db = db.Where("occurred_at < ?", *filter.To)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		logging.FromContext(ctx).Error("Error counting audit events", logging.Err(err))
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	if err := db.Order("id DESC").Limit(filter.Limit).Offset(offset).Find(&events).Error; err != nil {
+		logging.FromContext(ctx).Error("Error getting audit events", logging.Err(err))
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+// Walk visits every event in chain order, batchSize at a time, until fn returns false
+func (r *GormRepository) Walk(ctx context.Context, batchSize int, fn func(Event) bool) error {
+	var afterID uint64
+	for {
+		var batch []Event
+		if err := database.Conn(ctx, r.db).
+			Where("id > ?", afterID).
+			Order("id ASC").
+			Limit(batchSize).
+			Find(&batch).Error; err != nil {
+			logging.FromContext(ctx).Error("Error reading audit chain", logging.Err(err))
+			return err
+		}
+
+		for _, event := range batch {
+			if !fn(event) {
+				return nil
+			}
+			afterID = event.ID
+		}
+
+		if len(batch) < batchSize {
+			return nil
+		}
+	}
+}
+
+// normalizeDetails round-trips details through JSON so values hash the same before and after storage
+func normalizeDetails(details map[string]any) map[string]any {
+	if details == nil {
+		return nil
+	}
+	data, err := json.Marshal(details)
+	if err != nil {
+		return details
+	}
+	var normalized map[string]any
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return details
+	}
+	return normalized
+}
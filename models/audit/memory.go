@@ -0,0 +1,110 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"aadhaar-user-service/internals/dto"
+
+	"github.com/google/uuid"
+)
+
+var (
+	_ EventRepository = (*GormRepository)(nil)
+	_ EventRepository = (*MemoryRepository)(nil)
+)
+
+// MemoryRepository keeps the audit trail in memory, for tests and local runs without Postgres.
+// It filters, orders and pages like GormRepository.
+type MemoryRepository struct {
+	mu     sync.RWMutex
+	events []Event
+}
+
+// NewMemoryRepository creates an empty in-memory audit trail
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{}
+}
+
+// Append links the event to the end of the chain and stores it
+func (r *MemoryRepository) Append(ctx context.Context, e *Event) error {
+	return r.AppendBatch(ctx, []*Event{e})
+}
+
+// AppendBatch links the events to the end of the chain and stores them
+func (r *MemoryRepository) AppendBatch(ctx context.Context, events []*Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range events {
+		e.OccurredAt = e.OccurredAt.UTC().Truncate(time.Microsecond)
+		e.ID = uint64(len(r.events)) + 1
+		e.PrevHash = GenesisHash
+		if n := len(r.events); n > 0 {
+			e.PrevHash = r.events[n-1].Hash
+		}
+		e.Hash = e.ComputeHash()
+
+		r.events = append(r.events, *e)
+	}
+	return nil
+}
+
+// List returns events matching the filter, newest first
+func (r *MemoryRepository) List(ctx context.Context, filter dto.AuditFilter) ([]Event, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Compared as UUIDs, like the uuid column, so any spelling matches
+	var target *uuid.UUID
+	if filter.TargetUserID != "" {
+		id, err := uuid.Parse(filter.TargetUserID)
+		if err != nil {
+			return nil, 0, err
+		}
+		target = &id
+	}
+
+	var matches []Event
+	for i := len(r.events) - 1; i >= 0; i-- {
+		e := r.events[i]
+		switch {
+		case filter.Actor != "" && e.Actor != filter.Actor,
+			filter.Action != "" && e.Action != filter.Action,
+			target != nil && (e.TargetUserID == nil || *e.TargetUserID != *target),
+			filter.RequestID != "" && e.RequestID != filter.RequestID,
+			filter.From != nil && e.OccurredAt.Before(*filter.From),
+			filter.To != nil && !e.OccurredAt.Before(*filter.To):
+			continue
+		}
+		matches = append(matches, e)
+	}
+
+	total := int64(len(matches))
+
+	offset := (filter.Page - 1) * filter.Limit
+	if offset < 0 || offset > len(matches) {
+		offset = len(matches)
+	}
+	end := len(matches)
+	if filter.Limit > 0 && offset+filter.Limit < end {
+		end = offset + filter.Limit
+	}
+
+	return matches[offset:end], total, nil
+}
+
+// Walk visits every event in chain order until fn returns false
+func (r *MemoryRepository) Walk(ctx context.Context, batchSize int, fn func(Event) bool) error {
+	r.mu.RLock()
+	events := r.events[:len(r.events):len(r.events)]
+	r.mu.RUnlock()
+
+	for _, e := range events {
+		if !fn(e) {
+			return nil
+		}
+	}
+	return nil
+}
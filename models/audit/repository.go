@@ -0,0 +1,23 @@
+package audit
+
+import (
+	"context"
+
+	"aadhaar-user-service/internals/dto"
+)
+
+// EventRepository stores the audit trail. Appends are serialized, so every event links to the
+// event appended just before it.
+type EventRepository interface {
+	// Append sets the event's previous hash and hash, then inserts it at the end of the chain
+	Append(ctx context.Context, e *Event) error
+
+	// AppendBatch appends the events in order, all of them or none
+	AppendBatch(ctx context.Context, events []*Event) error
+
+	// List returns a page of events matching the filter, newest first, and the total number of matches
+	List(ctx context.Context, filter dto.AuditFilter) ([]Event, int64, error)
+
+	// Walk visits every event in chain order, batchSize at a time, until fn returns false
+	Walk(ctx context.Context, batchSize int, fn func(Event) bool) error
+}
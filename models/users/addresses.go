@@ -0,0 +1,80 @@
+package users
+
+import (
+	"time"
+
+	"aadhaar-user-service/internals/encryption"
+	"aadhaar-user-service/internals/logging"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Address represents the database model for the user_addresses table
+type Address struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID      uuid.UUID `gorm:"type:uuid;uniqueIndex;not null" json:"user_id"`
+	House       string    `gorm:"type:text;not null;serializer:pii" json:"house"`
+	Street      string    `gorm:"type:text;serializer:pii" json:"street"`
+	Locality    string    `gorm:"type:text;serializer:pii" json:"locality"`
+	VillageTown string    `gorm:"type:text;not null;serializer:pii" json:"village_town"`
+	District    string    `gorm:"size:100;not null;index" json:"district"`
+	State       string    `gorm:"size:60;not null;index" json:"state"`
+	PinCode     string    `gorm:"size:6;not null;index" json:"pin_code"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	encryption.Envelope
+}
+
+// TableName specifies the table name for the Address model
+func (Address) TableName() string {
+	return "user_addresses"
+}
+
+// BeforeSave prepares the data key before PII columns are written
+func (a *Address) BeforeSave(tx *gorm.DB) error {
+	return a.SealEnvelope(encryption.FromContext(tx.Statement.Context))
+}
+
+// BeforeCreate assigns the ID up front, as the encrypted columns are bound to it
+func (a *Address) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// saveAddress replaces the structured address of the user, or removes it when AddressDetails is nil
+func (u *User) saveAddress(db *gorm.DB) error {
+	ctx := db.Statement.Context
+
+	if u.AddressDetails == nil {
+		if err := db.Where("user_id = ?", u.ID).Delete(&Address{}).Error; err != nil {
+			logging.FromContext(ctx).Error("Error deleting user address", logging.Err(err))
+			return err
+		}
+		return nil
+	}
+
+	// A replaced address keeps its ID, which its encrypted columns are bound to
+	var ids []uuid.UUID
+	if err := db.Model(&Address{}).Where("user_id = ?", u.ID).Pluck("id", &ids).Error; err != nil {
+		logging.FromContext(ctx).Error("Error loading user address", logging.Err(err))
+		return err
+	}
+	if len(ids) > 0 {
+		u.AddressDetails.ID = ids[0]
+	}
+
+	u.AddressDetails.UserID = u.ID
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"house", "street", "locality", "village_town", "district", "state", "pin_code", "pii_key_id", "updated_at"}),
+	}).Create(u.AddressDetails).Error; err != nil {
+		logging.FromContext(ctx).Error("Error saving user address", logging.Err(err))
+		return err
+	}
+	return nil
+}
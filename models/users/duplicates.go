@@ -0,0 +1,75 @@
+package users
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"aadhaar-user-service/internals/dto"
+	"aadhaar-user-service/internals/logging"
+	"aadhaar-user-service/internals/names"
+
+	"gorm.io/gorm/clause"
+)
+
+// Candidates returns up to limit live users other than u with the phone number and date of birth
+// of u, or whose name shares a phonetic key with the name of u. Users with the same phone and
+// date of birth come first, then those sharing the most keys. Both sources are indexed and work
+// on encrypted rows, through blind indexes and the name_phonetic column, but miss rows written
+// before migrations 008 and 013 until `reindex` and `rotate-keys` have run.
+func (r *GormRepository) Candidates(ctx context.Context, u *User, limit int) ([]User, error) {
+	same := r.samePhoneAndBirth(u)
+	cond := r.db.Where(same)
+
+	// The phonetic source ranks by shared keys; names without keys match by phone and birth only
+	rank := clause.Expr{SQL: "0"}
+	if keys := names.Keys(u.Name); len(keys) > 0 {
+		for i, k := range keys {
+			keys[i] = phoneticToken(r.keys, k)
+		}
+		query := strings.Join(keys, " | ")
+		cond = cond.Or("name_phonetic @@ to_tsquery('simple', ?)", query)
+		rank = clause.Expr{SQL: "COALESCE(ts_rank(name_phonetic, to_tsquery('simple', ?)), 0)", Vars: []any{query}}
+	}
+
+	var users []User
+	if err := r.conn(ctx).
+		Where(cond).
+		Where("id <> ?", u.ID).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "COALESCE((?), false) DESC, ? DESC, created_at, id",
+			Vars: []any{same, rank},
+		}}).
+		Limit(limit).
+		Find(&users).Error; err != nil {
+		logging.FromContext(ctx).Error("Error finding duplicate candidates", logging.Err(err))
+		return nil, err
+	}
+	return users, nil
+}
+
+// samePhoneAndBirth returns the condition matching users with the phone number and date of birth
+// of u: their blind indexes while encryption is enabled, the stored values otherwise
+func (r *GormRepository) samePhoneAndBirth(u *User) clause.Expr {
+	dob := u.DateOfBirth.Format(dto.DateLayout)
+	if phone := blindIndex(r.keys, "phone", u.Phone); phone != nil {
+		return clause.Expr{SQL: "phone_bidx = ? AND dob_bidx = ?", Vars: []any{*phone, *blindIndex(r.keys, "date_of_birth", dob)}}
+	}
+	return clause.Expr{SQL: "pii_key_id IS NULL AND phone = ? AND date_of_birth = ?", Vars: []any{u.Phone, dob}}
+}
+
+// samePhoneAndBirth reports whether two users have the same phone number and date of birth
+func samePhoneAndBirth(a, b *User) bool {
+	return a.Phone == b.Phone && a.DateOfBirth.Equal(b.DateOfBirth)
+}
+
+// sharedKeys counts the phonetic keys two names have in common
+func sharedKeys(a, b []string) int {
+	shared := 0
+	for _, k := range a {
+		if slices.Contains(b, k) {
+			shared++
+		}
+	}
+	return shared
+}
@@ -0,0 +1,161 @@
+package users
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"aadhaar-user-service/internals/dto"
+	"aadhaar-user-service/internals/encryption"
+	"aadhaar-user-service/internals/logging"
+
+	"gorm.io/gorm"
+)
+
+// blindIndex returns the blind index of a normalized value under k, nil while encryption is disabled
+func blindIndex(k *encryption.Keyring, purpose, value string) *string {
+	if k == nil {
+		return nil
+	}
+	bidx := k.BlindIndex(purpose, value)
+	return &bidx
+}
+
+// normalizeEmail folds an email for blind indexing
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// emailDomain returns the lower-cased domain of an email for blind indexing
+func emailDomain(email string) string {
+	_, domain, _ := strings.Cut(normalizeEmail(email), "@")
+	return domain
+}
+
+// phonePrefixDocument returns the keyed hashes of every prefix of a phone number as the
+// phone_prefixes column indexes them, or an empty document while encryption is disabled
+func phonePrefixDocument(k *encryption.Keyring, phone string) SearchDocument {
+	if k == nil {
+		return ""
+	}
+	prefixes := make([]string, len(phone))
+	for i := range phone {
+		prefixes[i] = phonePrefixToken(k, phone[:i+1])
+	}
+	return SearchDocument(strings.Join(prefixes, " "))
+}
+
+// phonePrefixToken returns how a phone prefix is stored in phone_prefixes, hashed like searchToken
+func phonePrefixToken(k *encryption.Keyring, prefix string) string {
+	return hashedToken(k, "phone_prefix", prefix)
+}
+
+// normalizeName folds a name for blind indexing
+func normalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// encryptedQueryError returns an UnsupportedQueryError when params need the plaintext of
+// encrypted columns, nil otherwise. Names and emails are ciphertext under random nonces, so they
+// sort in no meaningful order and their substrings and trigrams cannot be matched; searches must
+// use the blind indexes (exact) or hashed words and phonetic keys (fulltext, phonetic). Date of
+// birth ranges are answered from birth_year, so they must span whole years.
+func encryptedQueryError(params dto.PaginationParams) error {
+	switch params.SortBy {
+	case "name", "email":
+		return &UnsupportedQueryError{
+			Field:   "SortBy",
+			Message: "SortBy " + params.SortBy + " is not available while PII is encrypted",
+		}
+	}
+
+	// Only the year of encrypted dates of birth is stored in plaintext
+	if from, err := dto.ParseDate(params.Filters.DateOfBirthFrom); err == nil && (from.Month() != time.January || from.Day() != 1) {
+		return &UnsupportedQueryError{
+			Field:   "DateOfBirthFrom",
+			Message: "DateOfBirthFrom must be the first day of a year while PII is encrypted",
+		}
+	}
+	if to, err := dto.ParseDate(params.Filters.DateOfBirthTo); err == nil && (to.Month() != time.December || to.Day() != 31) {
+		return &UnsupportedQueryError{
+			Field:   "DateOfBirthTo",
+			Message: "DateOfBirthTo must be the last day of a year while PII is encrypted",
+		}
+	}
+
+	if strings.TrimSpace(params.Search) == "" {
+		return nil
+	}
+	switch params.SearchMode {
+	case dto.SearchContains, dto.SearchPrefix, dto.SearchFuzzy:
+		return &UnsupportedQueryError{
+			Field:   "SearchMode",
+			Message: "SearchMode " + params.SearchMode + " is not available while PII is encrypted, use exact, fulltext or phonetic",
+		}
+	}
+	return nil
+}
+
+// maintenance runs fn in a transaction whose rewrites are not updates of the applicant's data:
+// with aadhaar.maintenance on, the updated_at triggers leave the rows' updated_at alone
+func (r *GormRepository) maintenance(ctx context.Context, fn func(db *gorm.DB) error) error {
+	return r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT set_config('aadhaar.maintenance', 'on', true)").Error; err != nil {
+			logging.FromContext(ctx).Error("Error starting maintenance transaction", logging.Err(err))
+			return err
+		}
+		return fn(tx.Unscoped().Session(&gorm.Session{}))
+	})
+}
+
+// ReencryptBatch re-encrypts up to limit users and addresses whose PII is not under the active
+// master key, including plaintext rows written before encryption was enabled. The rows keep
+// their updated_at, as re-encryption does not change the applicant's data.
+// It returns the number of rows rewritten; a batch that fails is rolled back as a whole.
+func (r *GormRepository) ReencryptBatch(ctx context.Context, limit int) (int, error) {
+	k := r.keys
+	if k == nil {
+		return 0, encryption.ErrNotConfigured
+	}
+	stale := "pii_key_id IS NULL OR pii_key_id <> ?"
+
+	var users []User
+	var addresses []Address
+	err := r.maintenance(ctx, func(db *gorm.DB) error {
+		// Rows encrypted before migrations 007, 012 and 013 still lack their hashed search words,
+		// filter hashes and phone and date of birth blind indexes
+		if err := db.Where(stale+" OR search_vector IS NULL OR email_domain_bidx IS NULL OR phone_bidx IS NULL", k.ActiveKeyID()).Limit(limit).Find(&users).Error; err != nil {
+			logging.FromContext(ctx).Error("Error loading users for re-encryption", logging.Err(err))
+			return err
+		}
+		for i := range users {
+			if err := db.Model(&users[i]).
+				Select("name", "email", "phone", "address", "date_of_birth", "email_bidx", "name_bidx", "phone_bidx", "dob_bidx",
+					"birth_year", "email_domain_bidx", "phone_prefixes", "search_vector", "name_phonetic", "pii_key_id").
+				Omit("updated_at").
+				Updates(&users[i]).Error; err != nil {
+				logging.FromContext(ctx).Error("Error re-encrypting user", logging.Err(err))
+				return err
+			}
+		}
+
+		if err := db.Where(stale, k.ActiveKeyID()).Limit(limit).Find(&addresses).Error; err != nil {
+			logging.FromContext(ctx).Error("Error loading addresses for re-encryption", logging.Err(err))
+			return err
+		}
+		for i := range addresses {
+			if err := db.Model(&addresses[i]).
+				Select("house", "street", "locality", "village_town", "pii_key_id").
+				Omit("updated_at").
+				Updates(&addresses[i]).Error; err != nil {
+				logging.FromContext(ctx).Error("Error re-encrypting address", logging.Err(err))
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(users) + len(addresses), nil
+}
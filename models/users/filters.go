@@ -0,0 +1,108 @@
+package users
+
+import (
+	"slices"
+	"strings"
+
+	"aadhaar-user-service/internals/dto"
+
+	"gorm.io/gorm"
+)
+
+// plaintext restricts a condition on an encrypted column to rows stored in plaintext
+const plaintext = "pii_key_id IS NULL AND "
+
+// filter restricts db to the users matching every filter set. Columns are fixed here and values
+// always bound, so no filter reaches the SQL text. Email, phone and date of birth are ciphertext
+// in encrypted rows, which are matched through their keyed hashes and birth year instead.
+func (r *GormRepository) filter(db *gorm.DB, f dto.UserFilters) *gorm.DB {
+	if f.Gender != "" {
+		db = db.Where("gender = ?", f.Gender)
+	}
+	if f.CreatedFrom != nil {
+		db = db.Where("created_at >= ?", *f.CreatedFrom)
+	}
+	if f.CreatedTo != nil {
+		db = db.Where("created_at < ?", *f.CreatedTo)
+	}
+	if f.UpdatedFrom != nil {
+		db = db.Where("updated_at >= ?", *f.UpdatedFrom)
+	}
+	if f.UpdatedTo != nil {
+		db = db.Where("updated_at < ?", *f.UpdatedTo)
+	}
+
+	// Dates of birth are stored as YYYY-MM-DD text, which sorts like the dates. While encrypted,
+	// List only lets whole years through, which birth_year answers for every row.
+	if from, err := dto.ParseDate(f.DateOfBirthFrom); err == nil {
+		if r.keys != nil {
+			db = db.Where("birth_year >= ?", from.Year())
+		} else {
+			db = db.Where(plaintext+"date_of_birth >= ?", from.Format(dto.DateLayout))
+		}
+	}
+	if to, err := dto.ParseDate(f.DateOfBirthTo); err == nil {
+		if r.keys != nil {
+			db = db.Where("birth_year <= ?", to.Year())
+		} else {
+			db = db.Where(plaintext+"date_of_birth <= ?", to.Format(dto.DateLayout))
+		}
+	}
+
+	// Rows not yet encrypted are matched by value, encrypted ones by their hashes
+	if f.EmailDomain != "" {
+		cond := r.db.Where(plaintext+"lower(email) LIKE ?", "%@"+likeEscaper.Replace(strings.ToLower(f.EmailDomain)))
+		if bidx := blindIndex(r.keys, "email_domain", strings.ToLower(f.EmailDomain)); bidx != nil {
+			cond = cond.Or("email_domain_bidx = ?", *bidx)
+		}
+		db = db.Where(cond)
+	}
+	if f.PhonePrefix != "" {
+		cond := r.db.Where(plaintext+"phone LIKE ?", likeEscaper.Replace(f.PhonePrefix)+"%")
+		if r.keys != nil {
+			cond = cond.Or("phone_prefixes @@ to_tsquery('simple', ?)", phonePrefixToken(r.keys, f.PhonePrefix))
+		}
+		db = db.Where(cond)
+	}
+	if f.PinCodePrefix != "" {
+		db = db.Where("id IN (?)", r.db.Model(&Address{}).Select("user_id").
+			Where("pin_code LIKE ?", likeEscaper.Replace(f.PinCodePrefix)+"%"))
+	}
+	if len(f.AadhaarApplicationIDs) > 0 {
+		db = db.Where("aadhaar_application_id IN ?", f.AadhaarApplicationIDs)
+	}
+	return db
+}
+
+// matchesFilters reports whether u matches every filter set, as GormRepository.filter does on
+// plaintext rows
+func matchesFilters(u *User, f dto.UserFilters) bool {
+	switch {
+	case f.Gender != "" && u.Gender != f.Gender:
+		return false
+	case f.CreatedFrom != nil && u.CreatedAt.Before(*f.CreatedFrom):
+		return false
+	case f.CreatedTo != nil && !u.CreatedAt.Before(*f.CreatedTo):
+		return false
+	case f.UpdatedFrom != nil && u.UpdatedAt.Before(*f.UpdatedFrom):
+		return false
+	case f.UpdatedTo != nil && !u.UpdatedAt.Before(*f.UpdatedTo):
+		return false
+	case f.EmailDomain != "" && !strings.HasSuffix(strings.ToLower(u.Email), "@"+strings.ToLower(f.EmailDomain)):
+		return false
+	case f.PhonePrefix != "" && !strings.HasPrefix(u.Phone, f.PhonePrefix):
+		return false
+	case f.PinCodePrefix != "" && (u.AddressDetails == nil || !strings.HasPrefix(u.AddressDetails.PinCode, f.PinCodePrefix)):
+		return false
+	case len(f.AadhaarApplicationIDs) > 0 && !slices.Contains(f.AadhaarApplicationIDs, u.AadhaarApplicationID):
+		return false
+	}
+
+	if from, err := dto.ParseDate(f.DateOfBirthFrom); err == nil && u.DateOfBirth.Before(from) {
+		return false
+	}
+	if to, err := dto.ParseDate(f.DateOfBirthTo); err == nil && u.DateOfBirth.After(to) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,449 @@
+package users
+
+import (
+	"cmp"
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"aadhaar-user-service/internals/dto"
+	"aadhaar-user-service/internals/encryption"
+	"aadhaar-user-service/internals/names"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	_ UserRepository = (*GormRepository)(nil)
+	_ UserRepository = (*MemoryRepository)(nil)
+)
+
+// MemoryRepository stores users in memory, for tests and local runs without Postgres.
+// It behaves like GormRepository: the same unique constraints among live users, soft deletes,
+// search, state and typed filters, sorting and pagination. PII is kept in plaintext, but with
+// encryption keys given the queries GormRepository cannot run on encrypted PII are refused alike.
+type MemoryRepository struct {
+	// encrypted refuses the queries GormRepository refuses on encrypted PII
+	encrypted bool
+
+	// txMu runs transactions one at a time, so a rollback undoes no other transaction's writes
+	txMu  sync.Mutex
+	mu    sync.RWMutex
+	users map[uuid.UUID]*User
+}
+
+// NewMemoryRepository creates an empty in-memory repository, answering queries as GormRepository
+// does with keys; a nil keys means encryption is disabled
+func NewMemoryRepository(keys *encryption.Keyring) *MemoryRepository {
+	return &MemoryRepository{encrypted: keys != nil, users: make(map[uuid.UUID]*User)}
+}
+
+// Transaction runs fn and, when it fails, puts every user back as it was before. Writes outside
+// a transaction are not held off while one runs.
+func (r *MemoryRepository) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
+
+	// Updates change stored users in place, so the snapshot copies them
+	r.mu.RLock()
+	snapshot := make(map[uuid.UUID]*User, len(r.users))
+	for id, u := range r.users {
+		snapshot[id] = clone(u)
+	}
+	r.mu.RUnlock()
+
+	if err := fn(ctx); err != nil {
+		r.mu.Lock()
+		r.users = snapshot
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Create inserts a copy of u, filling in its ID, address ID and timestamps
+func (r *MemoryRepository) Create(ctx context.Context, u *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+	if _, ok := r.users[u.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	if err := r.conflict(u); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt = now
+	}
+	if u.UpdatedAt.IsZero() {
+		u.UpdatedAt = now
+	}
+	if a := u.AddressDetails; a != nil {
+		if a.ID == uuid.Nil {
+			a.ID = uuid.New()
+		}
+		a.UserID = u.ID
+		a.CreatedAt, a.UpdatedAt = now, now
+	}
+
+	r.users[u.ID] = clone(u)
+	return nil
+}
+
+// GetByID returns a copy of the user, including soft-deleted users when asked
+func (r *MemoryRepository) GetByID(ctx context.Context, id uuid.UUID, includeDeleted bool) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[id]
+	if !ok || (u.DeletedAt.Valid && !includeDeleted) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return clone(u), nil
+}
+
+// GetByEmail returns a copy of the live user with the given email, ignoring case
+func (r *MemoryRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
+	return r.find(func(u *User) bool { return normalizeEmail(u.Email) == normalizeEmail(email) })
+}
+
+// GetByAadhaarApplicationID returns a copy of the live user with the given Aadhaar application ID
+func (r *MemoryRepository) GetByAadhaarApplicationID(ctx context.Context, aadhaarID string) (*User, error) {
+	return r.find(func(u *User) bool { return u.AadhaarApplicationID == aadhaarID })
+}
+
+// List returns copies of a page of users matching params, at params.Cursor when set and at
+// params.Page otherwise
+func (r *MemoryRepository) List(ctx context.Context, params dto.PaginationParams) (*UserPage, error) {
+	if r.encrypted {
+		if err := encryptedQueryError(params); err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	search := strings.TrimSpace(params.Search)
+
+	var matches []*User
+	for _, u := range r.users {
+		if u.DeletedAt.Valid && !params.IncludeDeleted {
+			continue
+		}
+		if params.State != "" && (u.AddressDetails == nil || u.AddressDetails.State != params.State) {
+			continue
+		}
+		if !matchesFilters(u, params.Filters) {
+			continue
+		}
+
+		// Matches are copies, so the scores never land on stored users
+		match := clone(u)
+		if search != "" {
+			score, ok := searchScore(u, search, params.SearchMode, params.Similarity)
+			if !ok {
+				continue
+			}
+			match.Score = &score
+		}
+		matches = append(matches, match)
+	}
+
+	// Same order as the SQL ORDER BY: the sort column, then id to break ties
+	column := sortColumn(params)
+	desc := getSafeSortOrder(params.Order) == "DESC"
+	sort.Slice(matches, func(i, j int) bool {
+		c := compareColumn(matches[i], matches[j], column)
+		if c == 0 {
+			c = strings.Compare(matches[i].ID.String(), matches[j].ID.String())
+		}
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	page := &UserPage{}
+	if params.WithTotal {
+		page.Total = int64(len(matches))
+	}
+
+	var start, end int
+	if c := params.Cursor; c != nil {
+		// The first match after the cursor row in listing order
+		position := sort.Search(len(matches), func(i int) bool {
+			cmp := compareCursor(matches[i], column, c)
+			if desc {
+				return cmp < 0
+			}
+			return cmp > 0
+		})
+
+		if c.Backward {
+			// Rows before the cursor row, which is not part of the page
+			end = position
+			if end > 0 && compareCursor(matches[end-1], column, c) == 0 {
+				end--
+			}
+			start = max(end-params.Limit, 0)
+			page.More = start > 0
+		} else {
+			start = position
+			end = min(start+params.Limit, len(matches))
+			page.More = end < len(matches)
+		}
+	} else {
+		start = min(max((params.Page-1)*params.Limit, 0), len(matches))
+		end = min(start+params.Limit, len(matches))
+		page.More = end < len(matches)
+	}
+
+	page.Users = make([]User, 0, end-start)
+	for _, u := range matches[start:end] {
+		u.SortValue = sortValue(u, column)
+		page.Users = append(page.Users, *u)
+	}
+	return page, nil
+}
+
+// Update writes the editable fields and structured address of a live user, refreshing u
+func (r *MemoryRepository) Update(ctx context.Context, u *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[u.ID]
+	if !ok || stored.DeletedAt.Valid {
+		return gorm.ErrRecordNotFound
+	}
+	if err := r.conflict(u); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	stored.AadhaarApplicationID = u.AadhaarApplicationID
+	stored.Name = u.Name
+	stored.Email = u.Email
+	stored.Phone = u.Phone
+	stored.Address = u.Address
+	stored.DateOfBirth = u.DateOfBirth
+	stored.Gender = u.Gender
+	stored.UpdatedAt = now
+
+	// The address keeps its ID and creation time when replaced, like the upsert on user_id
+	switch {
+	case u.AddressDetails == nil:
+		stored.AddressDetails = nil
+	case stored.AddressDetails == nil:
+		a := *u.AddressDetails
+		a.ID, a.UserID = uuid.New(), u.ID
+		a.CreatedAt, a.UpdatedAt = now, now
+		stored.AddressDetails = &a
+	default:
+		a := *u.AddressDetails
+		a.ID, a.UserID = stored.AddressDetails.ID, u.ID
+		a.CreatedAt, a.UpdatedAt = stored.AddressDetails.CreatedAt, now
+		stored.AddressDetails = &a
+	}
+
+	*u = *clone(stored)
+	return nil
+}
+
+// Delete soft-deletes a live user, moving updated_at like the update_users_updated_at trigger
+func (r *MemoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok || u.DeletedAt.Valid {
+		return gorm.ErrRecordNotFound
+	}
+	now := time.Now()
+	u.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+	u.UpdatedAt = now
+	return nil
+}
+
+// Restore clears deleted_at on a soft-deleted user
+func (r *MemoryRepository) Restore(ctx context.Context, u *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[u.ID]
+	if !ok || !stored.DeletedAt.Valid {
+		return gorm.ErrRecordNotFound
+	}
+	if err := r.conflict(stored); err != nil {
+		return err
+	}
+
+	stored.DeletedAt = gorm.DeletedAt{}
+	stored.UpdatedAt = time.Now()
+	u.DeletedAt = stored.DeletedAt
+	u.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+// Purge permanently removes users soft-deleted before the given time
+func (r *MemoryRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for id, u := range r.users {
+		if u.DeletedAt.Valid && u.DeletedAt.Time.Before(before) {
+			delete(r.users, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// ReencryptBatch has nothing to do, the in-memory store keeps PII in plaintext
+func (r *MemoryRepository) ReencryptBatch(ctx context.Context, limit int) (int, error) {
+	return 0, nil
+}
+
+// Candidates returns copies of up to limit live users other than u with the phone number and date
+// of birth of u, or whose name shares a phonetic key with the name of u. Users with the same phone
+// and date of birth come first, then those sharing the most keys
+func (r *MemoryRepository) Candidates(ctx context.Context, u *User, limit int) ([]User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := names.Keys(u.Name)
+	shared := make(map[uuid.UUID]int)
+	same := make(map[uuid.UUID]bool)
+	var candidates []User
+	for _, c := range r.users {
+		if c.DeletedAt.Valid || c.ID == u.ID {
+			continue
+		}
+		n, s := sharedKeys(keys, names.Keys(c.Name)), samePhoneAndBirth(u, c)
+		if n > 0 || s {
+			shared[c.ID], same[c.ID] = n, s
+			candidates = append(candidates, *clone(c))
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := &candidates[i], &candidates[j]
+		if same[a.ID] != same[b.ID] {
+			return same[a.ID]
+		}
+		if shared[a.ID] != shared[b.ID] {
+			return shared[a.ID] > shared[b.ID]
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID.String() < b.ID.String()
+	})
+	return candidates[:min(limit, len(candidates))], nil
+}
+
+// ReindexBatch has nothing to do, the in-memory store computes search words and phonetic keys
+// when searching
+func (r *MemoryRepository) ReindexBatch(ctx context.Context, limit int) (int, error) {
+	return 0, nil
+}
+
+// find returns a copy of the first live user matching fn
+func (r *MemoryRepository) find(fn func(u *User) bool) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if !u.DeletedAt.Valid && fn(u) {
+			return clone(u), nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// conflict reports whether another live user holds the email, in any case, or the Aadhaar
+// Application ID of u
+func (r *MemoryRepository) conflict(u *User) error {
+	for id, other := range r.users {
+		if id == u.ID || other.DeletedAt.Valid {
+			continue
+		}
+		if normalizeEmail(other.Email) == normalizeEmail(u.Email) {
+			return ErrDuplicateEmail
+		}
+		if other.AadhaarApplicationID == u.AadhaarApplicationID {
+			return ErrDuplicateAadhaarID
+		}
+	}
+	return nil
+}
+
+// compareColumn compares two users on a column returned by getSafeColumnName
+func compareColumn(a, b *User, column string) int {
+	switch column {
+	case "name":
+		return strings.Compare(a.Name, b.Name)
+	case "email":
+		return strings.Compare(a.Email, b.Email)
+	case "aadhaar_application_id":
+		return strings.Compare(a.AadhaarApplicationID, b.AadhaarApplicationID)
+	case "score":
+		return cmp.Compare(*a.Score, *b.Score)
+	default:
+		return a.CreatedAt.Compare(b.CreatedAt)
+	}
+}
+
+// sortValue returns the value of a text column returned by getSafeColumnName, as List reports it
+func sortValue(u *User, column string) string {
+	switch column {
+	case "name":
+		return u.Name
+	case "email":
+		return u.Email
+	case "aadhaar_application_id":
+		return u.AadhaarApplicationID
+	default:
+		return ""
+	}
+}
+
+// compareCursor compares a user with the row a cursor points at, on the sort column then id
+func compareCursor(u *User, column string, c *dto.Cursor) int {
+	var result int
+	switch column {
+	case "created_at":
+		t, _ := time.Parse(time.RFC3339Nano, c.Value)
+		result = u.CreatedAt.Compare(t)
+	case "score":
+		score, _ := strconv.ParseFloat(c.Value, 64)
+		result = cmp.Compare(*u.Score, score)
+	default:
+		result = strings.Compare(sortValue(u, column), c.Value)
+	}
+	if result == 0 {
+		result = strings.Compare(u.ID.String(), c.ID.String())
+	}
+	return result
+}
+
+// clone copies a user and its structured address, so callers never share stored state
+func clone(u *User) *User {
+	c := *u
+	if u.AddressDetails != nil {
+		a := *u.AddressDetails
+		c.AddressDetails = &a
+	}
+	c.UserDTO, c.UsersDTO, c.Score = nil, nil, nil
+	return &c
+}
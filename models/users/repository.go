@@ -0,0 +1,90 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"aadhaar-user-service/internals/dto"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrDuplicateEmail     = errors.New("a live user already has this email")
+	ErrDuplicateAadhaarID = errors.New("a live user already has this aadhaar application id")
+)
+
+// UnsupportedQueryError is returned by List for sorting, searching or filtering that cannot be
+// done while PII is encrypted, as it would compare ciphertext
+type UnsupportedQueryError struct {
+	// Field is the PaginationParams field asking for it
+	Field   string
+	Message string
+}
+
+func (e *UnsupportedQueryError) Error() string {
+	return e.Message
+}
+
+// UserPage is one page of a user list
+type UserPage struct {
+	// Users are in listing order, also when paging backwards
+	Users []User
+
+	// Total counts every match, only when params.WithTotal is set
+	Total int64
+
+	// More reports whether further users follow in the paging direction
+	More bool
+}
+
+// UserRepository stores users. Lookups of missing users return gorm.ErrRecordNotFound, and writes
+// that would give two live users the same email or Aadhaar Application ID return ErrDuplicateEmail
+// or ErrDuplicateAadhaarID.
+type UserRepository interface {
+	// Transaction runs fn so that the writes made with its context, by this repository and the
+	// audit repository alike, take effect together or not at all
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// Create inserts u, filling in its ID and timestamps
+	Create(ctx context.Context, u *User) error
+
+	// GetByID returns the user with its structured address, optionally including soft-deleted users
+	GetByID(ctx context.Context, id uuid.UUID, includeDeleted bool) (*User, error)
+
+	// GetByEmail returns the live user with the given email, ignoring case
+	GetByEmail(ctx context.Context, email string) (*User, error)
+
+	// GetByAadhaarApplicationID returns the live user with the given Aadhaar Application ID
+	GetByAadhaarApplicationID(ctx context.Context, aadhaarID string) (*User, error)
+
+	// List returns a page of users matching params, at params.Cursor when set and at params.Page
+	// otherwise. Users carry the SortValue their cursors are built from. While PII is encrypted,
+	// sorting by name or email, the contains, prefix and fuzzy search modes and date of birth
+	// ranges not spanning whole years return an UnsupportedQueryError.
+	List(ctx context.Context, params dto.PaginationParams) (*UserPage, error)
+
+	// Candidates returns up to limit live users other than u that may be the same applicant, the
+	// likeliest first, for duplicate detection to score
+	Candidates(ctx context.Context, u *User, limit int) ([]User, error)
+
+	// Update writes the editable fields and structured address of a live user, refreshing u
+	Update(ctx context.Context, u *User) error
+
+	// Delete soft-deletes a live user
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// Restore clears deleted_at on a soft-deleted user
+	Restore(ctx context.Context, u *User) error
+
+	// Purge permanently removes users soft-deleted before the given time and returns how many
+	Purge(ctx context.Context, before time.Time) (int64, error)
+
+	// ReencryptBatch re-encrypts up to limit rows not under the active master key and returns how many
+	ReencryptBatch(ctx context.Context, limit int) (int, error)
+
+	// ReindexBatch fills in the search and birth year columns of up to limit rows lacking them and
+	// returns how many
+	ReindexBatch(ctx context.Context, limit int) (int, error)
+}
@@ -0,0 +1,280 @@
+package users
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+
+	"aadhaar-user-service/internals/dto"
+	"aadhaar-user-service/internals/encryption"
+	"aadhaar-user-service/internals/logging"
+	"aadhaar-user-service/internals/names"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SearchDocument is the text the search_vector column is computed from
+type SearchDocument string
+
+// GormValue writes the document as a tsvector
+func (d SearchDocument) GormValue(ctx context.Context, db *gorm.DB) clause.Expr {
+	return clause.Expr{SQL: "to_tsvector('simple', ?)", Vars: []any{string(d)}}
+}
+
+// searchDocument returns the words of a name and email as full-text search indexes them
+func searchDocument(k *encryption.Keyring, name, email string) SearchDocument {
+	words := documentWords(name, email)
+	for i, w := range words {
+		words[i] = searchToken(k, w)
+	}
+	return SearchDocument(strings.Join(words, " "))
+}
+
+// phoneticDocument returns the phonetic keys of a name as the name_phonetic column indexes them
+func phoneticDocument(k *encryption.Keyring, name string) SearchDocument {
+	keys := names.Keys(name)
+	for i, key := range keys {
+		keys[i] = phoneticToken(k, key)
+	}
+	return SearchDocument(strings.Join(keys, " "))
+}
+
+// documentWords returns the words of a name and email, with names in Indic scripts
+// transliterated to Latin letters
+func documentWords(name, email string) []string {
+	return names.Words(name + " " + email)
+}
+
+// searchWords splits text into lower-cased words on anything that is not a letter or digit,
+// the way pg_trgm does
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// searchToken returns how a word is stored in search_vector: the word itself, or a keyed hash of
+// it under k while encryption is enabled. The h prefix keeps hashes single tokens for the
+// tsvector parser.
+func searchToken(k *encryption.Keyring, word string) string {
+	return hashedToken(k, "search", word)
+}
+
+// phoneticToken returns how a phonetic key is stored in name_phonetic, hashed like searchToken
+func phoneticToken(k *encryption.Keyring, key string) string {
+	return hashedToken(k, "phonetic", key)
+}
+
+// hashedToken returns token, or a truncated blind index of it for purpose while encryption is
+// enabled
+func hashedToken(k *encryption.Keyring, purpose, token string) string {
+	if bidx := blindIndex(k, purpose, token); bidx != nil {
+		return "h" + (*bidx)[:16]
+	}
+	return token
+}
+
+// tsQuery returns a to_tsquery expression matching documents that contain every word, or every
+// word as a prefix
+func tsQuery(k *encryption.Keyring, words []string, prefix bool) string {
+	terms := make([]string, len(words))
+	for i, w := range words {
+		if prefix {
+			terms[i] = w + ":*"
+		} else {
+			terms[i] = searchToken(k, w)
+		}
+	}
+	return strings.Join(terms, " & ")
+}
+
+// likeEscaper escapes the LIKE wildcards in a literal
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// trigrams returns the trigrams of s as pg_trgm extracts them: lower-cased words of letters and
+// digits, each padded with two spaces in front and one behind
+func trigrams(s string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, word := range searchWords(s) {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = struct{}{}
+		}
+	}
+	return set
+}
+
+// similarity mirrors pg_trgm's similarity(): shared trigrams over all distinct trigrams
+func similarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for t := range ta {
+		if _, ok := tb[t]; ok {
+			shared++
+		}
+	}
+	// Rounded to real, as Postgres returns it
+	return float64(float32(shared) / float32(len(ta)+len(tb)-shared))
+}
+
+// searchScore returns how well u matches a search term, or false when it does not match.
+// It follows the SQL of GormRepository.search on plaintext columns; full-text and phonetic
+// scores approximate ts_rank by the share of the user's words or keys that were searched for.
+func searchScore(u *User, search, mode string, threshold float64) (float64, bool) {
+	similar := max(similarity(u.Name, search), similarity(u.Email, search), similarity(u.AadhaarApplicationID, search))
+	words := names.Words(search)
+
+	switch mode {
+	case dto.SearchExact:
+		if u.AadhaarApplicationID == search || strings.EqualFold(u.Name, normalizeName(search)) || strings.EqualFold(u.Email, search) {
+			return 1, true
+		}
+
+	case dto.SearchPrefix:
+		if strings.HasPrefix(u.AadhaarApplicationID, search) || (len(words) > 0 && containsWords(u, words, true)) {
+			return similar, true
+		}
+
+	case dto.SearchFuzzy:
+		if similar >= threshold {
+			return similar, true
+		}
+
+	case dto.SearchFullText:
+		if len(words) > 0 && containsWords(u, words, false) {
+			return float64(float32(len(words)) / float32(len(documentWords(u.Name, u.Email)))), true
+		}
+
+	case dto.SearchPhonetic:
+		keys, own := names.Keys(search), names.Keys(u.Name)
+		if len(keys) > 0 && !slices.ContainsFunc(keys, func(k string) bool { return !slices.Contains(own, k) }) {
+			return float64(float32(len(keys)) / float32(len(own))), true
+		}
+
+	default:
+		search = strings.ToLower(search)
+		if strings.Contains(strings.ToLower(u.Name), search) ||
+			strings.Contains(strings.ToLower(u.Email), search) ||
+			strings.Contains(strings.ToLower(u.AadhaarApplicationID), search) {
+			return similar, true
+		}
+	}
+	return 0, false
+}
+
+// containsWords reports whether every word occurs in the name or email of u, or starts one of
+// their words
+func containsWords(u *User, words []string, prefix bool) bool {
+	own := documentWords(u.Name, u.Email)
+	for _, w := range words {
+		found := false
+		for _, o := range own {
+			if o == w || (prefix && strings.HasPrefix(o, w)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// search returns the condition a search term puts on users and the relevance of each match.
+// Encrypted names and emails only match exactly, through their blind indexes, by whole words in
+// full-text mode, through the hashed words in search_vector, and by sound in phonetic mode,
+// through the hashed keys in name_phonetic; List refuses the other modes while encrypted.
+func (r *GormRepository) search(params dto.PaginationParams) (*gorm.DB, clause.Expr) {
+	search := strings.TrimSpace(params.Search)
+	words := names.Words(search)
+
+	score := clause.Expr{
+		SQL:  "GREATEST(similarity(name, ?), similarity(email, ?), similarity(aadhaar_application_id, ?))",
+		Vars: []any{search, search, search},
+	}
+
+	// Names and emails are matched on plaintext rows only, which the trigram indexes cover
+	var cond *gorm.DB
+	switch params.SearchMode {
+	case dto.SearchExact:
+		cond = r.db.Where("aadhaar_application_id = ? OR (pii_key_id IS NULL AND (name ILIKE ? OR email ILIKE ?))",
+			search, likeEscaper.Replace(normalizeName(search)), likeEscaper.Replace(search))
+		score = clause.Expr{SQL: "1.0::real"}
+
+	case dto.SearchPrefix:
+		cond = r.db.Where("aadhaar_application_id LIKE ?", likeEscaper.Replace(search)+"%")
+		if len(words) > 0 {
+			cond = cond.Or("search_vector @@ to_tsquery('simple', ?)", tsQuery(r.keys, words, true))
+		}
+
+	case dto.SearchFuzzy:
+		// % compares against pg_trgm.similarity_threshold, set by List, and uses the trigram indexes
+		cond = r.db.Where("aadhaar_application_id % ? OR (pii_key_id IS NULL AND (name % ? OR email % ?))", search, search, search)
+
+	case dto.SearchFullText:
+		if len(words) == 0 {
+			return r.db.Where("FALSE"), score
+		}
+		query := tsQuery(r.keys, words, false)
+		cond = r.db.Where("search_vector @@ to_tsquery('simple', ?)", query)
+		score = clause.Expr{SQL: "ts_rank(search_vector, to_tsquery('simple', ?))", Vars: []any{query}}
+		return cond, score
+
+	case dto.SearchPhonetic:
+		keys := names.Keys(search)
+		if len(keys) == 0 {
+			return r.db.Where("FALSE"), score
+		}
+		for i, k := range keys {
+			keys[i] = phoneticToken(r.keys, k)
+		}
+		query := strings.Join(keys, " & ")
+		cond = r.db.Where("name_phonetic @@ to_tsquery('simple', ?)", query)
+		score = clause.Expr{SQL: "ts_rank(name_phonetic, to_tsquery('simple', ?))", Vars: []any{query}}
+		return cond, score
+
+	default:
+		searchPattern := "%" + search + "%"
+		cond = r.db.Where("aadhaar_application_id ILIKE ? OR (pii_key_id IS NULL AND (name ILIKE ? OR email ILIKE ?))",
+			searchPattern, searchPattern, searchPattern)
+	}
+
+	if bidx := blindIndex(r.keys, "email", normalizeEmail(search)); bidx != nil {
+		cond = cond.Or("email_bidx = ?", *bidx)
+	}
+	if bidx := blindIndex(r.keys, "name", normalizeName(search)); bidx != nil {
+		cond = cond.Or("name_bidx = ?", *bidx)
+	}
+	return cond, score
+}
+
+// ReindexBatch computes search_vector, name_phonetic and birth_year for up to limit users written
+// before migrations 008 and 009 and returns how many were indexed. Encrypted rows need their
+// master keys loaded. The rows keep their updated_at. A batch that fails is rolled back as a whole.
+func (r *GormRepository) ReindexBatch(ctx context.Context, limit int) (int, error) {
+	var users []User
+	err := r.maintenance(ctx, func(db *gorm.DB) error {
+		if err := db.Where("name_phonetic IS NULL OR birth_year IS NULL").Limit(limit).Find(&users).Error; err != nil {
+			logging.FromContext(ctx).Error("Error loading users for reindexing", logging.Err(err))
+			return err
+		}
+		for i := range users {
+			if err := db.Model(&users[i]).Select("search_vector", "name_phonetic", "birth_year").Omit("updated_at").Updates(&users[i]).Error; err != nil {
+				logging.FromContext(ctx).Error("Error reindexing user", logging.Err(err))
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(users), nil
+}
@@ -2,28 +2,68 @@ package users
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"aadhaar-user-service/internals/database"
 	"aadhaar-user-service/internals/dto"
+	"aadhaar-user-service/internals/encryption"
+	"aadhaar-user-service/internals/logging"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // User represents the database model for users table
 type User struct {
-	ID                   uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
-	AadhaarApplicationID string    `gorm:"uniqueIndex;size:14;not null" json:"aadhaar_application_id"`
-	Name                 string    `gorm:"size:100;not null" json:"name"`
-	Email                string    `gorm:"uniqueIndex;size:255;not null" json:"email"`
-	Phone                string    `gorm:"size:10;not null" json:"phone"`
-	Address              string    `gorm:"size:500;not null" json:"address"`
-	DateOfBirth          string    `gorm:"size:10;not null" json:"date_of_birth"`
-	Gender               string    `gorm:"size:10;not null" json:"gender"`
-	CreatedAt            time.Time `json:"created_at"`
-	UpdatedAt            time.Time `json:"updated_at"`
+	ID                   uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	AadhaarApplicationID string         `gorm:"uniqueIndex:idx_users_aadhaar_application_id,where:deleted_at IS NULL;size:14;not null" json:"aadhaar_application_id"`
+	Name                 string         `gorm:"type:text;not null;serializer:pii" json:"name"`
+	Email                string         `gorm:"uniqueIndex:idx_users_email,expression:lower(email),where:deleted_at IS NULL;type:text;not null;serializer:pii" json:"email"`
+	Phone                string         `gorm:"type:text;not null;serializer:pii" json:"phone"`
+	Address              string         `gorm:"type:text;not null;serializer:pii" json:"address"`
+	DateOfBirth          time.Time      `gorm:"type:text;not null;serializer:pii" json:"date_of_birth"`
+	Gender               string         `gorm:"size:10;not null" json:"gender"`
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+	DeletedAt            gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+
+	// Blind indexes for equality lookups on encrypted columns, NULL while encryption is disabled
+	EmailBidx       *string `gorm:"uniqueIndex:idx_users_email_bidx,where:deleted_at IS NULL;size:64" json:"-"`
+	NameBidx        *string `gorm:"index;size:64" json:"-"`
+	PhoneBidx       *string `gorm:"index:idx_users_phone_dob_bidx,priority:1;size:64" json:"-"`
+	DateOfBirthBidx *string `gorm:"column:dob_bidx;index:idx_users_phone_dob_bidx,priority:2;size:64" json:"-"`
+
+	// Year of the date of birth, in plaintext even while the date is encrypted, for range queries
+	BirthYear *int16 `gorm:"index" json:"-"`
+
+	// Keyed hashes of the email domain and of every phone prefix for the list filters, empty
+	// while encryption is disabled
+	EmailDomainBidx *string        `gorm:"index;size:64" json:"-"`
+	PhonePrefixes   SearchDocument `gorm:"->:false;<-;-:migration" json:"-"`
+
+	encryption.Envelope
+
+	// Words of the name and email for full-text search, hashed while encryption is enabled
+	SearchVector SearchDocument `gorm:"->:false;<-;-:migration" json:"-"`
+
+	// Phonetic keys of the name for phonetic search, hashed while encryption is enabled
+	NamePhonetic SearchDocument `gorm:"column:name_phonetic;->:false;<-;-:migration" json:"-"`
+
+	// Value of the sort column as stored, read by List for cursors; ciphertext for encrypted columns
+	SortValue string `gorm:"->;-:migration" json:"-"`
+
+	// Relevance of the user to the search term, read by List when searching
+	Score *float64 `gorm:"->;-:migration" json:"-"`
+
+	// Structured address, Address holds its single-line form
+	AddressDetails *Address `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"address_details,omitempty"`
 
 	// Non-database fields for DTO mapping
 	UserDTO  *dto.User  `gorm:"-"`
@@ -40,100 +80,346 @@ func New() *User {
 	return &User{}
 }
 
-// Create inserts a new user record into the database
-func (u *User) Create(ctx context.Context) error {
-	if err := database.Client().WithContext(ctx).Create(u).Error; err != nil {
-		fmt.Printf("Unable to create user: %v\n", err)
-		return err
+// Cursor returns the position of u in a listing sorted by params.SortBy
+func (u *User) Cursor(params dto.PaginationParams, backward bool) dto.Cursor {
+	value := u.SortValue
+	switch sortColumn(params) {
+	case "created_at":
+		value = u.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case "score":
+		value = strconv.FormatFloat(*u.Score, 'g', -1, 64)
+	}
+	return dto.Cursor{Value: value, ID: u.ID, Backward: backward, Query: params.Fingerprint()}
+}
+
+// BeforeCreate assigns the ID up front rather than leaving it to the column default, as the
+// encrypted columns are bound to it
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
 	}
 	return nil
 }
 
-// GetByID retrieves a user by their UUID
-func (u *User) GetByID(ctx context.Context) error {
-	if err := database.Client().WithContext(ctx).First(u, "id = ?", u.ID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			fmt.Printf("User not found: %v\n", err)
-			return err
-		}
-		fmt.Printf("Error getting user: %v\n", err)
+// BeforeSave prepares the data key and blind indexes before PII columns are written
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	k := encryption.FromContext(tx.Statement.Context)
+	if err := u.SealEnvelope(k); err != nil {
 		return err
 	}
+	u.EmailBidx = blindIndex(k, "email", normalizeEmail(u.Email))
+	u.NameBidx = blindIndex(k, "name", normalizeName(u.Name))
+	u.PhoneBidx = blindIndex(k, "phone", u.Phone)
+	u.DateOfBirthBidx = blindIndex(k, "date_of_birth", u.DateOfBirth.Format(dto.DateLayout))
+	u.EmailDomainBidx = blindIndex(k, "email_domain", emailDomain(u.Email))
+	u.PhonePrefixes = phonePrefixDocument(k, u.Phone)
+	u.SearchVector = searchDocument(k, u.Name, u.Email)
+	u.NamePhonetic = phoneticDocument(k, u.Name)
+	year := int16(u.DateOfBirth.Year())
+	u.BirthYear = &year
 	return nil
 }
 
-// GetByEmail retrieves a user by their email
-func (u *User) GetByEmail(ctx context.Context, email string) error {
-	if err := database.Client().WithContext(ctx).First(u, "email = ?", email).Error; err != nil {
-		return err
+// GormRepository stores users in Postgres through GORM
+type GormRepository struct {
+	db   *gorm.DB
+	keys *encryption.Keyring
+}
+
+// NewGormRepository creates a repository backed by db, encrypting PII with keys; a nil keys
+// stores PII in plaintext
+func NewGormRepository(db *gorm.DB, keys *encryption.Keyring) *GormRepository {
+	return &GormRepository{db: db, keys: keys}
+}
+
+// conn returns db for a query in ctx, with the keyring the pii serializer and hooks encrypt with
+func (r *GormRepository) conn(ctx context.Context) *gorm.DB {
+	return database.Conn(encryption.WithKeyring(ctx, r.keys), r.db)
+}
+
+// Transaction runs fn in a database transaction that the repositories given its context join
+func (r *GormRepository) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.Transaction(ctx, r.db, fn)
+}
+
+// Create inserts a new user record into the database
+func (r *GormRepository) Create(ctx context.Context, u *User) error {
+	if err := r.conn(ctx).Create(u).Error; err != nil {
+		logging.FromContext(ctx).Error("Unable to create user", logging.Err(err))
+		return duplicateError(err)
 	}
 	return nil
 }
 
+// GetByID retrieves a user by their UUID, including soft-deleted users when asked
+func (r *GormRepository) GetByID(ctx context.Context, id uuid.UUID, includeDeleted bool) (*User, error) {
+	db := r.conn(ctx)
+	if includeDeleted {
+		db = db.Unscoped()
+	}
+
+	u := New()
+	if err := db.Preload("AddressDetails").First(u, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			logging.FromContext(ctx).Debug("User not found", logging.Err(err))
+			return nil, err
+		}
+		logging.FromContext(ctx).Error("Error getting user", logging.Err(err))
+		return nil, err
+	}
+	return u, nil
+}
+
+// GetByEmail retrieves a live user by their email, ignoring case
+func (r *GormRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
+	db := r.conn(ctx)
+
+	// Rows written before encryption was enabled have no blind index yet
+	// Emails compare case-insensitively, like their blind index and the unique index on lower(email)
+	if bidx := blindIndex(r.keys, "email", normalizeEmail(email)); bidx != nil {
+		db = db.Where("email_bidx = ? OR (email_bidx IS NULL AND lower(email) = ?)", *bidx, normalizeEmail(email))
+	} else {
+		db = db.Where("lower(email) = ?", normalizeEmail(email))
+	}
+
+	u := New()
+	if err := db.First(u).Error; err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
 // GetByAadhaarApplicationID retrieves a user by their Aadhaar application ID
-func (u *User) GetByAadhaarApplicationID(ctx context.Context, aadhaarID string) error {
-	if err := database.Client().WithContext(ctx).First(u, "aadhaar_application_id = ?", aadhaarID).Error; err != nil {
-		return err
+func (r *GormRepository) GetByAadhaarApplicationID(ctx context.Context, aadhaarID string) (*User, error) {
+	u := New()
+	if err := r.conn(ctx).First(u, "aadhaar_application_id = ?", aadhaarID).Error; err != nil {
+		return nil, err
 	}
-	return nil
+	return u, nil
+}
+
+// List retrieves users with pagination, sorting, and optional search. Pages start after (or end
+// before) the row of params.Cursor when set, and at the offset of params.Page otherwise.
+func (r *GormRepository) List(ctx context.Context, params dto.PaginationParams) (*UserPage, error) {
+	if r.keys != nil {
+		if err := encryptedQueryError(params); err != nil {
+			return nil, err
+		}
+	}
+	if params.SearchMode != dto.SearchFuzzy || strings.TrimSpace(params.Search) == "" {
+		return r.list(ctx, r.conn(ctx), params)
+	}
+
+	// The similarity threshold of the % operator is a setting, scoped to a transaction here
+	var page *UserPage
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		threshold := strconv.FormatFloat(params.Similarity, 'g', -1, 64)
+		if err := tx.Exec("SELECT set_config('pg_trgm.similarity_threshold', ?, true)", threshold).Error; err != nil {
+			logging.FromContext(ctx).Error("Error setting similarity threshold", logging.Err(err))
+			return err
+		}
+		var err error
+		page, err = r.list(ctx, tx, params)
+		return err
+	})
+	return page, err
 }
 
-// GetAllPaginated retrieves users with pagination, sorting, and optional search
-func (u *User) GetAllPaginated(ctx context.Context, params dto.PaginationParams) ([]User, int64, error) {
-	var users []User
-	var total int64
+// list runs List on db
+func (r *GormRepository) list(ctx context.Context, db *gorm.DB, params dto.PaginationParams) (*UserPage, error) {
+	page := &UserPage{}
+
+	db = db.Model(&User{})
+
+	// Soft-deleted users are only listed on request
+	if params.IncludeDeleted {
+		db = db.Unscoped()
+	}
+
+	// Filter by state of the structured address
+	if params.State != "" {
+		db = db.Where("id IN (?)", r.db.Model(&Address{}).Select("user_id").Where("state = ?", params.State))
+	}
 
-	db := database.Client().WithContext(ctx).Model(&User{})
+	// Typed filters on user fields
+	db = r.filter(db, params.Filters)
 
 	// Apply search filter if provided (searches name, email, or aadhaar_application_id)
-	if params.Search != "" {
-		searchPattern := "%" + params.Search + "%"
-		db = db.Where("name ILIKE ? OR email ILIKE ? OR aadhaar_application_id ILIKE ?",
-			searchPattern, searchPattern, searchPattern)
+	var score *clause.Expr
+	if strings.TrimSpace(params.Search) != "" {
+		search, relevance := r.search(params)
+		db = db.Where(search)
+		score = &relevance
 	}
 
-	// Get total count before pagination
-	if err := db.Count(&total).Error; err != nil {
-		fmt.Printf("Error counting users: %v\n", err)
-		return nil, 0, err
+	// Counting scans every match, so it is only done on request
+	if params.WithTotal {
+		if err := db.Count(&page.Total).Error; err != nil {
+			logging.FromContext(ctx).Error("Error counting users", logging.Err(err))
+			return nil, err
+		}
 	}
 
-	// Apply sorting - using safe column mapping to prevent SQL injection
-	sortColumn := getSafeColumnName(params.SortBy)
+	// Apply sorting - using safe column mapping to prevent SQL injection.
+	// id breaks ties so pages don't overlap when sort values repeat.
+	sortColumn := sortColumn(params)
 	sortOrder := getSafeSortOrder(params.Order)
-	orderClause := fmt.Sprintf("%s %s", sortColumn, sortOrder)
 
-	// Calculate offset
-	offset := (params.Page - 1) * params.Limit
+	// Text columns are compared as stored; List refuses name and email while they are encrypted
+	columns, vars := "users.*", []any{}
+	if sortColumn != "created_at" && sortColumn != "score" {
+		columns += fmt.Sprintf(", %s AS sort_value", sortColumn)
+	}
+	if score != nil {
+		columns += ", ? AS score"
+		vars = append(vars, *score)
+	}
+	db = db.Select(columns, vars...)
+
+	if c := params.Cursor; c != nil {
+		// Keyset pagination: rows after the cursor row in listing order, or before it when paging
+		// backwards, fetched in reverse order and flipped below
+		var value any = c.Value
+		var err error
+		switch sortColumn {
+		case "created_at":
+			value, err = time.Parse(time.RFC3339Nano, c.Value)
+		case "score":
+			value, err = strconv.ParseFloat(c.Value, 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cursor value: %w", err)
+		}
 
-	// Apply pagination with LIMIT and OFFSET
-	if err := db.Order(orderClause).
-		Limit(params.Limit).
-		Offset(offset).
-		Find(&users).Error; err != nil {
-		fmt.Printf("Error getting users: %v\n", err)
-		return nil, 0, err
+		// The score alias is not visible in WHERE
+		var key any = clause.Expr{SQL: sortColumn}
+		if sortColumn == "score" {
+			key = *score
+		}
+
+		op := ">"
+		if (sortOrder == "DESC") != c.Backward {
+			op = "<"
+		}
+		if c.Backward {
+			sortOrder = reverseSortOrder(sortOrder)
+		}
+		db = db.Where(fmt.Sprintf("((?), id) %s (?, ?)", op), key, value, c.ID)
+	} else {
+		db = db.Offset((params.Page - 1) * params.Limit)
+	}
+
+	// One extra row tells whether another page follows
+	if err := db.Preload("AddressDetails").
+		Order(fmt.Sprintf("%s %s, id %s", sortColumn, sortOrder, sortOrder)).
+		Limit(params.Limit + 1).
+		Find(&page.Users).Error; err != nil {
+		logging.FromContext(ctx).Error("Error getting users", logging.Err(err))
+		return nil, err
 	}
 
-	return users, total, nil
+	if len(page.Users) > params.Limit {
+		page.Users, page.More = page.Users[:params.Limit], true
+	}
+	if params.Cursor != nil && params.Cursor.Backward {
+		slices.Reverse(page.Users)
+	}
+
+	return page, nil
 }
 
-// Delete removes a user from the database
-func (u *User) Delete(ctx context.Context) error {
-	if err := database.Client().WithContext(ctx).Delete(u).Error; err != nil {
-		fmt.Printf("Error deleting user: %v\n", err)
-		return err
+// Update writes the editable fields and structured address of the user back to the database.
+// updated_at is left to the update_users_updated_at trigger and read back via RETURNING.
+func (r *GormRepository) Update(ctx context.Context, u *User) error {
+	return r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(u).
+			Clauses(clause.Returning{}).
+			Select("aadhaar_application_id", "name", "email", "phone", "address", "date_of_birth", "gender",
+				"email_bidx", "name_bidx", "phone_bidx", "dob_bidx", "birth_year", "email_domain_bidx", "phone_prefixes",
+				"search_vector", "name_phonetic", "pii_key_id").
+			Omit("updated_at").
+			Updates(u)
+		if result.Error != nil {
+			logging.FromContext(ctx).Error("Error updating user", logging.Err(result.Error))
+			return duplicateError(result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return u.saveAddress(tx)
+	})
+}
+
+// Delete soft-deletes a user by setting deleted_at
+func (r *GormRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result := r.conn(ctx).Delete(&User{}, "id = ?", id)
+	if result.Error != nil {
+		logging.FromContext(ctx).Error("Error deleting user", logging.Err(result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Restore clears deleted_at on a soft-deleted user
+func (r *GormRepository) Restore(ctx context.Context, u *User) error {
+	result := r.conn(ctx).Unscoped().
+		Model(u).
+		Where("deleted_at IS NOT NULL").
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		logging.FromContext(ctx).Error("Error restoring user", logging.Err(result.Error))
+		return duplicateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
 	}
+	u.DeletedAt = gorm.DeletedAt{}
 	return nil
 }
 
+// Purge permanently removes users soft-deleted before the given time
+func (r *GormRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	result := r.conn(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Delete(&User{})
+	if result.Error != nil {
+		logging.FromContext(ctx).Error("Error purging users", logging.Err(result.Error))
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// uniqueViolation is the Postgres error code of a unique index violation
+const uniqueViolation = "23505"
+
+// duplicateError maps a unique violation on the email or Aadhaar Application ID indexes to its
+// repository error, e.g. when two requests enrol the same email at once
+func duplicateError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != uniqueViolation {
+		return err
+	}
+
+	switch pgErr.ConstraintName {
+	case "idx_users_email", "idx_users_email_bidx":
+		return ErrDuplicateEmail
+	case "idx_users_aadhaar_application_id":
+		return ErrDuplicateAadhaarID
+	}
+	return err
+}
+
 // getSafeColumnName maps user input to safe column names to prevent SQL injection
 func getSafeColumnName(column string) string {
 	safeColumns := map[string]string{
-		"name":                    "name",
-		"email":                   "email",
-		"created_at":              "created_at",
-		"aadhaar_application_id":  "aadhaar_application_id",
+		"name":                   "name",
+		"email":                  "email",
+		"created_at":             "created_at",
+		"aadhaar_application_id": "aadhaar_application_id",
+		"relevance":              "score",
 	}
 
 	if safe, ok := safeColumns[column]; ok {
@@ -142,6 +428,24 @@ func getSafeColumnName(column string) string {
 	return "created_at" // default
 }
 
+// sortColumn returns the column a listing is sorted by. Relevance needs a search term to rank
+// by and falls back to created_at without one.
+func sortColumn(params dto.PaginationParams) string {
+	column := getSafeColumnName(params.SortBy)
+	if column == "score" && strings.TrimSpace(params.Search) == "" {
+		return "created_at"
+	}
+	return column
+}
+
+// reverseSortOrder returns the opposite of a sort order returned by getSafeSortOrder
+func reverseSortOrder(order string) string {
+	if order == "ASC" {
+		return "DESC"
+	}
+	return "ASC"
+}
+
 // getSafeSortOrder validates sort order to prevent SQL injection
 func getSafeSortOrder(order string) string {
 	if order == "asc" || order == "ASC" {
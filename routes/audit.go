@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"aadhaar-user-service/controllers/audit"
+	"aadhaar-user-service/internals/rbac"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Audit registers audit trail routes
+func Audit(r fiber.Router, h *audit.Handler) {
+	a := r.Group("/audit")
+
+	a.Get("/", rbac.Require(rbac.AuditRead), h.GetAll)         // List audit events with filtering
+	a.Get("/verify", rbac.Require(rbac.AuditVerify), h.Verify) // Verify hash chain integrity
+}
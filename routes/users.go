@@ -2,16 +2,24 @@ package routes
 
 import (
 	"aadhaar-user-service/controllers/users"
+	"aadhaar-user-service/internals/rbac"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 // Users registers user routes
-func Users(r fiber.Router) {
+func Users(r fiber.Router, h *users.Handler) {
 	u := r.Group("/users")
 
-	u.Post("/", users.Add)      // Create a new user
-	u.Get("/", users.GetAll)    // List users with pagination and sorting
-	u.Get("/:id", users.Get)    // Get user by ID
-	u.Delete("/:id", users.Delete) // Delete user by ID
+	u.Post("/", rbac.Require(rbac.UsersCreate), h.Add)         // Create a new user
+	u.Post("/purge", rbac.Require(rbac.UsersPurge), h.Purge)   // Permanently remove old soft-deleted users
+	u.Get("/", rbac.Require(rbac.UsersList), h.GetAll)         // List users with pagination and sorting
+	u.Get("/export", rbac.Require(rbac.UsersExport), h.Export) // Export the listed users as CSV
+	u.Get("/:id", rbac.Require(rbac.UsersRead), h.Get)         // Get user by ID
+	u.Put("/:id", rbac.Require(rbac.UsersUpdate), h.Update)    // Replace user's editable fields
+	u.Patch("/:id", rbac.Require(rbac.UsersUpdate), h.Patch)   // Partially update user (JSON Merge Patch)
+	u.Delete("/:id", rbac.Require(rbac.UsersDelete), h.Delete) // Soft-delete user by ID
+
+	u.Post("/:id/restore", rbac.Require(rbac.UsersRestore), h.Restore)   // Restore a soft-deleted user
+	u.Get("/:id/duplicates", rbac.Require(rbac.UsersRead), h.Duplicates) // Likely duplicates of a user
 }
@@ -0,0 +1,231 @@
+package audit
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"aadhaar-user-service/internals/auth"
+	"aadhaar-user-service/internals/dto"
+	"aadhaar-user-service/internals/encryption"
+	"aadhaar-user-service/internals/metrics"
+	"aadhaar-user-service/internals/tracing"
+	"aadhaar-user-service/models/audit"
+
+	"github.com/google/uuid"
+)
+
+// Actions recorded in the audit trail
+const (
+	ActionCreate  = "create"
+	ActionRead    = "read"
+	ActionList    = "list"
+	ActionExport  = "export"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionPurge   = "purge"
+)
+
+// verifyBatchSize is the number of events read per query when verifying the chain
+const verifyBatchSize = 1000
+
+type requestKey struct{}
+
+// request holds the request metadata recorded with each event
+type request struct {
+	ID string
+	IP string
+}
+
+// WithRequest returns a context carrying the request ID and client IP for audit events
+func WithRequest(ctx context.Context, requestID, ip string) context.Context {
+	// Client-supplied IDs are capped to the column size
+	if len(requestID) > maxRequestIDLength {
+		requestID = requestID[:maxRequestIDLength]
+	}
+	return context.WithValue(ctx, requestKey{}, request{ID: requestID, IP: ip})
+}
+
+// maxRequestIDLength matches the audit_events.request_id column
+const maxRequestIDLength = 64
+
+// Entry describes an operation to record
+type Entry struct {
+	Action        string
+	TargetUserID  *uuid.UUID
+	ChangedFields map[string]audit.FieldChange
+	Details       map[string]any
+}
+
+// Recorder appends entries to the audit trail
+type Recorder struct {
+	repo audit.EventRepository
+	keys *encryption.Keyring
+
+	// reads group-commits read and list events
+	reads *batcher
+}
+
+// NewRecorder creates a Recorder appending to repo and hashing changed values with keys.
+// The sizes of read event batches are recorded into m unless it is nil.
+func NewRecorder(repo audit.EventRepository, keys *encryption.Keyring, m *metrics.Metrics) *Recorder {
+	return &Recorder{repo: repo, keys: keys, reads: &batcher{repo: repo, metrics: m}}
+}
+
+// Record appends an entry to the audit trail, attributed to the caller in ctx
+func (r *Recorder) Record(ctx context.Context, entry Entry) error {
+	event := audit.New()
+	event.OccurredAt = time.Now()
+	event.Actor = "anonymous"
+	if p, ok := auth.FromContext(ctx); ok {
+		event.Actor = p.Subject
+	}
+	if r, ok := ctx.Value(requestKey{}).(request); ok {
+		event.RequestID = r.ID
+		event.IP = r.IP
+	}
+	event.Action = entry.Action
+	event.TargetUserID = entry.TargetUserID
+	event.ChangedFields = entry.ChangedFields
+	event.Details = entry.Details
+
+	// Reads are the bulk of the trail and change nothing to commit with, so concurrent ones
+	// share a transaction; changes are appended in the transaction of the change
+	if entry.Action == ActionRead || entry.Action == ActionList || entry.Action == ActionExport {
+		return r.reads.append(ctx, event)
+	}
+	return r.repo.Append(ctx, event)
+}
+
+// Changes returns keyed hashes of the fields that differ between before and after.
+// A missing before (creation) or after (removal) map leaves that side empty, and so does a
+// Recorder without keys, which records only which fields changed.
+func (r *Recorder) Changes(before, after map[string]string) map[string]audit.FieldChange {
+	changes := make(map[string]audit.FieldChange)
+	for field, value := range after {
+		if old, ok := before[field]; ok && old == value {
+			continue
+		}
+		change := audit.FieldChange{After: r.hashValue(field, value)}
+		if old, ok := before[field]; ok {
+			change.Before = r.hashValue(field, old)
+		}
+		changes[field] = change
+	}
+	for field, old := range before {
+		if _, ok := after[field]; !ok {
+			changes[field] = audit.FieldChange{Before: r.hashValue(field, old)}
+		}
+	}
+	return changes
+}
+
+// hashValue hashes a field value without revealing it, or returns "" without keys. The hash is
+// always keyed: an unkeyed one of a low-entropy value such as a phone number or date of birth
+// would be brute-forced from the trail in seconds.
+func (r *Recorder) hashValue(field, value string) string {
+	if r.keys == nil {
+		return ""
+	}
+	return r.keys.BlindIndex("audit:"+field, value)
+}
+
+// AuditService handles audit trail queries
+type AuditService struct {
+	Events       *dto.AuditEvents
+	Verification *dto.AuditVerification
+
+	repo audit.EventRepository
+}
+
+// New creates a new AuditService instance reading from repo
+func New(repo audit.EventRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// GetAllPaginated retrieves audit events matching the filter
+func (s *AuditService) GetAllPaginated(ctx context.Context, filter dto.AuditFilter) (err error) {
+	ctx, span := tracing.Start(ctx, "AuditService.GetAllPaginated")
+	defer func() { tracing.End(span, err) }()
+
+	events, total, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	// Map to DTOs
+	eventDTOs := make([]dto.AuditEvent, len(events))
+	for i, e := range events {
+		eventDTOs[i] = toDTO(e)
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(total) / float64(filter.Limit)))
+
+	s.Events = &dto.AuditEvents{
+		Events:     eventDTOs,
+		Total:      total,
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+		TotalPages: totalPages,
+	}
+
+	return nil
+}
+
+// Verify walks the whole chain, checking each event's hash and its link to the previous event
+func (s *AuditService) Verify(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "AuditService.Verify")
+	defer func() { tracing.End(span, err) }()
+
+	result := &dto.AuditVerification{Valid: true}
+	prevHash := audit.GenesisHash
+
+	err = s.repo.Walk(ctx, verifyBatchSize, func(e audit.Event) bool {
+		result.Checked++
+
+		switch {
+		case e.PrevHash != prevHash:
+			result.Reason = "previous hash does not match the preceding event"
+		case e.ComputeHash() != e.Hash:
+			result.Reason = "event content does not match its hash"
+		default:
+			prevHash = e.Hash
+			return true
+		}
+
+		result.Valid = false
+		result.BrokenAtID = e.ID
+		return false
+	})
+	if err != nil {
+		return err
+	}
+
+	s.Verification = result
+	return nil
+}
+
+// toDTO maps an audit event model to its response DTO
+func toDTO(e audit.Event) dto.AuditEvent {
+	event := dto.AuditEvent{
+		ID:           e.ID,
+		OccurredAt:   e.OccurredAt,
+		Actor:        e.Actor,
+		Action:       e.Action,
+		TargetUserID: e.TargetUserID,
+		RequestID:    e.RequestID,
+		IP:           e.IP,
+		Details:      e.Details,
+		PrevHash:     e.PrevHash,
+		Hash:         e.Hash,
+	}
+	if len(e.ChangedFields) > 0 {
+		event.ChangedFields = make(map[string]dto.AuditFieldChange, len(e.ChangedFields))
+		for field, change := range e.ChangedFields {
+			event.ChangedFields[field] = dto.AuditFieldChange{Before: change.Before, After: change.After}
+		}
+	}
+	return event
+}
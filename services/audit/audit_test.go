@@ -0,0 +1,53 @@
+package audit
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"aadhaar-user-service/internals/encryption"
+	"aadhaar-user-service/models/audit"
+)
+
+func testKeyring(t *testing.T, blindIndexKey byte) *encryption.Keyring {
+	t.Helper()
+
+	key := func(b byte) string { return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32))) }
+	k, err := encryption.NewKeyring(map[string]string{"k1": key('m')}, "k1", key(blindIndexKey))
+	if err != nil {
+		t.Fatalf("keyring: %v", err)
+	}
+	return k
+}
+
+func TestChangesWithoutKeysNameFieldsOnly(t *testing.T) {
+	r := NewRecorder(audit.NewMemoryRepository(), nil, nil)
+
+	changes := r.Changes(map[string]string{"phone": "9800000001", "name": "Asha Rao"}, map[string]string{"phone": "9800000002", "name": "Asha Rao"})
+	if len(changes) != 1 {
+		t.Fatalf("changes %v, want phone only", changes)
+	}
+	if change, ok := changes["phone"]; !ok || change != (audit.FieldChange{}) {
+		t.Errorf("phone change %+v, want no hashes", change)
+	}
+}
+
+func TestChangesAreKeyed(t *testing.T) {
+	before := map[string]string{"phone": "9800000001"}
+	after := map[string]string{"phone": "9800000002", "email": "asha@example.com"}
+
+	changes := NewRecorder(audit.NewMemoryRepository(), testKeyring(t, 'a'), nil).Changes(before, after)
+	phone := changes["phone"]
+	if phone.Before == "" || phone.After == "" || phone.Before == phone.After {
+		t.Errorf("phone change %+v, want two distinct hashes", phone)
+	}
+	if email := changes["email"]; email.Before != "" || email.After == "" {
+		t.Errorf("email change %+v, want an after hash only", email)
+	}
+
+	// Another key hashes the same values differently
+	other := NewRecorder(audit.NewMemoryRepository(), testKeyring(t, 'b'), nil).Changes(before, after)
+	if other["phone"] == phone {
+		t.Error("hashes do not depend on the key")
+	}
+}
@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"context"
+	"sync"
+
+	"aadhaar-user-service/internals/metrics"
+	"aadhaar-user-service/models/audit"
+)
+
+// maxReadBatch caps the events appended in one transaction
+const maxReadBatch = 100
+
+// batcher appends events in group commits. Appending takes the chain lock, so concurrent
+// appends would queue on it one transaction each; instead the first caller appends everything
+// queued by then in one transaction, and the events queued meanwhile go together in the next.
+// A lone caller is not delayed.
+type batcher struct {
+	repo    audit.EventRepository
+	metrics *metrics.Metrics
+
+	mu sync.Mutex
+	// busy is set while a caller is appending a batch
+	busy  bool
+	queue []*waiter
+}
+
+// waiter is an event waiting to be appended
+type waiter struct {
+	event *audit.Event
+	// result receives the outcome of the batch the event was appended in
+	result chan error
+	// lead tells the first waiter to append the next batch
+	lead chan struct{}
+}
+
+// append queues e and returns once the batch it went into is committed
+func (b *batcher) append(ctx context.Context, e *audit.Event) error {
+	w := &waiter{event: e, result: make(chan error, 1), lead: make(chan struct{}, 1)}
+
+	b.mu.Lock()
+	b.queue = append(b.queue, w)
+	if b.busy {
+		b.mu.Unlock()
+		select {
+		case err := <-w.result:
+			return err
+		case <-w.lead:
+		}
+		b.mu.Lock()
+	}
+	b.busy = true
+
+	// w is first in the queue: it was empty, or w was handed the lead
+	batch := b.queue[:min(len(b.queue), maxReadBatch)]
+	b.queue = b.queue[len(batch):]
+	b.mu.Unlock()
+
+	events := make([]*audit.Event, len(batch))
+	for i, o := range batch {
+		events[i] = o.event
+	}
+	// The batch holds other callers' events, so it is not given up when this caller's request is
+	err := b.repo.AppendBatch(context.WithoutCancel(ctx), events)
+	b.metrics.AuditReadBatch(len(batch))
+	for _, o := range batch {
+		o.result <- err
+	}
+
+	b.mu.Lock()
+	if len(b.queue) > 0 {
+		b.queue[0].lead <- struct{}{}
+	} else {
+		b.busy = false
+	}
+	b.mu.Unlock()
+
+	return <-w.result
+}
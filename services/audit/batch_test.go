@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"aadhaar-user-service/models/audit"
+)
+
+// slowTrail is an in-memory audit trail whose batches take a while, like a contended lock
+type slowTrail struct {
+	*audit.MemoryRepository
+	batches atomic.Int32
+	err     error
+}
+
+func (t *slowTrail) AppendBatch(ctx context.Context, events []*audit.Event) error {
+	t.batches.Add(1)
+	time.Sleep(5 * time.Millisecond)
+	if t.err != nil {
+		return t.err
+	}
+	return t.MemoryRepository.AppendBatch(ctx, events)
+}
+
+// recordReads records n reads concurrently and returns their errors
+func recordReads(r *Recorder, n int) []error {
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = r.Record(context.Background(), Entry{Action: ActionRead})
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+func TestReadsAreGroupCommitted(t *testing.T) {
+	const n = 50
+	trail := &slowTrail{MemoryRepository: audit.NewMemoryRepository()}
+	r := NewRecorder(trail, nil, nil)
+
+	for i, err := range recordReads(r, n) {
+		if err != nil {
+			t.Fatalf("read %d: %v", i, err)
+		}
+	}
+
+	if batches := trail.batches.Load(); batches >= n {
+		t.Errorf("%d reads appended in %d batches, want fewer", n, batches)
+	}
+
+	var count int
+	prev := audit.GenesisHash
+	err := trail.Walk(context.Background(), 10, func(e audit.Event) bool {
+		if e.PrevHash != prev || e.Hash != e.ComputeHash() {
+			t.Errorf("event %d does not link to its predecessor", e.ID)
+		}
+		prev = e.Hash
+		count++
+		return true
+	})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	if count != n {
+		t.Errorf("%d events in the trail, want %d", count, n)
+	}
+}
+
+func TestReadBatchErrorsReachEveryCaller(t *testing.T) {
+	errAppend := errors.New("audit trail unavailable")
+	trail := &slowTrail{MemoryRepository: audit.NewMemoryRepository(), err: errAppend}
+	r := NewRecorder(trail, nil, nil)
+
+	for i, err := range recordReads(r, 20) {
+		if !errors.Is(err, errAppend) {
+			t.Errorf("read %d: error %v, want %v", i, err, errAppend)
+		}
+	}
+
+	// The batcher recovers once appends succeed again
+	trail.err = nil
+	if err := r.Record(context.Background(), Entry{Action: ActionList}); err != nil {
+		t.Errorf("list after failures: %v", err)
+	}
+}
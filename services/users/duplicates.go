@@ -0,0 +1,175 @@
+package users
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"slices"
+
+	"aadhaar-user-service/internals/dedup"
+	"aadhaar-user-service/internals/dto"
+	"aadhaar-user-service/internals/logging"
+	"aadhaar-user-service/internals/tracing"
+	"aadhaar-user-service/models/users"
+	"aadhaar-user-service/services/audit"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// candidateLimit is the number of candidates scored per user by duplicate detection
+const candidateLimit = 100
+
+// scanBatchSize is the number of users loaded per query by DuplicateReport
+const scanBatchSize = 500
+
+// duplicatesOf scores the candidates for being the same applicant as u and returns those
+// scoring at least threshold, best first
+func (s *UserService) duplicatesOf(ctx context.Context, u *users.User, threshold float64) ([]dto.Duplicate, error) {
+	candidates, err := s.repo.Candidates(ctx, u, candidateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []dto.Duplicate
+	for i := range candidates {
+		if d := compare(u, &candidates[i]); d.Score >= threshold {
+			found = append(found, d)
+		}
+	}
+
+	slices.SortStableFunc(found, func(a, b dto.Duplicate) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	return found, nil
+}
+
+// FindDuplicates finds the live users that are likely the same applicant as a user
+func (s *UserService) FindDuplicates(ctx context.Context, id string, params dto.DuplicatesParams) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.FindDuplicates")
+	defer func() { tracing.End(span, err) }()
+
+	// Parse UUID
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrInvalidUUID
+	}
+
+	user, err := s.repo.GetByID(ctx, parsedID, false)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	found, err := s.duplicatesOf(ctx, user, params.MinScore)
+	if err != nil {
+		return err
+	}
+
+	// The user was read to compare it, and the duplicates disclosed
+	if err := s.audit.Record(ctx, audit.Entry{
+		Action:       audit.ActionRead,
+		TargetUserID: &user.ID,
+		Details:      map[string]any{"duplicates": duplicateIDs(found)},
+	}); err != nil {
+		return err
+	}
+
+	s.Duplicates = &dto.Duplicates{
+		UserID:     user.ID,
+		MinScore:   params.MinScore,
+		Duplicates: found,
+	}
+	if s.Duplicates.Duplicates == nil {
+		s.Duplicates.Duplicates = []dto.Duplicate{}
+	}
+
+	return nil
+}
+
+// DuplicateReport scans every live user for likely duplicates scoring at least threshold and
+// sets Report to the pairs found, best first. Each pair is reported once, the older user first.
+func (s *UserService) DuplicateReport(ctx context.Context, threshold float64) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.DuplicateReport")
+	defer func() { tracing.End(span, err) }()
+
+	params := dto.DefaultPaginationParams()
+	params.Limit, params.Order, params.WithTotal = scanBatchSize, "asc", false
+
+	s.Report, s.Scanned = nil, 0
+	for {
+		page, err := s.repo.List(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		for i := range page.Users {
+			u := &page.Users[i]
+			candidates, err := s.repo.Candidates(ctx, u, candidateLimit)
+			if err != nil {
+				return err
+			}
+
+			// Pairs with users enrolled later are reported on their turn
+			for j := range candidates {
+				c := &candidates[j]
+				if d := compare(c, u); d.Score >= threshold && older(c, u) {
+					s.Report = append(s.Report, dto.DuplicatePair{UserID: c.ID, Duplicate: d})
+				}
+			}
+		}
+		s.Scanned += len(page.Users)
+		logging.FromContext(ctx).Debug("Scanned batch for duplicates", slog.Int("scanned", s.Scanned), slog.Int("pairs", len(s.Report)))
+
+		if !page.More {
+			break
+		}
+		next := page.Users[len(page.Users)-1].Cursor(params, false)
+		params.Cursor = &next
+	}
+
+	slices.SortStableFunc(s.Report, func(a, b dto.DuplicatePair) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	return nil
+}
+
+// compare scores other for being the same applicant as u
+func compare(u, other *users.User) dto.Duplicate {
+	scores := dedup.Compare(toApplicant(u), toApplicant(other))
+	return dto.Duplicate{
+		UserID: other.ID,
+		Score:  scores.Total(),
+		Fields: dto.DuplicateFields(scores),
+	}
+}
+
+// toApplicant returns what duplicate detection compares of a user
+func toApplicant(u *users.User) dedup.Applicant {
+	return dedup.Applicant{
+		Name:        u.Name,
+		DateOfBirth: u.DateOfBirth,
+		Gender:      u.Gender,
+		Phone:       u.Phone,
+		Address:     u.Address,
+	}
+}
+
+// duplicateIDs returns the user IDs of duplicates
+func duplicateIDs(found []dto.Duplicate) []string {
+	ids := make([]string, len(found))
+	for i, d := range found {
+		ids[i] = d.UserID.String()
+	}
+	return ids
+}
+
+// older reports whether a was enrolled before b, by ID when enrolled at the same time
+func older(a, b *users.User) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID.String() < b.ID.String()
+}
@@ -0,0 +1,114 @@
+package users
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"aadhaar-user-service/internals/dto"
+	"aadhaar-user-service/internals/tracing"
+	"aadhaar-user-service/services/audit"
+)
+
+// exportPageSize is the number of users read per query by Export
+const exportPageSize = 100
+
+// MaxExportRows is the most users one export may hold; larger ones must be narrowed by filters
+const MaxExportRows = 10000
+
+var ErrExportTooLarge = errors.New("export exceeds the row limit")
+
+// exportColumns are the CSV header of an export
+var exportColumns = []string{
+	"id", "aadhaar_application_id", "name", "email", "phone", "address",
+	"date_of_birth", "gender", "created_at", "updated_at", "deleted_at",
+}
+
+// Export writes the users matching the sorting, search and filters of params to w as CSV, masked
+// as in listings. Users are read a page at a time with cursors, and every page is recorded in
+// the audit trail as an export of its users. More than MaxExportRows matches fail with
+// ErrExportTooLarge, before any user is recorded when the count already exceeds it.
+func (s *UserService) Export(ctx context.Context, params dto.PaginationParams, w io.Writer) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.Export")
+	defer func() { tracing.End(span, err) }()
+
+	params.Page, params.Limit, params.Cursor = 1, exportPageSize, nil
+	params.WithTotal = true
+
+	out := csv.NewWriter(w)
+	if err := out.Write(exportColumns); err != nil {
+		return err
+	}
+
+	rows := 0
+	for {
+		page, err := s.repo.List(ctx, params)
+		if err != nil {
+			return err
+		}
+		if params.WithTotal && page.Total > MaxExportRows {
+			return ErrExportTooLarge
+		}
+		params.WithTotal = false
+
+		// Users enrolled while paging may still take the export past the limit
+		if rows += len(page.Users); rows > MaxExportRows {
+			return ErrExportTooLarge
+		}
+
+		userIDs := make([]string, len(page.Users))
+		for i, u := range page.Users {
+			userIDs[i] = u.ID.String()
+		}
+		if err := s.audit.Record(ctx, audit.Entry{
+			Action: audit.ActionExport,
+			Details: map[string]any{
+				"include_deleted": params.IncludeDeleted,
+				"user_ids":        userIDs,
+			},
+		}); err != nil {
+			return err
+		}
+
+		for i := range page.Users {
+			if err := out.Write(exportRow(toDTO(ctx, &page.Users[i]))); err != nil {
+				return err
+			}
+		}
+
+		if !page.More || len(page.Users) == 0 {
+			break
+		}
+		last := page.Users[len(page.Users)-1].Cursor(params, false)
+		params.Cursor = &last
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// exportRow returns the CSV cells of a user, in the order of exportColumns
+func exportRow(u *dto.User) []string {
+	timestamp := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		u.ID.String(), cell(u.AadhaarApplicationID), cell(u.Name), cell(u.Email), cell(u.Phone),
+		cell(u.Address), u.DateOfBirth, cell(u.Gender),
+		timestamp(u.CreatedAt), timestamp(u.UpdatedAt), timestamp(u.DeletedAt),
+	}
+}
+
+// cell escapes a value that a spreadsheet would otherwise evaluate as a formula
+func cell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,57 @@
+package users
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"aadhaar-user-service/internals/dto"
+	modelaudit "aadhaar-user-service/models/audit"
+	"aadhaar-user-service/models/users"
+	"aadhaar-user-service/services/audit"
+)
+
+func TestExportTooLarge(t *testing.T) {
+	ctx := context.Background()
+	repo := users.NewMemoryRepository(nil)
+	for n := range MaxExportRows + 1 {
+		u := users.New()
+		u.AadhaarApplicationID = fmt.Sprintf("2%013d", n)
+		u.Name = "Asha Rao"
+		u.Email = fmt.Sprintf("applicant%d@example.com", n)
+		u.Phone = fmt.Sprintf("98%08d", n)
+		u.DateOfBirth = time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC)
+		if err := repo.Create(ctx, u); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	trail := modelaudit.NewMemoryRepository()
+	var out bytes.Buffer
+	err := New(repo, audit.NewRecorder(trail, nil, nil), nil).Export(ctx, dto.DefaultPaginationParams(), &out)
+	if !errors.Is(err, ErrExportTooLarge) {
+		t.Fatalf("export: error %v, want %v", err, ErrExportTooLarge)
+	}
+	if _, total, err := trail.List(ctx, dto.AuditFilter{Page: 1, Limit: 10}); err != nil || total != 0 {
+		t.Errorf("%d audit events (%v), want none for a refused export", total, err)
+	}
+}
+
+func TestExportCells(t *testing.T) {
+	tests := []struct{ value, want string }{
+		{"Asha Rao", "Asha Rao"},
+		{"=HYPERLINK(\"http://example.com\")", "'=HYPERLINK(\"http://example.com\")"},
+		{"+91 98000 00001", "'+91 98000 00001"},
+		{"-1", "'-1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := cell(tt.value); got != tt.want {
+			t.Errorf("cell(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
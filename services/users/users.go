@@ -3,10 +3,20 @@ package users
 import (
 	"context"
 	"errors"
+	"log/slog"
 	"math"
+	"strings"
+	"time"
 
+	"aadhaar-user-service/internals/dedup"
 	"aadhaar-user-service/internals/dto"
+	"aadhaar-user-service/internals/geo"
+	"aadhaar-user-service/internals/logging"
+	"aadhaar-user-service/internals/masking"
+	"aadhaar-user-service/internals/metrics"
+	"aadhaar-user-service/internals/tracing"
 	"aadhaar-user-service/models/users"
+	"aadhaar-user-service/services/audit"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -17,30 +27,64 @@ var (
 	ErrEmailExists     = errors.New("email already exists")
 	ErrAadhaarIDExists = errors.New("aadhaar application id already exists")
 	ErrInvalidUUID     = errors.New("invalid uuid format")
+	ErrUserNotDeleted  = errors.New("user is not deleted")
+	ErrInvalidDOB      = errors.New("invalid date of birth")
+	ErrLikelyDuplicate = errors.New("likely duplicate of an existing user")
 )
 
+// rotateBatchSize is the number of rows re-encrypted per query during key rotation
+const rotateBatchSize = 500
+
+// reindexBatchSize is the number of rows reindexed per query by Reindex
+const reindexBatchSize = 500
+
 // UserService handles user business logic
 type UserService struct {
-	User  *dto.User
-	Users *dto.Users
+	User        *dto.User
+	Users       *dto.Users
+	NextPage    *dto.Cursor
+	PrevPage    *dto.Cursor
+	Purged      *dto.PurgeResult
+	Reencrypted int
+	Reindexed   int
+
+	// Duplicates are the likely duplicates of a user; on ErrLikelyDuplicate those of the applicant
+	Duplicates *dto.Duplicates
+	Report     []dto.DuplicatePair
+	Scanned    int
+
+	repo    users.UserRepository
+	audit   *audit.Recorder
+	metrics *metrics.Metrics
 }
 
-// New creates a new UserService instance
-func New() *UserService {
-	return &UserService{}
+// New creates a new UserService instance storing users in repo, recording to the audit trail and
+// counting into m, which may be nil
+func New(repo users.UserRepository, recorder *audit.Recorder, m *metrics.Metrics) *UserService {
+	return &UserService{repo: repo, audit: recorder, metrics: m}
 }
 
-// Create creates a new user after validation
-func (s *UserService) Create(ctx context.Context, input dto.UserCreate) error {
+// Create creates a new user after validation. Depending on the policy, an applicant that likely
+// duplicates a live user is rejected with ErrLikelyDuplicate or enrolled with the duplicates
+// flagged.
+func (s *UserService) Create(ctx context.Context, input dto.UserCreate, policy dedup.Policy) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.Create")
+	defer func() { tracing.End(span, err) }()
+
+	// Parse date of birth
+	dob, err := dto.ParseDate(input.DateOfBirth)
+	if err != nil {
+		return ErrInvalidDOB
+	}
+
 	// Check if email already exists
-	existingUser := users.New()
-	if err := existingUser.GetByEmail(ctx, input.Email); err == nil {
-		return ErrEmailExists
+	if _, err := s.repo.GetByEmail(ctx, input.Email); err == nil {
+		return s.duplicate(ErrEmailExists, "create")
 	}
 
 	// Check if Aadhaar Application ID already exists
-	if err := existingUser.GetByAadhaarApplicationID(ctx, input.AadhaarApplicationID); err == nil {
-		return ErrAadhaarIDExists
+	if _, err := s.repo.GetByAadhaarApplicationID(ctx, input.AadhaarApplicationID); err == nil {
+		return s.duplicate(ErrAadhaarIDExists, "create")
 	}
 
 	// Create new user
@@ -50,122 +94,534 @@ func (s *UserService) Create(ctx context.Context, input dto.UserCreate) error {
 	user.Email = input.Email
 	user.Phone = input.Phone
 	user.Address = input.Address
-	user.DateOfBirth = input.DateOfBirth
+	user.AddressDetails = toAddressModel(input.AddressDetails)
+	if user.AddressDetails != nil {
+		user.Address = addressLine(user.AddressDetails)
+	}
+	user.DateOfBirth = dob
 	user.Gender = input.Gender
 
-	if err := user.Create(ctx); err != nil {
+	// Check if the applicant is already enrolled under another email or application ID
+	var duplicates []dto.Duplicate
+	if policy.Mode != dedup.Off {
+		if duplicates, err = s.duplicatesOf(ctx, user, policy.Threshold); err != nil {
+			return err
+		}
+		if len(duplicates) > 0 && policy.Mode == dedup.Strict {
+			s.Duplicates = &dto.Duplicates{MinScore: policy.Threshold, Duplicates: duplicates}
+			return s.duplicate(ErrLikelyDuplicate, "create")
+		}
+	}
+
+	// The user is only stored together with its audit entry
+	if err := s.repo.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, user); err != nil {
+			return s.conflict(err, "create")
+		}
+
+		entry := audit.Entry{
+			Action:        audit.ActionCreate,
+			TargetUserID:  &user.ID,
+			ChangedFields: s.audit.Changes(nil, auditValues(user)),
+		}
+		if len(duplicates) > 0 {
+			entry.Details = map[string]any{"possible_duplicates": duplicateIDs(duplicates)}
+		}
+		return s.audit.Record(ctx, entry)
+	}); err != nil {
 		return err
 	}
+	s.metrics.UserCreated()
+	logging.FromContext(ctx).Info("User created", slog.String("user_id", user.ID.String()))
 
 	// Map to DTO
-	s.User = &dto.User{
-		ID:                   user.ID,
-		AadhaarApplicationID: user.AadhaarApplicationID,
-		Name:                 user.Name,
-		Email:                user.Email,
-		Phone:                user.Phone,
-		Address:              user.Address,
-		DateOfBirth:          user.DateOfBirth,
-		Gender:               user.Gender,
-		CreatedAt:            &user.CreatedAt,
-	}
+	s.User = toDTO(ctx, user)
+	s.User.PossibleDuplicate, s.User.Duplicates = len(duplicates) > 0, duplicates
 
 	return nil
 }
 
-// GetByID retrieves a user by ID
-func (s *UserService) GetByID(ctx context.Context, id string) error {
-	user := users.New()
+// GetByID retrieves a user by ID, optionally including soft-deleted users
+func (s *UserService) GetByID(ctx context.Context, id string, includeDeleted bool) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetByID")
+	defer func() { tracing.End(span, err) }()
 
 	// Parse UUID
 	parsedID, err := uuid.Parse(id)
 	if err != nil {
 		return ErrInvalidUUID
 	}
-	user.ID = parsedID
 
-	if err := user.GetByID(ctx); err != nil {
+	user, err := s.repo.GetByID(ctx, parsedID, includeDeleted)
+	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return ErrUserNotFound
 		}
 		return err
 	}
 
-	// Map to DTO
-	s.User = &dto.User{
-		ID:                   user.ID,
-		AadhaarApplicationID: user.AadhaarApplicationID,
-		Name:                 user.Name,
-		Email:                user.Email,
-		Phone:                user.Phone,
-		Address:              user.Address,
-		DateOfBirth:          user.DateOfBirth,
-		Gender:               user.Gender,
-		CreatedAt:            &user.CreatedAt,
-		UpdatedAt:            &user.UpdatedAt,
+	if err := s.audit.Record(ctx, audit.Entry{
+		Action:       audit.ActionRead,
+		TargetUserID: &user.ID,
+	}); err != nil {
+		return err
 	}
 
+	// Map to DTO
+	s.User = toDTO(ctx, user)
+
 	return nil
 }
 
-// GetAllPaginated retrieves users with pagination
-func (s *UserService) GetAllPaginated(ctx context.Context, params dto.PaginationParams) error {
-	user := users.New()
+// GetAllPaginated retrieves users with pagination. Users.Total is only set when params.WithTotal
+// is, and NextPage and PrevPage are the positions the adjacent pages are listed from.
+func (s *UserService) GetAllPaginated(ctx context.Context, params dto.PaginationParams) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetAllPaginated")
+	defer func() { tracing.End(span, err) }()
 
-	userList, total, err := user.GetAllPaginated(ctx, params)
+	page, err := s.repo.List(ctx, params)
 	if err != nil {
 		return err
 	}
+	userList := page.Users
+
+	// Record which users were disclosed; search terms and filters may hold PII and are left out
+	userIDs := make([]string, len(userList))
+	for i, u := range userList {
+		userIDs[i] = u.ID.String()
+	}
+	details := map[string]any{
+		"limit":           params.Limit,
+		"include_deleted": params.IncludeDeleted,
+		"user_ids":        userIDs,
+	}
+	if params.Cursor == nil {
+		details["page"] = params.Page
+	}
+	if err := s.audit.Record(ctx, audit.Entry{
+		Action:  audit.ActionList,
+		Details: details,
+	}); err != nil {
+		return err
+	}
 
 	// Map to DTOs
 	userDTOs := make([]dto.User, len(userList))
 	for i, u := range userList {
-		userDTOs[i] = dto.User{
-			ID:                   u.ID,
-			AadhaarApplicationID: u.AadhaarApplicationID,
-			Name:                 u.Name,
-			Email:                u.Email,
-			Phone:                u.Phone,
-			Address:              u.Address,
-			DateOfBirth:          u.DateOfBirth,
-			Gender:               u.Gender,
-			CreatedAt:            &u.CreatedAt,
-			UpdatedAt:            &u.UpdatedAt,
+		userDTOs[i] = *toDTO(ctx, &u)
+	}
+
+	s.Users = &dto.Users{
+		Users: userDTOs,
+		Limit: params.Limit,
+	}
+	if params.Cursor == nil {
+		s.Users.Page = params.Page
+	}
+	if params.WithTotal {
+		// Calculate total pages
+		totalPages := int(math.Ceil(float64(page.Total) / float64(params.Limit)))
+		s.Users.Total, s.Users.TotalPages = &page.Total, &totalPages
+	}
+
+	// Adjacent pages are listed from the first and last rows of this one. Going forwards, More
+	// tells whether a next page exists; a previous one exists after a cursor or past page 1.
+	s.NextPage, s.PrevPage = nil, nil
+	if len(userList) > 0 {
+		first, last := userList[0].Cursor(params, true), userList[len(userList)-1].Cursor(params, false)
+		backward := params.Cursor != nil && params.Cursor.Backward
+		if backward || page.More {
+			s.NextPage = &last
+		}
+		if (backward && page.More) || (!backward && (params.Cursor != nil || params.Page > 1)) {
+			s.PrevPage = &first
 		}
 	}
 
-	// Calculate total pages
-	totalPages := int(math.Ceil(float64(total) / float64(params.Limit)))
+	return nil
+}
 
-	s.Users = &dto.Users{
-		Users:      userDTOs,
-		Total:      total,
-		Page:       params.Page,
-		Limit:      params.Limit,
-		TotalPages: totalPages,
+// GetUpdate returns the current, unmasked editable fields of a user, as a base for partial updates
+func (s *UserService) GetUpdate(ctx context.Context, id string) (_ dto.UserUpdate, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUpdate")
+	defer func() { tracing.End(span, err) }()
+
+	// Parse UUID
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return dto.UserUpdate{}, ErrInvalidUUID
+	}
+
+	user, err := s.repo.GetByID(ctx, parsedID, false)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return dto.UserUpdate{}, ErrUserNotFound
+		}
+		return dto.UserUpdate{}, err
+	}
+
+	return dto.NewUserUpdate(*mapUser(user)), nil
+}
+
+// Update replaces the editable fields of an existing user
+func (s *UserService) Update(ctx context.Context, id string, input dto.UserUpdate) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.Update")
+	defer func() { tracing.End(span, err) }()
+
+	// Parse UUID
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrInvalidUUID
+	}
+
+	// Check if user exists
+	user, err := s.repo.GetByID(ctx, parsedID, false)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ErrUserNotFound
+		}
+		return err
 	}
 
+	// Parse date of birth
+	dob, err := dto.ParseDate(input.DateOfBirth)
+	if err != nil {
+		return ErrInvalidDOB
+	}
+
+	// Check if email is taken by another user
+	if existingUser, err := s.repo.GetByEmail(ctx, input.Email); err == nil && existingUser.ID != user.ID {
+		return s.duplicate(ErrEmailExists, "update")
+	}
+
+	// Check if Aadhaar Application ID is taken by another user
+	if existingUser, err := s.repo.GetByAadhaarApplicationID(ctx, input.AadhaarApplicationID); err == nil && existingUser.ID != user.ID {
+		return s.duplicate(ErrAadhaarIDExists, "update")
+	}
+
+	before := auditValues(user)
+
+	user.AadhaarApplicationID = input.AadhaarApplicationID
+	user.Name = input.Name
+	user.Email = input.Email
+	user.Phone = input.Phone
+	user.Address = input.Address
+	user.AddressDetails = toAddressModel(input.AddressDetails)
+	if user.AddressDetails != nil {
+		user.Address = addressLine(user.AddressDetails)
+	}
+	user.DateOfBirth = dob
+	user.Gender = input.Gender
+
+	if err := s.repo.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, user); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrUserNotFound
+			}
+			return s.conflict(err, "update")
+		}
+
+		return s.audit.Record(ctx, audit.Entry{
+			Action:        audit.ActionUpdate,
+			TargetUserID:  &user.ID,
+			ChangedFields: s.audit.Changes(before, auditValues(user)),
+		})
+	}); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("User updated", slog.String("user_id", user.ID.String()))
+
+	// Map to DTO
+	s.User = toDTO(ctx, user)
+
 	return nil
 }
 
-// Delete removes a user by ID
-func (s *UserService) Delete(ctx context.Context, id string) error {
-	user := users.New()
+// Delete soft-deletes a user by ID
+func (s *UserService) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.Delete")
+	defer func() { tracing.End(span, err) }()
 
 	// Parse UUID
 	parsedID, err := uuid.Parse(id)
 	if err != nil {
 		return ErrInvalidUUID
 	}
-	user.ID = parsedID
 
 	// Check if user exists
-	if err := user.GetByID(ctx); err != nil {
+	user, err := s.repo.GetByID(ctx, parsedID, false)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	if err := s.repo.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, user.ID); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		return s.audit.Record(ctx, audit.Entry{
+			Action:       audit.ActionDelete,
+			TargetUserID: &user.ID,
+		})
+	}); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("User deleted", slog.String("user_id", user.ID.String()))
+
+	return nil
+}
+
+// Restore brings back a soft-deleted user by ID
+func (s *UserService) Restore(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.Restore")
+	defer func() { tracing.End(span, err) }()
+
+	// Parse UUID
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrInvalidUUID
+	}
+
+	user, err := s.repo.GetByID(ctx, parsedID, true)
+	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return ErrUserNotFound
 		}
 		return err
 	}
 
-	return user.Delete(ctx)
+	if !user.DeletedAt.Valid {
+		return ErrUserNotDeleted
+	}
+
+	// A live user may have claimed the email or Aadhaar Application ID meanwhile
+	if _, err := s.repo.GetByEmail(ctx, user.Email); err == nil {
+		return s.duplicate(ErrEmailExists, "restore")
+	}
+
+	if _, err := s.repo.GetByAadhaarApplicationID(ctx, user.AadhaarApplicationID); err == nil {
+		return s.duplicate(ErrAadhaarIDExists, "restore")
+	}
+
+	if err := s.repo.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Restore(ctx, user); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrUserNotDeleted
+			}
+			return s.conflict(err, "restore")
+		}
+
+		return s.audit.Record(ctx, audit.Entry{
+			Action:       audit.ActionRestore,
+			TargetUserID: &user.ID,
+		})
+	}); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("User restored", slog.String("user_id", user.ID.String()))
+
+	// Map to DTO
+	s.User = toDTO(ctx, user)
+
+	return nil
+}
+
+// Purge permanently removes users that were soft-deleted more than olderThanDays ago
+func (s *UserService) Purge(ctx context.Context, params dto.PurgeParams) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.Purge")
+	defer func() { tracing.End(span, err) }()
+
+	before := time.Now().AddDate(0, 0, -params.OlderThanDays)
+
+	var purged int64
+	if err := s.repo.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		if purged, err = s.repo.Purge(ctx, before); err != nil {
+			return err
+		}
+
+		return s.audit.Record(ctx, audit.Entry{
+			Action: audit.ActionPurge,
+			Details: map[string]any{
+				"purged": purged,
+				"before": before.UTC().Format(time.RFC3339),
+			},
+		})
+	}); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("Purged deleted users", slog.Int64("purged", purged), slog.Time("before", before))
+
+	s.Purged = &dto.PurgeResult{
+		Purged: purged,
+		Before: before,
+	}
+
+	return nil
+}
+
+// RotateKeys re-encrypts all PII that is not yet under the active master key
+func (s *UserService) RotateKeys(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.RotateKeys")
+	defer func() { tracing.End(span, err) }()
+
+	for {
+		n, err := s.repo.ReencryptBatch(ctx, rotateBatchSize)
+		s.Reencrypted += n
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+		logging.FromContext(ctx).Debug("Re-encrypted batch", slog.Int("rows", n), slog.Int("total", s.Reencrypted))
+	}
+}
+
+// Reindex fills in the search words and phonetic name keys of users written before they existed
+func (s *UserService) Reindex(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.Reindex")
+	defer func() { tracing.End(span, err) }()
+
+	for {
+		n, err := s.repo.ReindexBatch(ctx, reindexBatchSize)
+		s.Reindexed += n
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+		logging.FromContext(ctx).Debug("Reindexed batch", slog.Int("rows", n), slog.Int("total", s.Reindexed))
+	}
+}
+
+// duplicate counts a rejected duplicate email, Aadhaar Application ID or applicant and returns err
+func (s *UserService) duplicate(err error, operation string) error {
+	field := metrics.FieldEmail
+	switch err {
+	case ErrAadhaarIDExists:
+		field = metrics.FieldAadhaarApplicationID
+	case ErrLikelyDuplicate:
+		field = metrics.FieldApplicant
+	}
+	s.metrics.DuplicateRejected(field, operation)
+	return err
+}
+
+// conflict translates a unique violation reported by the repository, e.g. from a concurrent
+// request that passed the same checks, into ErrEmailExists or ErrAadhaarIDExists
+func (s *UserService) conflict(err error, operation string) error {
+	switch {
+	case errors.Is(err, users.ErrDuplicateEmail):
+		return s.duplicate(ErrEmailExists, operation)
+	case errors.Is(err, users.ErrDuplicateAadhaarID):
+		return s.duplicate(ErrAadhaarIDExists, operation)
+	}
+	return err
+}
+
+// toDTO maps a user model to its response DTO, masking PII the caller may not see
+func toDTO(ctx context.Context, u *users.User) *dto.User {
+	user := mapUser(u)
+	masking.User(ctx, user)
+	return user
+}
+
+// mapUser maps a user model to its unmasked DTO
+func mapUser(u *users.User) *dto.User {
+	user := &dto.User{
+		ID:                   u.ID,
+		AadhaarApplicationID: u.AadhaarApplicationID,
+		Name:                 u.Name,
+		Email:                u.Email,
+		Phone:                u.Phone,
+		Address:              u.Address,
+		DateOfBirth:          u.DateOfBirth.Format(dto.DateLayout),
+		Gender:               u.Gender,
+		CreatedAt:            &u.CreatedAt,
+		UpdatedAt:            &u.UpdatedAt,
+		Score:                u.Score,
+	}
+	age := dto.Age(u.DateOfBirth, time.Now())
+	user.Age = &age
+	if u.DeletedAt.Valid {
+		user.DeletedAt = &u.DeletedAt.Time
+	}
+	if u.AddressDetails != nil {
+		user.AddressDetails = &dto.Address{
+			House:       u.AddressDetails.House,
+			Street:      u.AddressDetails.Street,
+			Locality:    u.AddressDetails.Locality,
+			VillageTown: u.AddressDetails.VillageTown,
+			District:    u.AddressDetails.District,
+			State:       u.AddressDetails.State,
+			PinCode:     u.AddressDetails.PinCode,
+		}
+	}
+	return user
+}
+
+// auditValues returns the editable fields of a user as recorded (hashed) in the audit trail
+func auditValues(u *users.User) map[string]string {
+	user := mapUser(u)
+	values := map[string]string{
+		"aadhaar_application_id": user.AadhaarApplicationID,
+		"name":                   user.Name,
+		"email":                  user.Email,
+		"phone":                  user.Phone,
+		"address":                user.Address,
+		"date_of_birth":          user.DateOfBirth,
+		"gender":                 user.Gender,
+	}
+	if d := user.AddressDetails; d != nil {
+		values["address_details"] = strings.Join([]string{d.House, d.Street, d.Locality, d.VillageTown, d.District, d.State, d.PinCode}, "\x1f")
+	}
+	return values
+}
+
+// toAddressModel maps a structured address DTO to its model, canonicalizing the state and
+// district names
+func toAddressModel(a *dto.Address) *users.Address {
+	if a == nil {
+		return nil
+	}
+
+	state := a.State
+	if s, ok := geo.LookupState(a.State); ok {
+		state = s.Name
+	}
+	district := strings.TrimSpace(a.District)
+	if d, ok := geo.LookupDistrict(a.District, a.State); ok {
+		district = d
+	}
+
+	return &users.Address{
+		House:       strings.TrimSpace(a.House),
+		Street:      strings.TrimSpace(a.Street),
+		Locality:    strings.TrimSpace(a.Locality),
+		VillageTown: strings.TrimSpace(a.VillageTown),
+		District:    district,
+		State:       state,
+		PinCode:     a.PinCode,
+	}
+}
+
+// addressLine formats a structured address as the single-line address kept for compatibility
+func addressLine(a *users.Address) string {
+	return dto.Address{
+		House:       a.House,
+		Street:      a.Street,
+		Locality:    a.Locality,
+		VillageTown: a.VillageTown,
+		District:    a.District,
+		State:       a.State,
+		PinCode:     a.PinCode,
+	}.String()
 }
@@ -0,0 +1,115 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"aadhaar-user-service/internals/dedup"
+	"aadhaar-user-service/internals/dto"
+	modelaudit "aadhaar-user-service/models/audit"
+	"aadhaar-user-service/models/users"
+	"aadhaar-user-service/services/audit"
+
+	"gorm.io/gorm"
+)
+
+var errAppend = errors.New("audit trail unavailable")
+
+// failingTrail is an audit trail whose appends fail while failing is set
+type failingTrail struct {
+	*modelaudit.MemoryRepository
+	failing bool
+}
+
+func (t *failingTrail) Append(ctx context.Context, e *modelaudit.Event) error {
+	if t.failing {
+		return errAppend
+	}
+	return t.MemoryRepository.Append(ctx, e)
+}
+
+func applicant() dto.UserCreate {
+	return dto.UserCreate{
+		AadhaarApplicationID: "20000000000001",
+		Name:                 "Asha Rao",
+		Email:                "asha@example.com",
+		Phone:                "9800000001",
+		Address:              "12 MG Road, Bengaluru",
+		DateOfBirth:          "1990-01-02",
+		Gender:               "female",
+	}
+}
+
+func TestChangesRollBackWithoutAuditEntry(t *testing.T) {
+	ctx := context.Background()
+	repo := users.NewMemoryRepository(nil)
+	trail := &failingTrail{MemoryRepository: modelaudit.NewMemoryRepository()}
+	service := func() *UserService { return New(repo, audit.NewRecorder(trail, nil, nil), nil) }
+	policy := dedup.Policy{Mode: dedup.Off}
+
+	trail.failing = true
+	if err := service().Create(ctx, applicant(), policy); !errors.Is(err, errAppend) {
+		t.Fatalf("create: error %v, want %v", err, errAppend)
+	}
+	if _, err := repo.GetByEmail(ctx, applicant().Email); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("user stored without its audit entry: %v", err)
+	}
+
+	trail.failing = false
+	created := service()
+	if err := created.Create(ctx, applicant(), policy); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	id := created.User.ID.String()
+
+	trail.failing = true
+	update := applicant()
+	update.Name = "Asha Iyer"
+	if err := service().Update(ctx, id, dto.UserUpdate(update)); !errors.Is(err, errAppend) {
+		t.Fatalf("update: error %v, want %v", err, errAppend)
+	}
+	if err := service().Delete(ctx, id); !errors.Is(err, errAppend) {
+		t.Fatalf("delete: error %v, want %v", err, errAppend)
+	}
+
+	user, err := repo.GetByID(ctx, created.User.ID, false)
+	if err != nil {
+		t.Fatalf("user deleted without its audit entry: %v", err)
+	}
+	if user.Name != "Asha Rao" {
+		t.Errorf("name %q updated without its audit entry", user.Name)
+	}
+}
+
+func TestDuplicateSamePhoneAndBirth(t *testing.T) {
+	ctx := context.Background()
+	repo := users.NewMemoryRepository(nil)
+	trail := modelaudit.NewMemoryRepository()
+	policy := dedup.Policy{Mode: dedup.Strict, Threshold: 0.85}
+
+	enrolled := applicant()
+	enrolled.Name = "Xavier Dsouza"
+	enrolled.Gender = "male"
+	if err := New(repo, audit.NewRecorder(trail, nil, nil), nil).Create(ctx, enrolled, policy); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// The names share no phonetic key, only the phone number and date of birth find the candidate
+	again := enrolled
+	again.AadhaarApplicationID = "20000000000002"
+	again.Email = "zavier@example.com"
+	again.Name = "Zavier D'Souza"
+	service := New(repo, audit.NewRecorder(trail, nil, nil), nil)
+	if err := service.Create(ctx, again, policy); !errors.Is(err, ErrLikelyDuplicate) {
+		t.Fatalf("create: error %v, want %v", err, ErrLikelyDuplicate)
+	}
+	if service.Duplicates == nil || len(service.Duplicates.Duplicates) != 1 {
+		t.Fatalf("duplicates %+v, want the enrolled user", service.Duplicates)
+	}
+
+	again.Phone = "9899999999"
+	if err := New(repo, audit.NewRecorder(trail, nil, nil), nil).Create(ctx, again, policy); err != nil {
+		t.Fatalf("create with another phone: %v", err)
+	}
+}